@@ -0,0 +1,87 @@
+package api
+
+import (
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// File extensions that are considered as videos when filtering posts
+var videoExtensions = map[string]bool{
+	".mp4":  true,
+	".m4v":  true,
+	".mov":  true,
+	".webm": true,
+	".mkv":  true,
+	".avi":  true,
+	".wmv":  true,
+	".flv":  true,
+}
+
+var (
+	requireVideo      bool
+	requireAttachment bool
+	requireGdrive     bool
+	filteredPosts     atomic.Int64
+)
+
+// SetContentFilters sets the content types that a post must have to be downloaded
+func SetContentFilters(video, attachment, gdrive bool) {
+	requireVideo = video
+	requireAttachment = attachment
+	requireGdrive = gdrive
+}
+
+// ContentFiltersEnabled returns true if any of the content filters are set
+func ContentFiltersEnabled() bool {
+	return requireVideo || requireAttachment || requireGdrive
+}
+
+// PostContent contains the content types of a post that are detected from its metadata
+type PostContent struct {
+	HasVideo      bool
+	HasAttachment bool
+	HasGdrive     bool
+}
+
+// AddAttachment marks the post as having an attachment
+// and as having a video if the attachment's filename has a video extension.
+func (c *PostContent) AddAttachment(filename string) {
+	c.HasAttachment = true
+	c.AddMedia(filename)
+}
+
+// AddMedia marks the post as having a video if the filename has a video extension
+func (c *PostContent) AddMedia(filename string) {
+	if videoExtensions[strings.ToLower(filepath.Ext(filename))] {
+		c.HasVideo = true
+	}
+}
+
+// AddText marks the post as having a Google Drive link if the text contains one
+func (c *PostContent) AddText(text string) {
+	if utils.GDRIVE_URL_REGEX.MatchString(text) {
+		c.HasGdrive = true
+	}
+}
+
+// PostLacksContent returns true if the post does not have all the content types
+// required by SetContentFilters and should be skipped.
+//
+// The number of skipped posts can be retrieved with FilteredPostCount.
+func PostLacksContent(content *PostContent) bool {
+	if (requireVideo && !content.HasVideo) ||
+		(requireAttachment && !content.HasAttachment) ||
+		(requireGdrive && !content.HasGdrive) {
+		filteredPosts.Add(1)
+		return true
+	}
+	return false
+}
+
+// FilteredPostCount returns the number of posts skipped by PostLacksContent
+func FilteredPostCount() int64 {
+	return filteredPosts.Load()
+}
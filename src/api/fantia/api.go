@@ -69,9 +69,12 @@ func getFantiaPostDetails(postArg *fantiaPostArgs, dlOptions *FantiaDlOptions) (
 	)
 	if err != nil || res.StatusCode != 200 {
 		errCode := utils.CONNECTION_ERROR
+		logFields := utils.LogFields{Url: postApiUrl, PostId: postArg.postId}
 		if err == nil {
 			errCode = res.StatusCode
+			logFields.Status = res.StatusCode
 		}
+		logFields.Code = errCode
 
 		errMsg := fmt.Sprintf(
 			"fantia error %d: failed to get post details for %s",
@@ -89,7 +92,7 @@ func getFantiaPostDetails(postArg *fantiaPostArgs, dlOptions *FantiaDlOptions) (
 		}
 
 		progress.Stop(true)
-		return nil, err
+		return nil, utils.WithLogFields(err, logFields)
 	}
 
 	progress.Stop(false)
@@ -269,7 +272,7 @@ func dlFantiaPost(count, maxCount int, postId string, dlOptions *FantiaDlOptions
 		},
 		dlOptions.Configs,
 	)
-	fmt.Println()
+	fmt.Fprintln(color.Output)
 	return postGdriveUrls, nil
 }
 
@@ -303,7 +306,7 @@ func (f *FantiaDl) dlFantiaPosts(dlOptions *FantiaDlOptions) []*request.ToDownlo
 // Parse the HTML response from the creator's page to get the post IDs.
 func parseCreatorHtml(res *http.Response, creatorId string) ([]string, error) {
 	// parse the response
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(utils.LimitResBody(res))
 	res.Body.Close()
 	if err != nil {
 		err = fmt.Errorf(
@@ -473,3 +476,28 @@ func (f *FantiaDl) getCreatorsPosts(dlOptions *FantiaDlOptions) {
 	}
 	f.PostIds = utils.RemoveSliceDuplicates(f.PostIds)
 }
+
+// Checks if the Fantia post was deleted after it was listed by querying
+// the post's API which responds with a 404 status code for such posts.
+func isPostDeleted(postId string, dlOptions *FantiaDlOptions) bool {
+	useHttp3 := utils.IsHttp3Supported(utils.FANTIA, true)
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:  "GET",
+			Url:     fantiaPostUrl + postId,
+			Cookies: dlOptions.SessionCookies,
+			Headers: map[string]string{
+				"Referer":      fmt.Sprintf("%s/posts/%s", utils.FANTIA_URL, postId),
+				"x-csrf-token": dlOptions.CsrfToken,
+			},
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+			UserAgent: dlOptions.Configs.UserAgent,
+		},
+	)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+	return res.StatusCode == http.StatusNotFound
+}
@@ -104,7 +104,7 @@ func (f *FantiaDlOptions) GetCsrfToken(userAgent string) error {
 	}
 
 	// parse the response
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	doc, err := goquery.NewDocumentFromReader(utils.LimitResBody(res))
 	if err != nil {
 		return fmt.Errorf(
 			"fantia error %d, failed to parse response body when getting CSRF token from Fantia: %w", 
@@ -1,6 +1,7 @@
 package fantia
 
 import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
@@ -11,6 +12,11 @@ func FantiaDownloadProcess(fantiaDl *FantiaDl, fantiaDlOptions *FantiaDlOptions)
 		return
 	}
 
+	fantiaDl.FanclubIds, fantiaDl.FanclubPageNums = api.FilterNewCreators(
+		utils.FANTIA,
+		fantiaDl.FanclubIds,
+		fantiaDl.FanclubPageNums,
+	)
 	if len(fantiaDl.FanclubIds) > 0 {
 		fantiaDl.getCreatorsPosts(fantiaDlOptions)
 	}
@@ -23,7 +29,9 @@ func FantiaDownloadProcess(fantiaDl *FantiaDl, fantiaDlOptions *FantiaDlOptions)
 	}
 
 	if fantiaDlOptions.GdriveClient != nil && len(gdriveLinks) > 0 {
-		fantiaDlOptions.GdriveClient.DownloadGdriveUrls(gdriveLinks, fantiaDlOptions.Configs)
+		request.DeferUntilPlanned(func() {
+			fantiaDlOptions.GdriveClient.DownloadGdriveUrls(gdriveLinks, fantiaDlOptions.Configs)
+		})
 		downloadedPosts = true
 	}
 
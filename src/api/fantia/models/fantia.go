@@ -30,11 +30,13 @@ type FantiaPost struct {
 			Original string `json:"original"`
 		} `json:"thumb"`
 		Fanclub struct {
+			ID   int `json:"id"`
 			User struct {
 				Name string `json:"name"`
 			} `json:"user"`
 		} `json:"fanclub"`
 		Status       string `json:"status"`
+		PostedAt     string `json:"posted_at"`
 		PostContents []FantiaContent `json:"post_contents"`
 	} `json:"post"`
 	Redirect string `json:"redirect"` // if get flagged by the system, it will redirect to this recaptcha url
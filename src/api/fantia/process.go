@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/fantia/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
@@ -63,6 +64,21 @@ func dlAttachmentsFromPost(content *models.FantiaContent, postFolderPath string)
 	return urlsSlice
 }
 
+// Detects the content types of the Fantia post for the content filters
+func getPostContent(postJson *models.FantiaPost) *api.PostContent {
+	content := &api.PostContent{}
+	content.AddText(postJson.Post.Comment)
+	for _, postContent := range postJson.Post.PostContents {
+		content.AddText(postContent.Comment)
+		if postContent.AttachmentURI != "" {
+			content.AddAttachment(postContent.AttachmentURI)
+		} else if postContent.DownloadUri != "" {
+			content.AddAttachment(postContent.Filename)
+		}
+	}
+	return content
+}
+
 var errRecaptcha = fmt.Errorf("recaptcha detected for the current session")
 
 // Process the JSON response from Fantia's API and
@@ -87,18 +103,28 @@ func processFantiaPost(res *http.Response, downloadPath string, dlOptions *Fanti
 	}
 
 	post := postJson.Post
+	if api.PostIsTooOld(post.PostedAt) {
+		return nil, nil, nil
+	}
+	if api.ContentFiltersEnabled() && api.PostLacksContent(getPostContent(&postJson)) {
+		return nil, nil, nil
+	}
+
 	postId := strconv.Itoa(post.ID)
 	postTitle := post.Title
 	creatorName := post.Fanclub.User.Name
-	postFolderPath := utils.GetPostFolder(
-		filepath.Join(
-			downloadPath,
-			utils.FANTIA_TITLE,
-		),
-		creatorName,
-		postId,
-		postTitle,
+	postFolderPath := utils.GetTemplatedPostFolder(
+		downloadPath,
+		utils.FANTIA_TITLE,
+		&utils.PostFolderInfo{
+			Platform:    utils.FANTIA_TITLE,
+			CreatorName: creatorName,
+			PostId:      postId,
+			Title:       postTitle,
+			Date:        post.PostedAt,
+		},
 	)
+	api.RecordPost(utils.FANTIA_TITLE, postTitle, post.PostedAt, postFolderPath)
 
 	var urlsSlice []*request.ToDownload
 	thumbnail := post.Thumb.Original
@@ -116,8 +142,16 @@ func processFantiaPost(res *http.Response, downloadPath string, dlOptions *Fanti
 		dlOptions.Configs.LogUrls,
 	)
 
+	creator := utils.FANTIA + "/" + strconv.Itoa(post.Fanclub.ID)
+	postDeleted := func() bool {
+		return isPostDeleted(postId, dlOptions)
+	}
 	postContent := post.PostContents
 	if postContent == nil {
+		request.SetCreator(urlsSlice, creator)
+		request.SetPost(urlsSlice, postFolderPath)
+		request.SetPostDate(urlsSlice, post.PostedAt)
+		request.SetPostDeletedCheck(urlsSlice, "Fantia post "+postId, postDeleted)
 		return urlsSlice, gdriveLinks, nil
 	}
 	for _, content := range postContent {
@@ -137,6 +171,12 @@ func processFantiaPost(res *http.Response, downloadPath string, dlOptions *Fanti
 			urlsSlice = append(urlsSlice, dlAttachmentsFromPost(&content, postFolderPath)...)
 		}
 	}
+	request.AddIndexPrefixes(urlsSlice)
+	urlsSlice = request.SelectFileIndices(urlsSlice, postFolderPath)
+	request.SetCreator(urlsSlice, creator)
+	request.SetPost(urlsSlice, postFolderPath)
+	request.SetPostDate(urlsSlice, post.PostedAt)
+	request.SetPostDeletedCheck(urlsSlice, "Fantia post "+postId, postDeleted)
 	return urlsSlice, gdriveLinks, nil
 }
 
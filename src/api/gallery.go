@@ -0,0 +1,55 @@
+package api
+
+import (
+	"sync"
+)
+
+// GalleryPost is a processed post that will be shown in the index.html gallery
+type GalleryPost struct {
+	Platform   string
+	Title      string
+	Date       string
+	FolderPath string
+}
+
+var (
+	recordPosts    bool
+	galleryMu      sync.Mutex
+	galleryPosts   []*GalleryPost
+	galleryFolders = make(map[string]bool)
+)
+
+// SetRecordPosts sets whether the processed posts should be recorded for the index.html gallery
+func SetRecordPosts(record bool) {
+	recordPosts = record
+}
+
+// RecordPost records the processed post so that its downloaded files can be shown in the index.html gallery
+func RecordPost(platform, title, date, folderPath string) {
+	if !recordPosts {
+		return
+	}
+
+	galleryMu.Lock()
+	defer galleryMu.Unlock()
+	if galleryFolders[folderPath] {
+		return
+	}
+	galleryFolders[folderPath] = true
+	galleryPosts = append(galleryPosts, &GalleryPost{
+		Platform:   platform,
+		Title:      title,
+		Date:       date,
+		FolderPath: folderPath,
+	})
+}
+
+// GetRecordedPosts returns the posts recorded by RecordPost in the order they were processed
+func GetRecordedPosts() []*GalleryPost {
+	galleryMu.Lock()
+	defer galleryMu.Unlock()
+
+	posts := make([]*GalleryPost, len(galleryPosts))
+	copy(posts, galleryPosts)
+	return posts
+}
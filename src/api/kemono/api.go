@@ -2,9 +2,11 @@ package kemono
 
 import (
 	"fmt"
+	"net/http"
 	"sync"
 	"strconv"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
@@ -131,15 +133,22 @@ func getMultiplePosts(posts []*models.KemonoPostToDl, downloadPath string, dlOpt
 	return urlsToDownload, gdriveLinks
 }
 
-func getCreatorPosts(creator *models.KemonoCreatorToDl, downloadPath string, dlOptions *KemonoDlOptions) ([]*request.ToDownload, []*request.ToDownload, error) {
+// Returns all the posts JSON of the creator within the given page numbers
+func getCreatorPostsJson(creator *models.KemonoCreatorToDl, dlOptions *KemonoDlOptions) (models.KemonoJson, error) {
+	return getCreatorPostsJsonFromApi(utils.KEMONO_API_URL, creator, dlOptions)
+}
+
+// Same as getCreatorPostsJson but with the base URL of the API, e.g. to test against a mock API
+func getCreatorPostsJsonFromApi(apiUrl string, creator *models.KemonoCreatorToDl, dlOptions *KemonoDlOptions) (models.KemonoJson, error) {
 	useHttp3 := utils.IsHttp3Supported(utils.KEMONO, true)
 	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(creator.PageNum)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	minOffset, maxOffset := utils.ConvertPageNumToOffset(minPage, maxPage, utils.KEMONO_PER_PAGE)
 
-	var postsToDl, gdriveLinksToDl []*request.ToDownload
+	var posts models.KemonoJson
+	seenPosts := make(map[string]struct{})
 	params := make(map[string]string)
 	curOffset := minOffset
 	for {
@@ -148,7 +157,7 @@ func getCreatorPosts(creator *models.KemonoCreatorToDl, downloadPath string, dlO
 			&request.RequestArgs{
 				Url: fmt.Sprintf(
 					"%s/%s/user/%s",
-					utils.KEMONO_API_URL,
+					apiUrl,
 					creator.Service,
 					creator.CreatorId,
 				),
@@ -163,27 +172,37 @@ func getCreatorPosts(creator *models.KemonoCreatorToDl, downloadPath string, dlO
 			},
 		)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		var resJson models.KemonoJson
 		if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		if len(resJson) == 0 {
 			break
 		}
-
-		posts, gdriveLinks := processMultipleJson(resJson, downloadPath, dlOptions)
-		postsToDl = append(postsToDl, posts...)
-		gdriveLinksToDl = append(gdriveLinksToDl, gdriveLinks...)
+		// the pages may overlap so the posts that were already returned are skipped
+		posts = append(posts, utils.RemoveSeenItems(resJson, seenPosts, func(post *models.MainKemonoJson) string {
+			return post.Service + "/" + post.Id
+		})...)
 
 		if (hasMax && curOffset >= maxOffset) {
 			break
 		}
 		curOffset += 25
 	}
+	return posts, nil
+}
+
+func getCreatorPosts(creator *models.KemonoCreatorToDl, downloadPath string, dlOptions *KemonoDlOptions) ([]*request.ToDownload, []*request.ToDownload, error) {
+	resJson, err := getCreatorPostsJson(creator, dlOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postsToDl, gdriveLinksToDl := processMultipleJson(resJson, downloadPath, dlOptions)
 	return postsToDl, gdriveLinksToDl, nil
 }
 
@@ -231,6 +250,33 @@ func getMultipleCreators(creators []*models.KemonoCreatorToDl, downloadPath stri
 	return urlsToDownload, gdriveLinks
 }
 
+// Sends the files of each creator's posts to the channel as soon as the creator's posts have been listed
+// so that their downloads can start while the posts of the rest of the creators are still being listed.
+//
+// Returns the number of files that were sent and the Google Drive links of the posts.
+func streamMultipleCreators(creators []*models.KemonoCreatorToDl, downloadPath string, dlOptions *KemonoDlOptions, urlInfoChan chan<- []*request.ToDownload) (int, []*request.ToDownload) {
+	var errSlice []error
+	var gdriveLinks []*request.ToDownload
+	filesSent := 0
+	for _, creator := range creators {
+		postsToDl, gdriveLinksToDl, err := getCreatorPosts(creator, downloadPath, dlOptions)
+		if err != nil {
+			errSlice = append(errSlice, err)
+			continue
+		}
+		if len(postsToDl) > 0 {
+			urlInfoChan <- postsToDl
+			filesSent += len(postsToDl)
+		}
+		gdriveLinks = append(gdriveLinks, gdriveLinksToDl...)
+	}
+
+	if len(errSlice) > 0 {
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	return filesSent, gdriveLinks
+}
+
 func processFavCreator(resJson models.KemonoFavCreatorJson) []*models.KemonoCreatorToDl {
 	var creators []*models.KemonoCreatorToDl
 	for _, creator := range resJson {
@@ -268,7 +314,7 @@ func getFavourites(downloadPath string, dlOptions *KemonoDlOptions) ([]*request.
 	if err := utils.LoadJsonFromResponse(res, &creatorResJson); err != nil {
 		return nil, nil, err
 	}
-	artistToDl := processFavCreator(creatorResJson)
+	artistToDl := filterNewCreators(processFavCreator(creatorResJson))
 
 	reqArgs.Params = map[string]string{
 		"type": "post",
@@ -290,3 +336,56 @@ func getFavourites(downloadPath string, dlOptions *KemonoDlOptions) ([]*request.
 
 	return urlsToDownload, gdriveLinks, nil
 }
+
+// Filters out the creators that were already downloaded by a previous successful run
+// if the only new creators mode is enabled, otherwise all the creators are returned.
+func filterNewCreators(creators []*models.KemonoCreatorToDl) []*models.KemonoCreatorToDl {
+	var newCreators []*models.KemonoCreatorToDl
+	for _, creator := range creators {
+		if api.IsNewCreator(utils.KEMONO, creator.Service+"/"+creator.CreatorId) {
+			newCreators = append(newCreators, creator)
+		}
+	}
+	api.LogSkippedCreators(utils.KEMONO, len(creators)-len(newCreators))
+	return newCreators
+}
+
+// Checks if the Kemono Party post was deleted after it was listed by querying the post's API
+// which responds with a 404 status code or an empty JSON array for such posts.
+func isPostDeleted(service, creatorId, postId string, dlOptions *KemonoDlOptions) bool {
+	useHttp3 := utils.IsHttp3Supported(utils.KEMONO, true)
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Url: fmt.Sprintf(
+				"%s/%s/user/%s/post/%s",
+				utils.KEMONO_API_URL,
+				service,
+				creatorId,
+				postId,
+			),
+			Method:    "GET",
+			Headers:   getKemonoPartyHeaders(),
+			UserAgent: dlOptions.Configs.UserAgent,
+			Cookies:   dlOptions.SessionCookies,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return false
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return true
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return false
+	}
+
+	var resJson models.KemonoJson
+	if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+		return false
+	}
+	return len(resJson) == 0
+}
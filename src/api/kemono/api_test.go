@@ -0,0 +1,50 @@
+package kemono
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+)
+
+func TestGetCreatorPostsSkipsOverlappingPages(t *testing.T) {
+	// the posts of each page by offset where the adjacent pages overlap
+	pages := map[string][]string{
+		"0":  {"5", "4", "3"},
+		"25": {"3", "2"},
+		"50": {"2", "1"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fanbox/user/12345" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		var posts []string
+		for _, postId := range pages[r.URL.Query().Get("o")] {
+			posts = append(posts, fmt.Sprintf(`{"id": %q, "service": "fanbox", "user": "12345"}`, postId))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, "[%s]", strings.Join(posts, ","))
+	}))
+	defer srv.Close()
+
+	posts, err := getCreatorPostsJsonFromApi(
+		srv.URL,
+		&models.KemonoCreatorToDl{Service: "fanbox", CreatorId: "12345"},
+		&KemonoDlOptions{Configs: &configs.Config{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotIds []string
+	for _, post := range posts {
+		gotIds = append(gotIds, post.Id)
+	}
+	if got, want := strings.Join(gotIds, ","), "5,4,3,2,1"; got != want {
+		t.Errorf("got the posts %s, want %s without duplicates", got, want)
+	}
+}
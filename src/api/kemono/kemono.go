@@ -46,32 +46,52 @@ func KemonoDownloadProcess(config *configs.Config, kemonoDl *KemonoDl, dlOptions
 		toDownload = append(toDownload, postsToDl...)
 		gdriveLinks = append(gdriveLinks, gdriveLinksToDl...)
 	}
-	if len(kemonoDl.CreatorsToDl) > 0 {
-		creatorsToDl, gdriveLinksToDl := getMultipleCreators(
-			kemonoDl.CreatorsToDl,
-			utils.DOWNLOAD_PATH,
-			dlOptions,
-		)
-		toDownload = append(toDownload, creatorsToDl...)
-		gdriveLinks = append(gdriveLinks, gdriveLinksToDl...)
-	}
+	kemonoDl.CreatorsToDl = filterNewCreators(kemonoDl.CreatorsToDl)
 
 	var downloadedPosts bool
-	if len(toDownload) > 0 {
+	reqDlOptions := &request.DlOptions{
+		MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+		Cookies:        dlOptions.SessionCookies,
+		UseHttp3:       utils.IsHttp3Supported(utils.KEMONO, false),
+	}
+	if len(kemonoDl.CreatorsToDl) > 0 {
+		// the creators' posts are downloaded while the rest of the creators are still being listed
+		type streamResult struct {
+			filesSent   int
+			gdriveLinks []*request.ToDownload
+		}
+		urlInfoChan := make(chan []*request.ToDownload)
+		resultChan := make(chan streamResult, 1)
+		go func() {
+			defer close(urlInfoChan)
+			if len(toDownload) > 0 {
+				urlInfoChan <- toDownload
+			}
+			filesSent, creatorsGdriveLinks := streamMultipleCreators(
+				kemonoDl.CreatorsToDl,
+				utils.DOWNLOAD_PATH,
+				dlOptions,
+				urlInfoChan,
+			)
+			resultChan <- streamResult{
+				filesSent:   len(toDownload) + filesSent,
+				gdriveLinks: creatorsGdriveLinks,
+			}
+		}()
+		request.DownloadUrlStream(urlInfoChan, reqDlOptions, config)
+
+		result := <-resultChan
+		downloadedPosts = result.filesSent > 0
+		gdriveLinks = append(gdriveLinks, result.gdriveLinks...)
+	} else if len(toDownload) > 0 {
 		downloadedPosts = true
-		request.DownloadUrls(
-			toDownload,
-			&request.DlOptions{
-				MaxConcurrency: utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
-				Cookies:        dlOptions.SessionCookies,
-				UseHttp3:       utils.IsHttp3Supported(utils.KEMONO, false),
-			},
-			config,
-		)
+		request.DownloadUrls(toDownload, reqDlOptions, config)
 	}
 	if dlOptions.GdriveClient != nil && len(gdriveLinks) > 0 {
 		downloadedPosts = true
-		dlOptions.GdriveClient.DownloadGdriveUrls(gdriveLinks, config)
+		request.DeferUntilPlanned(func() {
+			dlOptions.GdriveClient.DownloadGdriveUrls(gdriveLinks, config)
+		})
 	}
 
 	if downloadedPosts {
@@ -0,0 +1,72 @@
+package kemono
+
+import (
+	"fmt"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Returns the number of files in the post, including the post thumbnail.
+func getPostFileCount(post *models.MainKemonoJson) int {
+	fileCount := len(post.Attachments)
+	if post.File.Path != "" {
+		fileCount++
+	}
+	return fileCount
+}
+
+// ListCreatorsPosts gets the posts of the given creators without downloading them
+// and returns a summary of each post for the user to browse.
+func ListCreatorsPosts(creators []*models.KemonoCreatorToDl, dlOptions *KemonoDlOptions) []*utils.PostSummary {
+	var errSlice []error
+	var postSummaries []*utils.PostSummary
+	creatorLen := len(creators)
+	baseMsg := "Getting creator's posts from Kemono Party [%d/" + fmt.Sprintf("%d]...", creatorLen)
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished getting %d creator's posts from Kemono Party!\n",
+			creatorLen,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting %d creator's posts from Kemono Party.\nPlease refer to the logs for more details.",
+			creatorLen,
+		),
+		creatorLen,
+	)
+	progress.Start()
+	for _, creator := range creators {
+		posts, err := getCreatorPostsJson(creator, dlOptions)
+		if err != nil {
+			errSlice = append(errSlice, err)
+			progress.MsgIncrement(baseMsg)
+			continue
+		}
+
+		for _, post := range posts {
+			postSummaries = append(postSummaries, &utils.PostSummary{
+				Creator:   fmt.Sprintf("%s/%s", creator.Service, creator.CreatorId),
+				Id:        post.Id,
+				Title:     post.Title,
+				Date:      post.Published,
+				FileCount: getPostFileCount(post),
+			})
+		}
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasError := false
+	if len(errSlice) > 0 {
+		hasError = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasError)
+	return postSummaries
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"path/filepath"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
@@ -40,13 +41,39 @@ func getKemonoFilePath(postFolderPath, childDir, fileName string) string {
 	return filepath.Join(postFolderPath, childDir, fileName)
 }
 
+// Detects the content types of the Kemono Party post for the content filters
+func getPostContent(resJson *models.MainKemonoJson) *api.PostContent {
+	content := &api.PostContent{}
+	content.AddText(resJson.Content)
+	content.AddText(resJson.Embed.Url)
+	content.AddMedia(resJson.File.Name)
+	for _, attachment := range resJson.Attachments {
+		content.AddAttachment(attachment.Name)
+	}
+	return content
+}
+
 func processJson(resJson *models.MainKemonoJson, downloadPath string, dlOptions *KemonoDlOptions) ([]*request.ToDownload, []*request.ToDownload) {
-	postFolderPath := utils.GetPostFolder(
-		filepath.Join(downloadPath, "Kemono-Party", resJson.Service),
-		resJson.User,
-		resJson.Id,
-		resJson.Title,
+	if api.PostIsTooOld(resJson.Published) {
+		return nil, nil
+	}
+	if api.ContentFiltersEnabled() && api.PostLacksContent(getPostContent(resJson)) {
+		return nil, nil
+	}
+
+	postFolderPath := utils.GetTemplatedPostFolder(
+		downloadPath,
+		filepath.Join("Kemono-Party", resJson.Service),
+		&utils.PostFolderInfo{
+			Platform:    utils.KEMONO_TITLE,
+			Service:     resJson.Service,
+			CreatorName: resJson.User,
+			PostId:      resJson.Id,
+			Title:       resJson.Title,
+			Date:        resJson.Published,
+		},
 	)
+	api.RecordPost(utils.KEMONO_TITLE, resJson.Title, resJson.Published, postFolderPath)
 
 	var gdriveLinks []*request.ToDownload
 	var toDownload []*request.ToDownload
@@ -88,6 +115,18 @@ func processJson(resJson *models.MainKemonoJson, downloadPath string, dlOptions
 		dlOptions.Configs.LogUrls,
 	)
 	gdriveLinks = append(gdriveLinks, contentGdriveLinks...)
+	request.AddIndexPrefixes(toDownload)
+	toDownload = request.SelectFileIndices(toDownload, postFolderPath)
+	request.SetCreator(toDownload, utils.KEMONO+"/"+resJson.Service+"/"+resJson.User)
+	request.SetPost(toDownload, postFolderPath)
+	request.SetPostDate(toDownload, resJson.Published)
+	request.SetPostDeletedCheck(
+		toDownload,
+		"Kemono Party post "+resJson.Service+"/"+resJson.User+"/"+resJson.Id,
+		func() bool {
+			return isPostDeleted(resJson.Service, resJson.User, resJson.Id, dlOptions)
+		},
+	)
 	return toDownload, gdriveLinks
 }
 
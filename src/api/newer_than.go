@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+var (
+	newerThanFilePath string
+	newerThanCutoff   time.Time
+)
+
+// SetNewerThanFile sets the reference file whose modification time will be used
+// as the cutoff where only posts published after it will be downloaded.
+//
+// If the reference file does not exist yet, no posts will be filtered
+// and the file will be created when TouchNewerThanFile is called at the end of the run.
+func SetNewerThanFile(filePath string) error {
+	if filePath == "" {
+		return nil
+	}
+
+	newerThanFilePath = filePath
+	fileInfo, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to get the modification time of %q, more info => %v",
+			utils.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	newerThanCutoff = fileInfo.ModTime()
+	return nil
+}
+
+// PostIsTooOld returns true if the post's published date is not after
+// the cutoff set by SetNewerThanFile and the post should be skipped.
+//
+// Posts with a published date that could not be parsed will not be skipped.
+func PostIsTooOld(publishedDate string) bool {
+	if newerThanCutoff.IsZero() {
+		return false
+	}
+
+	publishedTime, ok := utils.ParsePostDate(publishedDate)
+	if !ok {
+		return false
+	}
+	return !publishedTime.After(newerThanCutoff)
+}
+
+// TouchNewerThanFile sets the modification time of the reference file to the given time
+// which should be the start of the run so that posts published during the run will not be missed.
+//
+// The reference file will be created if it does not exist.
+func TouchNewerThanFile(runStartTime time.Time) error {
+	if newerThanFilePath == "" {
+		return nil
+	}
+
+	if !utils.PathExists(newerThanFilePath) {
+		f, err := os.Create(newerThanFilePath)
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: failed to create the reference file %q, more info => %v",
+				utils.OS_ERROR,
+				newerThanFilePath,
+				err,
+			)
+		}
+		f.Close()
+	}
+
+	if err := os.Chtimes(newerThanFilePath, runStartTime, runStartTime); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to update the modification time of %q, more info => %v",
+			utils.OS_ERROR,
+			newerThanFilePath,
+			err,
+		)
+	}
+	return nil
+}
@@ -22,3 +22,28 @@ func ConvertPageNumToOffset(minPageNum, maxPageNum, perPage int, checkPixivMax b
 	}
 	return minOffset, maxOffset
 }
+
+var ACCEPTED_PREFER_SIZE = []string{
+	"original",
+	"large",
+	"small",
+}
+
+// Returns the image URL based on the preferred size.
+//
+// If the preferred size is not available, it will fall back to the next bigger size.
+// Note that thumbnails are not considered here.
+func SelectImageUrl(preferSize, originalUrl, largeUrl, smallUrl string) string {
+	switch preferSize {
+	case "small":
+		if smallUrl != "" {
+			return smallUrl
+		}
+		fallthrough
+	case "large":
+		if largeUrl != "" {
+			return largeUrl
+		}
+	}
+	return originalUrl
+}
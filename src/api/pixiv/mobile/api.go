@@ -152,10 +152,19 @@ func (pixiv *PixivMobile) GetMultipleArtworkDetails(artworkIds []string, downloa
 	return artworksToDownload, ugoiraSlice
 }
 
+// Returns the ID of the artwork to detect duplicates across pages where nil artworks have an ID of 0
+func getArtworkId(artwork *models.PixivMobileIllustJson) int {
+	if artwork == nil {
+		return 0
+	}
+	return artwork.Id
+}
+
 func (pixiv *PixivMobile) getIllustratorPostMainLogic(params map[string]string, userId, downloadPath string, offsetArg *offsetArgs) ([]*request.ToDownload, []*models.Ugoira, []error) {
 	var errSlice []error
 	var ugoiraSlice []*models.Ugoira
 	var artworksToDownload []*request.ToDownload
+	seenArtworks := make(map[int]struct{})
 	nextUrl := pixiv.baseUrl + "/v1/user/illusts"
 
 	curOffset := offsetArg.minOffset
@@ -182,6 +191,8 @@ func (pixiv *PixivMobile) getIllustratorPostMainLogic(params map[string]string,
 			return nil, nil, []error{err}
 		}
 
+		// the pages may overlap so the artworks that were already returned are skipped
+		resJson.Illusts = utils.RemoveSeenItems(resJson.Illusts, seenArtworks, getArtworkId)
 		artworks, ugoira, errS := pixiv.processMultipleArtworkJson(&resJson, downloadPath)
 		if len(errS) > 0 {
 			errSlice = append(errSlice, errS...)
@@ -310,6 +321,7 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName, downloadPath string, dlOptions
 	var errSlice []error
 	var ugoiraSlice []*models.Ugoira
 	var artworksToDownload []*request.ToDownload
+	seenArtworks := make(map[int]struct{})
 	params := map[string]string{
 		"word":          tagName,
 		"search_target": dlOptions.SearchMode,
@@ -343,6 +355,8 @@ func (pixiv *PixivMobile) tagSearchLogic(tagName, downloadPath string, dlOptions
 			continue
 		}
 
+		// the pages may overlap so the artworks that were already returned are skipped
+		resJson.Illusts = utils.RemoveSeenItems(resJson.Illusts, seenArtworks, getArtworkId)
 		artworks, ugoira, errS := pixiv.processMultipleArtworkJson(&resJson, downloadPath)
 		errSlice = append(errSlice, errS...)
 		artworksToDownload = append(artworksToDownload, artworks...)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/fatih/color"
@@ -17,6 +18,9 @@ type PixivMobileDlOptions struct {
 	RatingMode  string
 	ArtworkType string
 
+	// PreferSize is the preferred image size to download. Can be "original", "large", or "small".
+	PreferSize  string
+
 	Configs     *configs.Config
 
 	MobileClient *PixivMobile
@@ -90,6 +94,19 @@ func (p *PixivMobileDlOptions) ValidateArgs(userAgent string) {
 		},
 	)
 
+	p.PreferSize = strings.ToLower(p.PreferSize)
+	utils.ValidateStrArgs(
+		p.PreferSize,
+		pixivcommon.ACCEPTED_PREFER_SIZE,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Preferred image size %s is not allowed",
+				utils.INPUT_ERROR,
+				p.PreferSize,
+			),
+		},
+	)
+
 	p.ArtworkType = strings.ToLower(p.ArtworkType)
 	utils.ValidateStrArgs(
 		p.ArtworkType,
@@ -105,6 +122,7 @@ func (p *PixivMobileDlOptions) ValidateArgs(userAgent string) {
 
 	if p.RefreshToken != "" {
 		p.MobileClient = NewPixivMobile(p.RefreshToken, 10)
+		p.MobileClient.preferSize = p.PreferSize
 		if p.RatingMode != "all" {
 			color.Red(
 				utils.CombineStringsWithNewline(
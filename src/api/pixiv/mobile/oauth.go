@@ -65,9 +65,9 @@ func (pixiv *PixivMobile) StartOauthFlow() error {
 	color.Yellow("https://github.com/KJHJason/Cultured-Downloader/blob/main/doc/pixiv_oauth_guide.md\n")
 	for {
 		var code string
-		fmt.Print(color.YellowString("Please enter the code you received from Pixiv: "))
+		fmt.Fprint(color.Output, color.YellowString("Please enter the code you received from Pixiv: "))
 		_, err := fmt.Scanln(&code)
-		fmt.Println()
+		fmt.Fprintln(color.Output)
 		if err != nil {
 			color.Red("Failed to read inputted code: " + err.Error())
 			continue
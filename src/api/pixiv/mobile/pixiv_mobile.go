@@ -25,6 +25,7 @@ type PixivMobile struct {
 
 	// User given arguments
 	apiTimeout int
+	preferSize string
 
 	// Access token information
 	accessTokenMu  sync.Mutex
@@ -43,6 +44,7 @@ func NewPixivMobile(refreshToken string, timeout int) *PixivMobile {
 		redirectUri:   utils.PIXIV_MOBILE_URL + "/web/v1/users/auth/pixiv/callback",
 		refreshToken:  refreshToken,
 		apiTimeout:    timeout,
+		preferSize:    "original",
 	}
 	if refreshToken != "" {
 		// refresh the access token and verify it
@@ -79,6 +81,9 @@ func (pixiv *PixivMobile) getHeaders(additional map[string]string) map[string]st
 		"App-OS-Version": "14.6",
 		"Authorization":  "Bearer " + pixiv.accessTokenMap.accessToken,
 	}
+	if lang := request.GetAcceptLanguage(); lang != "" {
+		baseHeaders["Accept-Language"] = lang
+	}
 	for k, v := range baseHeaders {
 		headers[k] = v
 	}
@@ -2,8 +2,9 @@ package pixivmobile
 
 import (
 	"strconv"
-	"path/filepath"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
@@ -11,7 +12,7 @@ import (
 
 // Process the artwork JSON and returns a slice of map that contains the urls of the images and the file path
 func (pixiv *PixivMobile) processArtworkJson(artworkJson *models.PixivMobileIllustJson, downloadPath string) ([]*request.ToDownload, *models.Ugoira, error) {
-	if artworkJson == nil {
+	if artworkJson == nil || api.PostIsTooOld(artworkJson.CreateDate) {
 		return nil, nil, nil
 	}
 
@@ -19,9 +20,18 @@ func (pixiv *PixivMobile) processArtworkJson(artworkJson *models.PixivMobileIllu
 	artworkTitle := artworkJson.Title
 	artworkType := artworkJson.Type
 	illustratorName := artworkJson.User.Name
-	artworkFolderPath := utils.GetPostFolder(
-		filepath.Join(downloadPath, utils.PIXIV_TITLE), illustratorName, artworkId, artworkTitle,
+	artworkFolderPath := utils.GetTemplatedPostFolder(
+		downloadPath,
+		utils.PIXIV_TITLE,
+		&utils.PostFolderInfo{
+			Platform:    utils.PIXIV_TITLE,
+			CreatorName: illustratorName,
+			PostId:      artworkId,
+			Title:       artworkTitle,
+			Date:        artworkJson.CreateDate,
+		},
 	)
+	api.RecordPost(utils.PIXIV_TITLE, artworkTitle, artworkJson.CreateDate, artworkFolderPath)
 
 	if artworkType == "ugoira" {
 		ugoiraInfo, err := pixiv.getUgoiraMetadata(artworkId, artworkFolderPath)
@@ -35,18 +45,31 @@ func (pixiv *PixivMobile) processArtworkJson(artworkJson *models.PixivMobileIllu
 	singlePageImageUrl := artworkJson.MetaSinglePage.OriginalImageUrl
 	if singlePageImageUrl != "" {
 		artworksToDownload = append(artworksToDownload, &request.ToDownload{
-			Url:      singlePageImageUrl,
+			Url:      pixivcommon.SelectImageUrl(
+				pixiv.preferSize,
+				singlePageImageUrl,
+				artworkJson.ImageUrls.Large,
+				artworkJson.ImageUrls.Medium,
+			),
 			FilePath: artworkFolderPath,
 		})
 	} else {
 		for _, image := range artworkJson.MetaPages {
-			imageUrl := image.ImageUrls.Original
+			imageUrl := pixivcommon.SelectImageUrl(
+				pixiv.preferSize,
+				image.ImageUrls.Original,
+				image.ImageUrls.Large,
+				image.ImageUrls.Medium,
+			)
 			artworksToDownload = append(artworksToDownload, &request.ToDownload{
 				Url:      imageUrl,
 				FilePath: artworkFolderPath,
 			})
 		}
 	}
+	request.SetCreator(artworksToDownload, utils.PIXIV+"/"+strconv.Itoa(artworkJson.User.Id))
+	request.SetPost(artworksToDownload, artworkFolderPath)
+	request.SetPostDate(artworksToDownload, artworkJson.CreateDate)
 	return artworksToDownload, nil, nil
 }
 
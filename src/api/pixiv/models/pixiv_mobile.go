@@ -19,20 +19,29 @@ type UgoiraJson struct {
 }
 
 type PixivMobileIllustJson struct {
-	Id    int    `json:"id"`
-	Title string `json:"title"`
-	Type  string `json:"type"`
+	Id         int    `json:"id"`
+	Title      string `json:"title"`
+	Type       string `json:"type"`
+	CreateDate string `json:"create_date"`
 
 	User struct {
+		Id    int    `json:"id"`
 		Name  string `json:"name"`
 	} `json:"user"`
 
+	ImageUrls struct {
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"image_urls"`
+
 	MetaSinglePage struct {
 		OriginalImageUrl string `json:"original_image_url"`
 	} `json:"meta_single_page"`
 
 	MetaPages []struct {
 		ImageUrls struct {
+			Medium   string `json:"medium"`
+			Large    string `json:"large"`
 			Original string `json:"original"`
 		} `json:"image_urls"`
 	} `json:"meta_pages"`
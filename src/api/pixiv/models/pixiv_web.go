@@ -5,6 +5,7 @@ type ArtworkDetails struct {
 		UserName   string `json:"userName"`
 		Title      string `json:"title"`
 		IllustType int64  `json:"illustType"`
+		UploadDate string `json:"uploadDate"`
 	}
 }
 
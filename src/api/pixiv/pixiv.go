@@ -3,6 +3,7 @@ package pixiv
 import (
 	"fmt"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/ugoira"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
@@ -25,6 +26,11 @@ func alertUser(artworksToDl []*request.ToDownload, ugoiraToDl []*models.Ugoira)
 func PixivWebDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivweb.PixivWebDlOptions, pixivUgoiraOptions *ugoira.UgoiraOptions) {
 	var ugoiraToDl []*models.Ugoira
 	var artworksToDl []*request.ToDownload
+	pixivDl.IllustratorIds, pixivDl.IllustratorPageNums = api.FilterNewCreators(
+		utils.PIXIV,
+		pixivDl.IllustratorIds,
+		pixivDl.IllustratorPageNums,
+	)
 	if len(pixivDl.IllustratorIds) > 0 {
 		artworkIdsSlice := pixivweb.GetMultipleIllustratorPosts(
 			pixivDl.IllustratorIds,
@@ -97,16 +103,19 @@ func PixivWebDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivweb.PixivWeb
 		)
 	}
 	if len(ugoiraToDl) > 0 {
-		ugoira.DownloadMultipleUgoira(
-			&ugoira.UgoiraArgs{
-				UseMobileApi: false,
-				ToDownload:   ugoiraToDl,
-				Cookies:      pixivDlOptions.SessionCookies,
-			},
-			pixivUgoiraOptions,
-			pixivDlOptions.Configs,
-			request.CallRequest,
-		)
+		// the Ugoira can only be converted after their zip files have been downloaded
+		request.DeferUntilPlanned(func() {
+			ugoira.DownloadMultipleUgoira(
+				&ugoira.UgoiraArgs{
+					UseMobileApi: false,
+					ToDownload:   ugoiraToDl,
+					Cookies:      pixivDlOptions.SessionCookies,
+				},
+				pixivUgoiraOptions,
+				pixivDlOptions.Configs,
+				request.CallRequest,
+			)
+		})
 	}
 
 	alertUser(artworksToDl, ugoiraToDl)
@@ -116,6 +125,11 @@ func PixivWebDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivweb.PixivWeb
 func PixivMobileDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivmobile.PixivMobileDlOptions, pixivUgoiraOptions *ugoira.UgoiraOptions) {
 	var ugoiraToDl []*models.Ugoira
 	var artworksToDl []*request.ToDownload
+	pixivDl.IllustratorIds, pixivDl.IllustratorPageNums = api.FilterNewCreators(
+		utils.PIXIV,
+		pixivDl.IllustratorIds,
+		pixivDl.IllustratorPageNums,
+	)
 	if len(pixivDl.IllustratorIds) > 0 {
 		artworkSlice, ugoiraSlice := pixivDlOptions.MobileClient.GetMultipleIllustratorPosts(
 			pixivDl.IllustratorIds,
@@ -186,16 +200,19 @@ func PixivMobileDownloadProcess(pixivDl *PixivDl, pixivDlOptions *pixivmobile.Pi
 		)
 	}
 	if len(ugoiraToDl) > 0 {
-		ugoira.DownloadMultipleUgoira(
-			&ugoira.UgoiraArgs{
-				UseMobileApi: true,
-				ToDownload:   ugoiraToDl,
-				Cookies:      nil,
-			},
-			pixivUgoiraOptions,
-			pixivDlOptions.Configs,
-			pixivDlOptions.MobileClient.SendRequest,
-		)
+		// the Ugoira can only be converted after their zip files have been downloaded
+		request.DeferUntilPlanned(func() {
+			ugoira.DownloadMultipleUgoira(
+				&ugoira.UgoiraArgs{
+					UseMobileApi: true,
+					ToDownload:   ugoiraToDl,
+					Cookies:      nil,
+				},
+				pixivUgoiraOptions,
+				pixivDlOptions.Configs,
+				pixivDlOptions.MobileClient.SendRequest,
+			)
+		})
 	}
 
 	alertUser(artworksToDl, ugoiraToDl)
@@ -226,6 +226,10 @@ func DownloadMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions
 		useHttp3 = utils.IsHttp3Supported(utils.PIXIV, true)
 	}
 
+	// the ugoira zip files must be saved at the expected
+	// file paths for the conversion process to find them
+	dlConfig := *config
+	dlConfig.MirrorPath = false
 	request.DownloadUrlsWithHandler(
 		urlsToDownload,
 		&request.DlOptions{
@@ -234,7 +238,7 @@ func DownloadMultipleUgoira(ugoiraArgs *UgoiraArgs, ugoiraOptions *UgoiraOptions
 			Cookies:        ugoiraArgs.Cookies,
 			UseHttp3:       useHttp3,
 		},
-		config,    // Note: if isMobileApi is true, custom user-agent will be ignored
+		&dlConfig, // Note: if isMobileApi is true, custom user-agent will be ignored
 		reqHandler,
 	)
 
@@ -3,9 +3,9 @@ package pixivweb
 import (
 	"fmt"
 	"net/http"
-	"path/filepath"
 	"strconv"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
@@ -16,22 +16,28 @@ import (
 func getArtworkDetailsLogic(artworkId string, reqArgs *request.RequestArgs) (*models.ArtworkDetails, error) {
 	artworkDetailsRes, err := request.CallRequest(reqArgs)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"pixiv error %d: failed to get artwork details for ID %v from %s",
-			utils.CONNECTION_ERROR,
-			artworkId,
-			reqArgs.Url,
+		return nil, utils.WithLogFields(
+			fmt.Errorf(
+				"pixiv error %d: failed to get artwork details for ID %v from %s",
+				utils.CONNECTION_ERROR,
+				artworkId,
+				reqArgs.Url,
+			),
+			utils.LogFields{Code: utils.CONNECTION_ERROR, Url: reqArgs.Url, PostId: artworkId},
 		)
 	}
 
 	if artworkDetailsRes.StatusCode != 200 {
 		artworkDetailsRes.Body.Close()
-		return nil, fmt.Errorf(
-			"pixiv error %d: failed to get details for artwork ID %s due to %s response from %s",
-			utils.RESPONSE_ERROR,
-			artworkId,
-			artworkDetailsRes.Status,
-			reqArgs.Url,
+		return nil, utils.WithLogFields(
+			fmt.Errorf(
+				"pixiv error %d: failed to get details for artwork ID %s due to %s response from %s",
+				utils.RESPONSE_ERROR,
+				artworkId,
+				artworkDetailsRes.Status,
+				reqArgs.Url,
+			),
+			utils.LogFields{Code: utils.RESPONSE_ERROR, Url: reqArgs.Url, PostId: artworkId, Status: artworkDetailsRes.StatusCode},
 		)
 	}
 
@@ -65,23 +71,29 @@ func getArtworkUrlsToDlLogic(artworkType int64, artworkId string, reqArgs *reque
 	reqArgs.Url = url
 	artworkUrlsRes, err := request.CallRequest(reqArgs)
 	if err != nil { 
-		return nil, fmt.Errorf(
-			"pixiv error %d: failed to get artwork URLs for ID %s from %s due to %v",
-			utils.CONNECTION_ERROR,
-			artworkId,
-			url,
-			err,
+		return nil, utils.WithLogFields(
+			fmt.Errorf(
+				"pixiv error %d: failed to get artwork URLs for ID %s from %s due to %v",
+				utils.CONNECTION_ERROR,
+				artworkId,
+				url,
+				err,
+			),
+			utils.LogFields{Code: utils.CONNECTION_ERROR, Url: url, PostId: artworkId},
 		)
 	}
 
 	if artworkUrlsRes.StatusCode != 200 {
 		artworkUrlsRes.Body.Close()
-		return nil,fmt.Errorf(
-			"pixiv error %d: failed to get artwork URLs for ID %s due to %s response from %s",
-			utils.RESPONSE_ERROR,
-			artworkId,
-			artworkUrlsRes.Status,
-			url,
+		return nil, utils.WithLogFields(
+			fmt.Errorf(
+				"pixiv error %d: failed to get artwork URLs for ID %s due to %s response from %s",
+				utils.RESPONSE_ERROR,
+				artworkId,
+				artworkUrlsRes.Status,
+				url,
+			),
+			utils.LogFields{Code: utils.RESPONSE_ERROR, Url: url, PostId: artworkId, Status: artworkUrlsRes.StatusCode},
 		)
 	}
 	return artworkUrlsRes, nil
@@ -116,12 +128,18 @@ func getArtworkDetails(artworkId, downloadPath string, dlOptions *PixivWebDlOpti
 	artworkJsonBody := artworkDetailsJsonRes.Body
 	illustratorName := artworkJsonBody.UserName
 	artworkName := artworkJsonBody.Title
-	artworkPostDir := utils.GetPostFolder(
-		filepath.Join(downloadPath, utils.PIXIV_TITLE),
-		illustratorName,
-		artworkId,
-		artworkName,
+	artworkPostDir := utils.GetTemplatedPostFolder(
+		downloadPath,
+		utils.PIXIV_TITLE,
+		&utils.PostFolderInfo{
+			Platform:    utils.PIXIV_TITLE,
+			CreatorName: illustratorName,
+			PostId:      artworkId,
+			Title:       artworkName,
+			Date:        artworkJsonBody.UploadDate,
+		},
 	)
+	api.RecordPost(utils.PIXIV_TITLE, artworkName, artworkJsonBody.UploadDate, artworkPostDir)
 
 	artworkType := artworkJsonBody.IllustType
 	artworkUrlsRes, err := getArtworkUrlsToDlLogic(artworkType, artworkId, reqArgs)
@@ -133,6 +151,7 @@ func getArtworkDetails(artworkId, downloadPath string, dlOptions *PixivWebDlOpti
 		artworkUrlsRes,
 		artworkType,
 		artworkPostDir,
+		dlOptions.PreferSize,
 	)
 	if err != nil {
 		return nil, nil, err
@@ -309,6 +328,7 @@ type pageNumArgs struct {
 func tagSearchLogic(tagName string, reqArgs *request.RequestArgs, pageNumArgs *pageNumArgs) ([]string, []error) {
 	var errSlice []error
 	var artworkIds []string
+	seenArtworks := make(map[string]struct{})
 	page := 0
 	for {
 		page++
@@ -342,7 +362,10 @@ func tagSearchLogic(tagName string, reqArgs *request.RequestArgs, pageNumArgs *p
 			break
 		}
 
-		artworkIds = append(artworkIds, tagArtworkIds...)
+		// the search results may shift between pages so the artworks that were already returned are skipped
+		artworkIds = append(artworkIds, utils.RemoveSeenItems(tagArtworkIds, seenArtworks, func(id string) string {
+			return id
+		})...)
 		if page != pageNumArgs.maxPage {
 			pixivSleep()
 		}
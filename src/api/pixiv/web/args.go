@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/common"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
@@ -18,6 +19,9 @@ type PixivWebDlOptions struct {
 	RatingMode  string
 	ArtworkType string
 
+	// PreferSize is the preferred image size to download. Can be "original", "large", or "small".
+	PreferSize  string
+
 	Configs     *configs.Config
 
 	SessionCookies  []*http.Cookie
@@ -91,6 +95,19 @@ func (p *PixivWebDlOptions) ValidateArgs(userAgent string) {
 		},
 	)
 
+	p.PreferSize = strings.ToLower(p.PreferSize)
+	utils.ValidateStrArgs(
+		p.PreferSize,
+		pixivcommon.ACCEPTED_PREFER_SIZE,
+		[]string{
+			fmt.Sprintf(
+				"pixiv error %d: Preferred image size %s is not allowed",
+				utils.INPUT_ERROR,
+				p.PreferSize,
+			),
+		},
+	)
+
 	p.ArtworkType = strings.ToLower(p.ArtworkType)
 	utils.ValidateStrArgs(
 		p.ArtworkType,
@@ -64,7 +64,7 @@ func processIllustratorPostJson(resJson *models.PixivWebIllustratorJson, pageNum
 
 // Process the artwork details JSON and returns a map of urls
 // with its file path or a Ugoira struct (One of them will be null depending on the artworkType)
-func processArtworkJson(res *http.Response, artworkType int64, postDownloadDir string) ([]*request.ToDownload, *models.Ugoira, error) {
+func processArtworkJson(res *http.Response, artworkType int64, postDownloadDir, preferSize string) ([]*request.ToDownload, *models.Ugoira, error) {
 	if artworkType == UGOIRA {
 		var ugoiraJson models.PixivWebArtworkUgoiraJson
 		if err := utils.LoadJsonFromResponse(res, &ugoiraJson); err != nil {
@@ -89,10 +89,16 @@ func processArtworkJson(res *http.Response, artworkType int64, postDownloadDir s
 	var urlsToDownload []*request.ToDownload
 	for _, artworkUrl := range artworkUrls.Body {
 		urlsToDownload = append(urlsToDownload, &request.ToDownload{
-			Url:      artworkUrl.Urls.Original,
+			Url:      pixivcommon.SelectImageUrl(
+				preferSize,
+				artworkUrl.Urls.Original,
+				artworkUrl.Urls.Regular,
+				artworkUrl.Urls.Small,
+			),
 			FilePath: postDownloadDir,
 		})
 	}
+	request.SetPost(urlsToDownload, postDownloadDir)
 	return urlsToDownload, nil, nil
 }
 
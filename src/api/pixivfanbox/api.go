@@ -19,6 +19,13 @@ func GetPixivFanboxHeaders() map[string]string {
 	}
 }
 
+// postRes is the response of a post's details with the cookies of the session it was
+// requested with when rotating between multiple sessions, nil otherwise.
+type postRes struct {
+	res     *http.Response
+	cookies []*http.Cookie
+}
+
 // Query Pixiv Fanbox's API based on the slice of post IDs and
 // returns a map of urls and a map of GDrive urls to download from.
 func (pf *PixivFanboxDl) getPostDetails(dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, []*request.ToDownload) {
@@ -29,7 +36,7 @@ func (pf *PixivFanboxDl) getPostDetails(dlOptions *PixivFanboxDlOptions) ([]*req
 	}
 	var wg sync.WaitGroup
 	queue := make(chan struct{}, maxConcurrency)
-	resChan := make(chan *http.Response, postIdsLen)
+	resChan := make(chan *postRes, postIdsLen)
 	errChan := make(chan error, postIdsLen)
 
 	baseMsg := "Getting post details from Pixiv Fanbox [%d/" + fmt.Sprintf("%d]...", postIdsLen)
@@ -65,11 +72,12 @@ func (pf *PixivFanboxDl) getPostDetails(dlOptions *PixivFanboxDlOptions) ([]*req
 			queue <- struct{}{}
 			header := GetPixivFanboxHeaders()
 			params := map[string]string{"postId": postId}
+			sessionLabel, cookies := dlOptions.getSessionCookies()
 			res, err := request.CallRequest(
 				&request.RequestArgs{
 					Method:    "GET",
 					Url:       url,
-					Cookies:   dlOptions.SessionCookies,
+					Cookies:   cookies,
 					Headers:   header,
 					Params:    params,
 					UserAgent: dlOptions.Configs.UserAgent,
@@ -78,21 +86,34 @@ func (pf *PixivFanboxDl) getPostDetails(dlOptions *PixivFanboxDlOptions) ([]*req
 				},
 			)
 			if err != nil {
-				errChan <- fmt.Errorf(
-					"pixiv fanbox error %d: failed to get post details for %s, more info => %v",
-					utils.CONNECTION_ERROR,
-					url,
-					err,
+				errChan <- utils.WithLogFields(
+					fmt.Errorf(
+						"pixiv fanbox error %d: failed to get post details for %s, more info => %v",
+						utils.CONNECTION_ERROR,
+						url,
+						err,
+					),
+					utils.LogFields{Code: utils.CONNECTION_ERROR, Url: url, PostId: postId},
 				)
 			} else if res.StatusCode != 200 {
-				errChan <- fmt.Errorf(
-					"pixiv fanbox error %d: failed to get post details for %s due to a %s response",
-					utils.CONNECTION_ERROR,
-					url,
-					res.Status,
+				res.Body.Close()
+				dlOptions.reportSessionStatus(sessionLabel, res.StatusCode)
+				errChan <- utils.WithLogFields(
+					fmt.Errorf(
+						"pixiv fanbox error %d: failed to get post details for %s due to a %s response",
+						utils.CONNECTION_ERROR,
+						url,
+						res.Status,
+					),
+					utils.LogFields{Code: utils.CONNECTION_ERROR, Url: url, PostId: postId, Status: res.StatusCode},
 				)
 			} else {
-				resChan <- res
+				post := &postRes{res: res}
+				if sessionLabel != "" {
+					// the post's files are downloaded with the session that has access to the post
+					post.cookies = cookies
+				}
+				resChan <- post
 			}
 			progress.MsgIncrement(baseMsg)
 		}(postId)
@@ -119,11 +140,12 @@ func getCreatorPaginatedPosts(creatorId string, dlOptions *PixivFanboxDlOptions)
 		utils.PIXIV_FANBOX_API_URL,
 	)
 	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_FANBOX, true)
+	sessionLabel, cookies := dlOptions.getSessionCookies()
 	res, err := request.CallRequest(
 		&request.RequestArgs{
 			Method:    "GET",
 			Url:       url,
-			Cookies:   dlOptions.SessionCookies,
+			Cookies:   cookies,
 			Headers:   headers,
 			Params:    params,
 			UserAgent: dlOptions.Configs.UserAgent,
@@ -143,6 +165,7 @@ func getCreatorPaginatedPosts(creatorId string, dlOptions *PixivFanboxDlOptions)
 			)
 		} else {
 			res.Body.Close()
+			dlOptions.reportSessionStatus(sessionLabel, res.StatusCode)
 			err = fmt.Errorf(
 				"%s %d: failed to get creator's posts for %s due to %s response",
 				errPrefix,
@@ -187,6 +210,7 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 	}
 	queue := make(chan struct{}, maxConcurrency)
 	resChan := make(chan *resStruct, len(paginatedUrls))
+	creator := utils.PIXIV_FANBOX + "/" + creatorId
 	for idx, paginatedUrl := range paginatedUrls {
 		curPage := idx + 1
 		if curPage < minPage {
@@ -203,11 +227,15 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 				<-queue
 			}()
 			queue <- struct{}{}
+			if request.IsCreatorSkipped(creator) {
+				return
+			}
+			sessionLabel, cookies := dlOptions.getSessionCookies()
 			res, err := request.CallRequest(
 				&request.RequestArgs{
 					Method:    "GET",
 					Url:       reqUrl,
-					Cookies:   dlOptions.SessionCookies,
+					Cookies:   cookies,
 					Headers:   headers,
 					UserAgent: dlOptions.Configs.UserAgent,
 					Http2:     !useHttp3,
@@ -217,6 +245,10 @@ func getFanboxPosts(creatorId, pageNum string, dlOptions *PixivFanboxDlOptions)
 			if err != nil || res.StatusCode != 200 {
 				if err == nil {
 					res.Body.Close()
+					dlOptions.reportSessionStatus(sessionLabel, res.StatusCode)
+					request.RecordCreatorFailure(creator, fmt.Errorf("%s response", res.Status))
+				} else {
+					request.RecordCreatorFailure(creator, err)
 				}
 				utils.LogError(
 					err,
@@ -313,3 +345,37 @@ func (pf *PixivFanboxDl) getCreatorsPosts(dlOptions *PixivFanboxDlOptions) {
 	progress.Stop(hasErr)
 	pf.PostIds = utils.RemoveSliceDuplicates(pf.PostIds)
 }
+
+// Checks if the Pixiv Fanbox post was deleted after it was listed by querying the post.info API
+// which responds with a JSON error like {"error":"general_error"} instead of the post details.
+func isPostDeleted(postId string, dlOptions *PixivFanboxDlOptions) bool {
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_FANBOX, true)
+	_, cookies := dlOptions.getSessionCookies()
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:    "GET",
+			Url:       fmt.Sprintf("%s/post.info", utils.PIXIV_FANBOX_API_URL),
+			Cookies:   cookies,
+			Headers:   GetPixivFanboxHeaders(),
+			Params:    map[string]string{"postId": postId},
+			UserAgent: dlOptions.Configs.UserAgent,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return false
+	}
+	if res.StatusCode != http.StatusNotFound && res.StatusCode != http.StatusBadRequest {
+		res.Body.Close()
+		return false
+	}
+
+	var errJson struct {
+		Error string `json:"error"`
+	}
+	if err := utils.LoadJsonFromResponse(res, &errJson); err != nil {
+		return false
+	}
+	return errJson.Error != ""
+}
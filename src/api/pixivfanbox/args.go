@@ -1,9 +1,11 @@
 package pixivfanbox
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
@@ -18,9 +20,26 @@ type PixivFanboxDl struct {
 	CreatorPageNums []string
 
 	PostIds []string
+
+	// TagNames are the tags to download the posts of across all creators
+	TagNames    []string
+	TagPageNums []string
+
+	// FollowingPattern is a glob pattern, or a regex if FollowingRegex is true,
+	// to match against the name and ID of the creators followed by the account.
+	FollowingPattern string
+	FollowingRegex   bool
+	followingMatcher func(string) bool
 }
 
-var creatorIdRegex = regexp.MustCompile(`^[\w.-]+$`)
+var (
+	creatorIdRegex = regexp.MustCompile(`^[\w.-]+$`)
+
+	ACCEPTED_THUMBNAIL_SIZES = []string{
+		"medium",
+		"original",
+	}
+)
 
 // ValidateArgs validates the IDs of the Pixiv Fanbox creators and posts to download.
 //
@@ -57,6 +76,31 @@ func (pf *PixivFanboxDl) ValidateArgs() {
 		pf.CreatorIds,
 		pf.CreatorPageNums,
 	)
+
+	if len(pf.TagPageNums) > 0 {
+		utils.ValidatePageNumInput(
+			len(pf.TagNames),
+			pf.TagPageNums,
+			[]string{
+				"Number of Pixiv Fanbox tag name(s) and page numbers must be equal.",
+			},
+		)
+	} else {
+		pf.TagPageNums = make([]string, len(pf.TagNames))
+	}
+	pf.TagNames, pf.TagPageNums = utils.RemoveDuplicateIdAndPageNum(
+		pf.TagNames,
+		pf.TagPageNums,
+	)
+
+	if pf.FollowingPattern != "" {
+		matcher, err := getCreatorMatcher(pf.FollowingPattern, pf.FollowingRegex)
+		if err != nil {
+			color.Red(err.Error())
+			os.Exit(1)
+		}
+		pf.followingMatcher = matcher
+	}
 }
 
 // PixivFanboxDlOptions is the struct that contains the options for downloading from Pixiv Fanbox.
@@ -65,6 +109,14 @@ type PixivFanboxDlOptions struct {
 	DlImages      bool
 	DlAttachments bool
 	DlGdrive      bool
+	DlPlans       bool
+
+	// ThumbnailSize is the size of the post's cover image to download, "medium" or "original"
+	ThumbnailSize string
+
+	// Series are the IDs or names of the series to only download the posts of
+	Series        []string
+	seriesMatches int
 
 	Configs       *configs.Config
 
@@ -72,19 +124,42 @@ type PixivFanboxDlOptions struct {
 	// used in the download process for Pixiv Fanbox posts
 	GdriveClient *gdrive.GDrive
 
-	SessionCookieId string
-	SessionCookies  []*http.Cookie
+	// SessionCookieIds can have multiple session cookie values
+	// which will be rotated per request to spread the load across accounts.
+	SessionCookieIds []string
+	SessionCookies   []*http.Cookie
+	Sessions         *api.SessionRotator
 }
 
 // ValidateArgs validates the session cookie ID of the Pixiv Fanbox account to download from.
 //
 // Should be called after initialising the struct.
 func (pf *PixivFanboxDlOptions) ValidateArgs(userAgent string) {
-	if pf.SessionCookieId != "" {
-		pf.SessionCookies = []*http.Cookie{
-			api.VerifyAndGetCookie(utils.PIXIV_FANBOX, pf.SessionCookieId, userAgent),
-		}
+	if len(pf.SessionCookieIds) > 0 {
+		pf.Sessions = api.NewSessionRotator(utils.PIXIV_FANBOX, pf.SessionCookieIds, userAgent)
+		_, pf.SessionCookies = pf.Sessions.Next()
+	}
+
+	if pf.ThumbnailSize == "" {
+		pf.ThumbnailSize = "medium"
+	}
+	for idx, series := range pf.Series {
+		pf.Series[idx] = strings.TrimSpace(series)
 	}
+	pf.Series = utils.RemoveSliceDuplicates(pf.Series)
+
+	pf.ThumbnailSize = strings.ToLower(pf.ThumbnailSize)
+	utils.ValidateStrArgs(
+		pf.ThumbnailSize,
+		ACCEPTED_THUMBNAIL_SIZES,
+		[]string{
+			fmt.Sprintf(
+				"pixiv fanbox error %d: Thumbnail size %s is not allowed",
+				utils.INPUT_ERROR,
+				pf.ThumbnailSize,
+			),
+		},
+	)
 
 	if pf.DlGdrive && pf.GdriveClient == nil {
 		pf.DlGdrive = false
@@ -92,3 +167,21 @@ func (pf *PixivFanboxDlOptions) ValidateArgs(userAgent string) {
 		pf.GdriveClient = nil
 	}
 }
+
+// Returns the label and cookies of the session to use for the next request.
+//
+// The label is empty if the user did not supply multiple sessions.
+func (pf *PixivFanboxDlOptions) getSessionCookies() (string, []*http.Cookie) {
+	if pf.Sessions == nil || pf.Sessions.Len() == 0 {
+		return "", pf.SessionCookies
+	}
+	return pf.Sessions.Next()
+}
+
+// Reports the response status code of a request made with the session of the given label
+func (pf *PixivFanboxDlOptions) reportSessionStatus(label string, statusCode int) {
+	if pf.Sessions == nil || label == "" {
+		return
+	}
+	pf.Sessions.ReportStatus(label, statusCode)
+}
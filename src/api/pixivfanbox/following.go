@@ -0,0 +1,130 @@
+package pixivfanbox
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Returns a function that checks if the given creator name or ID matches the pattern.
+//
+// The pattern is matched case-insensitively as a glob pattern, e.g. "*illust*",
+// unless isRegex is true where it will be compiled as a regular expression instead.
+func getCreatorMatcher(pattern string, isRegex bool) (func(string) bool, error) {
+	if isRegex {
+		regex, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"pixiv fanbox error %d: invalid regex pattern %q for the followed creators, more info => %v",
+				utils.INPUT_ERROR,
+				pattern,
+				err,
+			)
+		}
+		return regex.MatchString, nil
+	}
+
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf(
+			"pixiv fanbox error %d: invalid glob pattern %q for the followed creators, more info => %v",
+			utils.INPUT_ERROR,
+			pattern,
+			err,
+		)
+	}
+	return func(s string) bool {
+		matched, _ := path.Match(pattern, strings.ToLower(s))
+		return matched
+	}, nil
+}
+
+// Retrieves the creators followed by the authenticated Pixiv Fanbox account
+func getFollowedCreators(dlOptions *PixivFanboxDlOptions) (*models.FanboxFollowingJson, error) {
+	url := fmt.Sprintf("%s/creator.listFollowing", utils.PIXIV_FANBOX_API_URL)
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_FANBOX, true)
+	sessionLabel, cookies := dlOptions.getSessionCookies()
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:    "GET",
+			Url:       url,
+			Cookies:   cookies,
+			Headers:   GetPixivFanboxHeaders(),
+			UserAgent: dlOptions.Configs.UserAgent,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv fanbox error %d: failed to get your followed creators, more info => %v",
+			utils.CONNECTION_ERROR,
+			err,
+		)
+	}
+	if res.StatusCode != 200 {
+		res.Body.Close()
+		dlOptions.reportSessionStatus(sessionLabel, res.StatusCode)
+		return nil, fmt.Errorf(
+			"pixiv fanbox error %d: failed to get your followed creators due to a %s response",
+			utils.RESPONSE_ERROR,
+			res.Status,
+		)
+	}
+
+	var resJson models.FanboxFollowingJson
+	if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+		return nil, err
+	}
+	return &resJson, nil
+}
+
+// Adds the creators followed by the authenticated Pixiv Fanbox account whose
+// name or creator ID matches the following pattern to the slice of creator IDs to download from.
+func (pf *PixivFanboxDl) addFollowedCreators(dlOptions *PixivFanboxDlOptions) {
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		"Getting your followed creators from Pixiv Fanbox...",
+		"Finished getting your followed creators from Pixiv Fanbox!",
+		"Something went wrong while getting your followed creators from Pixiv Fanbox!\nPlease refer to logs for more details.",
+		0,
+	)
+	progress.Start()
+
+	followedCreators, err := getFollowedCreators(dlOptions)
+	if err != nil {
+		progress.Stop(true)
+		utils.LogError(err, "", false, utils.ERROR)
+		return
+	}
+
+	matchedCount := 0
+	for _, creator := range followedCreators.Body {
+		if !pf.followingMatcher(creator.CreatorId) && !pf.followingMatcher(creator.User.Name) {
+			continue
+		}
+
+		matchedCount++
+		pf.CreatorIds = append(pf.CreatorIds, creator.CreatorId)
+		pf.CreatorPageNums = append(pf.CreatorPageNums, "")
+	}
+	progress.SuccessMsg = fmt.Sprintf(
+		"Found %d of your %d followed creator(s) on Pixiv Fanbox matching %q!\n",
+		matchedCount,
+		len(followedCreators.Body),
+		pf.FollowingPattern,
+	)
+	progress.Stop(false)
+
+	pf.CreatorIds, pf.CreatorPageNums = utils.RemoveDuplicateIdAndPageNum(
+		pf.CreatorIds,
+		pf.CreatorPageNums,
+	)
+}
@@ -21,10 +21,17 @@ type FanboxPostJson struct {
 		Type          string          `json:"type"`
 		CreatorId     string          `json:"creatorId"`
 		CoverImageUrl string          `json:"coverImageUrl"`
+		PublishedDate string          `json:"publishedDatetime"`
+		Series        *FanboxSeries   `json:"series"` // nil if the post is not in any series
 		Body          json.RawMessage `json:"body"`
 	} `json:"body"`
 }
 
+type FanboxSeries struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
 type FanboxFilePostJson struct {
 	Text  string `json:"text"`
 	Files []struct {
@@ -87,3 +94,36 @@ type FanboxArticleJson struct {
 		Url       string `json:"url"`
 	} `json:"fileMap"`
 }
+
+type FanboxFollowingJson struct {
+	Body []struct {
+		CreatorId string `json:"creatorId"`
+		User      struct {
+			UserId string `json:"userId"`
+			Name   string `json:"name"`
+		} `json:"user"`
+	} `json:"body"`
+}
+
+type FanboxPlan struct {
+	Id              string `json:"id"`
+	Title           string `json:"title"`
+	Fee             int    `json:"fee"`
+	Description     string `json:"description"`
+	CoverImageUrl   string `json:"coverImageUrl"`
+	HasAdultContent bool   `json:"hasAdultContent"`
+	PaymentMethod   string `json:"paymentMethod"`
+}
+
+type FanboxCreatorPlansJson struct {
+	Body []*FanboxPlan `json:"body"`
+}
+
+type FanboxTaggedPostsJson struct {
+	Body struct {
+		Items []struct {
+			Id string `json:"id"`
+		} `json:"items"`
+		NextUrl string `json:"nextUrl"`
+	} `json:"body"`
+}
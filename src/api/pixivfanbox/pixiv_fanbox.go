@@ -1,27 +1,52 @@
 package pixivfanbox
 
 import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
 // Start the download process for Pixiv Fanbox
 func PixivFanboxDownloadProcess(pixivFanboxDl *PixivFanboxDl, pixivFanboxDlOptions *PixivFanboxDlOptions) {
-	if !pixivFanboxDlOptions.DlThumbnails && !pixivFanboxDlOptions.DlImages && !pixivFanboxDlOptions.DlAttachments && !pixivFanboxDlOptions.DlGdrive {
+	if !pixivFanboxDlOptions.DlThumbnails && !pixivFanboxDlOptions.DlImages && !pixivFanboxDlOptions.DlAttachments && !pixivFanboxDlOptions.DlGdrive && !pixivFanboxDlOptions.DlPlans {
 		return
 	}
 
+	if pixivFanboxDl.followingMatcher != nil {
+		pixivFanboxDl.addFollowedCreators(
+			pixivFanboxDlOptions,
+		)
+	}
+
+	pixivFanboxDl.CreatorIds, pixivFanboxDl.CreatorPageNums = api.FilterNewCreators(
+		utils.PIXIV_FANBOX,
+		pixivFanboxDl.CreatorIds,
+		pixivFanboxDl.CreatorPageNums,
+	)
+	if pixivFanboxDlOptions.DlPlans && len(pixivFanboxDl.CreatorIds) > 0 {
+		pixivFanboxDl.saveCreatorsPlans(
+			pixivFanboxDlOptions,
+		)
+	}
+
 	if len(pixivFanboxDl.CreatorIds) > 0 {
 		pixivFanboxDl.getCreatorsPosts(
 			pixivFanboxDlOptions,
 		)
 	}
 
+	if len(pixivFanboxDl.TagNames) > 0 {
+		pixivFanboxDl.getTagsPosts(
+			pixivFanboxDlOptions,
+		)
+	}
+
 	var urlsToDownload, gdriveUrlsToDownload []*request.ToDownload
 	if len(pixivFanboxDl.PostIds) > 0 {
 		urlsToDownload, gdriveUrlsToDownload = pixivFanboxDl.getPostDetails(
 			pixivFanboxDlOptions,
 		)
+		pixivFanboxDlOptions.warnIfNoSeriesMatches()
 	}
 
 	var downloadedPosts bool
@@ -40,7 +65,9 @@ func PixivFanboxDownloadProcess(pixivFanboxDl *PixivFanboxDl, pixivFanboxDlOptio
 	}
 	if pixivFanboxDlOptions.GdriveClient != nil && len(gdriveUrlsToDownload) > 0 {
 		downloadedPosts = true
-		pixivFanboxDlOptions.GdriveClient.DownloadGdriveUrls(gdriveUrlsToDownload, pixivFanboxDlOptions.Configs)
+		request.DeferUntilPlanned(func() {
+			pixivFanboxDlOptions.GdriveClient.DownloadGdriveUrls(gdriveUrlsToDownload, pixivFanboxDlOptions.Configs)
+		})
 	}
 
 	if downloadedPosts {
@@ -0,0 +1,148 @@
+package pixivfanbox
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const plansFilename = "plans.json"
+
+// Retrieves the support plans of the creator
+func getCreatorPlans(creatorId string, dlOptions *PixivFanboxDlOptions) ([]*models.FanboxPlan, error) {
+	url := fmt.Sprintf("%s/plan.listCreator", utils.PIXIV_FANBOX_API_URL)
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_FANBOX, true)
+	sessionLabel, cookies := dlOptions.getSessionCookies()
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:    "GET",
+			Url:       url,
+			Cookies:   cookies,
+			Headers:   GetPixivFanboxHeaders(),
+			Params:    map[string]string{"creatorId": creatorId},
+			UserAgent: dlOptions.Configs.UserAgent,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv fanbox error %d: failed to get the plans of %s, more info => %v",
+			utils.CONNECTION_ERROR,
+			creatorId,
+			err,
+		)
+	}
+	if res.StatusCode != 200 {
+		res.Body.Close()
+		dlOptions.reportSessionStatus(sessionLabel, res.StatusCode)
+		return nil, fmt.Errorf(
+			"pixiv fanbox error %d: failed to get the plans of %s due to a %s response",
+			utils.RESPONSE_ERROR,
+			creatorId,
+			res.Status,
+		)
+	}
+
+	var resJson models.FanboxCreatorPlansJson
+	if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+		return nil, err
+	}
+	return resJson.Body, nil
+}
+
+// Saves the support plans of the creator as a JSON file in the creator's directory.
+//
+// Returns false if the creator has no plans configured.
+func saveCreatorPlans(creatorId string, dlOptions *PixivFanboxDlOptions) (bool, error) {
+	plans, err := getCreatorPlans(creatorId, dlOptions)
+	if err != nil {
+		return false, err
+	}
+	if len(plans) == 0 {
+		return false, nil
+	}
+
+	plansJson, err := utils.MarshalJson(plans)
+	if err != nil {
+		return false, fmt.Errorf(
+			"pixiv fanbox error %d: failed to marshal the plans of %s, more info => %v",
+			utils.JSON_ERROR,
+			creatorId,
+			err,
+		)
+	}
+
+	creatorFolderPath := filepath.Join(
+		utils.DOWNLOAD_PATH,
+		"Pixiv-Fanbox",
+		utils.CleanPathName(creatorId),
+	)
+	plansFilePath := filepath.Join(creatorFolderPath, plansFilename)
+	if _, err := utils.WriteSidecarFile(plansFilePath, plansJson, dlOptions.Configs.OverwriteFiles); err != nil {
+		return false, fmt.Errorf(
+			"pixiv fanbox error %d: failed to save the plans of %s to %s, more info => %v",
+			utils.OS_ERROR,
+			creatorId,
+			plansFilePath,
+			err,
+		)
+	}
+	return true, nil
+}
+
+// Saves the support plans of each creator in the slice of creator IDs
+func (pf *PixivFanboxDl) saveCreatorsPlans(dlOptions *PixivFanboxDlOptions) {
+	creatorIdsLen := len(pf.CreatorIds)
+	baseMsg := "Saving the plans of creator(s) on Pixiv Fanbox [%d/" + fmt.Sprintf("%d]...", creatorIdsLen)
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished saving the plans of %d creator(s) on Pixiv Fanbox!",
+			creatorIdsLen,
+		),
+		fmt.Sprintf(
+			"Something went wrong while saving the plans of %d creator(s) on Pixiv Fanbox!\nPlease refer to logs for more details.",
+			creatorIdsLen,
+		),
+		creatorIdsLen,
+	)
+	progress.Start()
+
+	var errSlice []error
+	var noPlansCreators []string
+	for _, creatorId := range pf.CreatorIds {
+		hasPlans, err := saveCreatorPlans(creatorId, dlOptions)
+		if err != nil {
+			errSlice = append(errSlice, err)
+		} else if !hasPlans {
+			noPlansCreators = append(noPlansCreators, creatorId)
+		}
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+
+	for _, creatorId := range noPlansCreators {
+		utils.LogError(
+			nil,
+			fmt.Sprintf("pixiv fanbox creator %s has no plans configured, skipped saving its plans", creatorId),
+			false,
+			utils.INFO,
+		)
+	}
+}
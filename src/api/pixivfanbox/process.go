@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox/models"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
@@ -17,6 +21,22 @@ import (
 // https://fanbox.pixiv.help/hc/en-us/articles/360011057793-What-types-of-attachments-can-I-post-
 var pixivFanboxAllowedImageExt = []string{"jpg", "jpeg", "png", "gif"}
 
+// Matches the resize path component of Pixiv's image CDN URLs,
+// e.g. "/c/1200x630_90_a2_g5/" in "https://pixiv.pximg.net/c/1200x630_90_a2_g5/fanbox/public/images/post/1/cover/abc.jpeg"
+var thumbnailResizeRegex = regexp.MustCompile(`/c/\d+x\d+(_\w+)?/`)
+
+// Returns the URL of the cover image in the given size.
+//
+// The cover image URL returned by the API is the "medium" size which is usually 1200x630
+// while the "original" size is the uploaded image without the resize path component.
+// Other sizes are not used as the CDN only serves the resize sizes that Pixiv Fanbox itself requests.
+func getThumbnailUrl(coverImageUrl, thumbnailSize string) string {
+	if thumbnailSize == "original" {
+		return thumbnailResizeRegex.ReplaceAllString(coverImageUrl, "/")
+	}
+	return coverImageUrl
+}
+
 func detectUrlsAndPasswordsInPost(text, postFolderPath string, articleBlocks models.FanboxArticleBlocks, dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, bool) {
 	loggedPassword := false 
 	if utils.DetectPasswordInText(text) {
@@ -52,6 +72,42 @@ func detectUrlsAndPasswordsInPost(text, postFolderPath string, articleBlocks mod
 	return gdriveLinks, loggedPassword
 }
 
+// Returns the image URLs of the article post in the order they appear in the post body.
+//
+// Images in the image map that are not referenced by any of
+// the body blocks are appended at the end, sorted by their ID.
+func getArticleImageUrls(articleJson *models.FanboxArticleJson) []string {
+	imageUrls := make([]string, 0, len(articleJson.ImageMap))
+	addedImages := make(map[string]struct{}, len(articleJson.ImageMap))
+	for _, articleBlock := range articleJson.Blocks {
+		if articleBlock.Type != "image" {
+			continue
+		}
+		if _, added := addedImages[articleBlock.ImageID]; added {
+			continue
+		}
+
+		imageInfo, ok := articleJson.ImageMap[articleBlock.ImageID]
+		if !ok {
+			continue
+		}
+		addedImages[articleBlock.ImageID] = struct{}{}
+		imageUrls = append(imageUrls, imageInfo.OriginalUrl)
+	}
+
+	var unreferencedIds []string
+	for imageId := range articleJson.ImageMap {
+		if _, added := addedImages[imageId]; !added {
+			unreferencedIds = append(unreferencedIds, imageId)
+		}
+	}
+	sort.Strings(unreferencedIds)
+	for _, imageId := range unreferencedIds {
+		imageUrls = append(imageUrls, articleJson.ImageMap[imageId].OriginalUrl)
+	}
+	return imageUrls
+}
+
 func processFanboxArticlePost(postBody json.RawMessage, postFolderPath string, dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, []*request.ToDownload, error) {
 	var articleJson models.FanboxArticleJson
 	if err := utils.LoadJsonFromBytes(postBody, &articleJson); err != nil {
@@ -61,12 +117,20 @@ func processFanboxArticlePost(postBody json.RawMessage, postFolderPath string, d
 	var urlsSlice []*request.ToDownload
 	var gdriveLinks []*request.ToDownload
 	// retrieve images and attachments url(s)
-	imageMap := articleJson.ImageMap
-	if imageMap != nil && dlOptions.DlImages {
-		for _, imageInfo := range imageMap {
+	if len(articleJson.ImageMap) > 0 && dlOptions.DlImages {
+		imageUrls := getArticleImageUrls(&articleJson)
+		padWidth := len(strconv.Itoa(len(imageUrls)))
+		for idx, imageUrl := range imageUrls {
+			// prefix the filename with the image's position in
+			// the post so that the files follow the post's visual order
+			// unless all the files of the post will already be prefixed with their index
+			filename := utils.GetLastPartOfUrl(imageUrl)
+			if !request.IndexPrefixEnabled() {
+				filename = fmt.Sprintf("%0*d_%s", padWidth, idx+1, filename)
+			}
 			urlsSlice = append(urlsSlice, &request.ToDownload{
-				Url:      imageInfo.OriginalUrl,
-				FilePath: filepath.Join(postFolderPath, utils.IMAGES_FOLDER),
+				Url:      imageUrl,
+				FilePath: filepath.Join(postFolderPath, utils.IMAGES_FOLDER, filename),
 			})
 		}
 	}
@@ -214,6 +278,44 @@ func processFanboxImagePost(postBody json.RawMessage, postFolderPath string, dlO
 	return urlsSlice, gdriveLinks, nil
 }
 
+// Detects the content types of the Pixiv Fanbox post for the content filters
+func getPostContent(postType string, postBody json.RawMessage) *api.PostContent {
+	content := &api.PostContent{}
+	if postBody == nil {
+		return content
+	}
+
+	switch postType {
+	case "file":
+		var filePost models.FanboxFilePostJson
+		if err := utils.LoadJsonFromBytes(postBody, &filePost); err == nil {
+			content.AddText(filePost.Text)
+			for _, file := range filePost.Files {
+				content.AddAttachment(file.Name + "." + file.Extension)
+			}
+		}
+	case "image", "text":
+		var textPost models.FanboxTextPostJson
+		if err := utils.LoadJsonFromBytes(postBody, &textPost); err == nil {
+			content.AddText(textPost.Text)
+		}
+	case "article":
+		var articlePost models.FanboxArticleJson
+		if err := utils.LoadJsonFromBytes(postBody, &articlePost); err == nil {
+			for _, block := range articlePost.Blocks {
+				content.AddText(block.Text)
+				for _, link := range block.Links {
+					content.AddText(link.Url)
+				}
+			}
+			for _, file := range articlePost.FileMap {
+				content.AddAttachment(file.Name + "." + file.Extension)
+			}
+		}
+	}
+	return content
+}
+
 // Process the JSON response from Pixiv Fanbox's API and
 // returns a map of urls and a map of GDrive urls to download from
 func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, []*request.ToDownload, error) {
@@ -223,21 +325,42 @@ func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *P
 	}
 
 	postJson := post.Body
+	if api.PostIsTooOld(postJson.PublishedDate) {
+		return nil, nil, nil
+	}
+	if api.ContentFiltersEnabled() && api.PostLacksContent(getPostContent(postJson.Type, postJson.Body)) {
+		return nil, nil, nil
+	}
+	if !dlOptions.matchesSeries(postJson.Series) {
+		return nil, nil, nil
+	}
+
 	postId := postJson.Id
 	postTitle := postJson.Title
 	creatorId := postJson.CreatorId
-	postFolderPath := utils.GetPostFolder(
-		filepath.Join(downloadPath, "Pixiv-Fanbox"),
-		creatorId,
-		postId,
-		postTitle,
+	postFolderPath := utils.GetTemplatedPostFolder(
+		downloadPath,
+		"Pixiv-Fanbox",
+		&utils.PostFolderInfo{
+			Platform:    utils.PIXIV_FANBOX_TITLE,
+			CreatorName: creatorId,
+			PostId:      postId,
+			Title:       postTitle,
+			Date:        postJson.PublishedDate,
+		},
 	)
+	postFolderPath = dlOptions.getSeriesFolderPath(postFolderPath, postJson.Series)
+	api.RecordPost(utils.PIXIV_FANBOX_TITLE, postTitle, postJson.PublishedDate, postFolderPath)
+
+	postDeleted := func() bool {
+		return isPostDeleted(postId, dlOptions)
+	}
 
 	var urlsSlice []*request.ToDownload
 	thumbnail := postJson.CoverImageUrl
 	if dlOptions.DlThumbnails && thumbnail != "" {
 		urlsSlice = append(urlsSlice, &request.ToDownload{
-			Url:      thumbnail,
+			Url:      getThumbnailUrl(thumbnail, dlOptions.ThumbnailSize),
 			FilePath: postFolderPath,
 		})
 	}
@@ -248,6 +371,10 @@ func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *P
 	postType := postJson.Type
 	postBody := postJson.Body
 	if postBody == nil {
+		request.SetCreator(urlsSlice, utils.PIXIV_FANBOX+"/"+creatorId)
+		request.SetPost(urlsSlice, postFolderPath)
+		request.SetPostDate(urlsSlice, postJson.PublishedDate)
+		request.SetPostDeletedCheck(urlsSlice, "Pixiv Fanbox post "+postId, postDeleted)
 		return urlsSlice, nil, nil
 	}
 
@@ -286,10 +413,16 @@ func processFanboxPostJson(res *http.Response, downloadPath string, dlOptions *P
 		return nil, nil, err
 	}
 	urlsSlice = append(urlsSlice, newUrlsSlice...)
+	request.AddIndexPrefixes(urlsSlice)
+	urlsSlice = request.SelectFileIndices(urlsSlice, postFolderPath)
+	request.SetCreator(urlsSlice, utils.PIXIV_FANBOX+"/"+creatorId)
+	request.SetPost(urlsSlice, postFolderPath)
+	request.SetPostDate(urlsSlice, postJson.PublishedDate)
+	request.SetPostDeletedCheck(urlsSlice, "Pixiv Fanbox post "+postId, postDeleted)
 	return urlsSlice, gdriveLinks, nil
 }
 
-func processMultiplePostJson(resChan chan *http.Response, dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, []*request.ToDownload) {
+func processMultiplePostJson(resChan chan *postRes, dlOptions *PixivFanboxDlOptions) ([]*request.ToDownload, []*request.ToDownload) {
 	// parse the responses
 	var errSlice []error
 	var urlsSlice, gdriveUrls []*request.ToDownload
@@ -312,15 +445,18 @@ func processMultiplePostJson(resChan chan *http.Response, dlOptions *PixivFanbox
 		len(resChan),
 	)
 	progress.Start()
-	for res := range resChan {
+	for post := range resChan {
 		postUrls, postGdriveLinks, err := processFanboxPostJson(
-			res,
+			post.res,
 			utils.DOWNLOAD_PATH,
 			dlOptions,
 		)
 		if err != nil {
 			errSlice = append(errSlice, err)
 		} else {
+			for _, postUrl := range postUrls {
+				postUrl.Cookies = post.cookies
+			}
 			urlsSlice = append(urlsSlice, postUrls...)
 			gdriveUrls = append(gdriveUrls, postGdriveLinks...)
 		}
@@ -0,0 +1,100 @@
+package pixivfanbox
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+func TestGetThumbnailUrl(t *testing.T) {
+	const (
+		coverUrl    = "https://pixiv.pximg.net/c/1200x630_90_a2_g5/fanbox/public/images/post/5765432/cover/Xo8oUwRzDbk5zC1pQ8VhYbaO.jpeg"
+		originalUrl = "https://pixiv.pximg.net/fanbox/public/images/post/5765432/cover/Xo8oUwRzDbk5zC1pQ8VhYbaO.jpeg"
+	)
+
+	tests := []struct {
+		name          string
+		coverImageUrl string
+		thumbnailSize string
+		want          string
+	}{
+		{
+			name:          "medium keeps the URL from the API",
+			coverImageUrl: coverUrl,
+			thumbnailSize: "medium",
+			want:          coverUrl,
+		},
+		{
+			name:          "original strips the resize path component",
+			coverImageUrl: coverUrl,
+			thumbnailSize: "original",
+			want:          originalUrl,
+		},
+		{
+			name:          "original of a URL without a resize path component",
+			coverImageUrl: originalUrl,
+			thumbnailSize: "original",
+			want:          originalUrl,
+		},
+		{
+			name:          "original of a resize path component without a suffix",
+			coverImageUrl: "https://pixiv.pximg.net/c/1200x630/fanbox/public/images/post/5765432/cover/Xo8oUwRzDbk5zC1pQ8VhYbaO.png",
+			thumbnailSize: "original",
+			want:          "https://pixiv.pximg.net/fanbox/public/images/post/5765432/cover/Xo8oUwRzDbk5zC1pQ8VhYbaO.png",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := getThumbnailUrl(test.coverImageUrl, test.thumbnailSize); got != test.want {
+				t.Errorf("getThumbnailUrl(%q, %q) = %q, want %q", test.coverImageUrl, test.thumbnailSize, got, test.want)
+			}
+		})
+	}
+}
+
+func TestArticleImagesArePrefixedWithTheirPosition(t *testing.T) {
+	const imageUrl = "https://downloads.fanbox.cc/images/post/5765432/"
+	postBody := json.RawMessage(`{
+		"blocks": [
+			{"type": "p", "text": "The first paragraph"},
+			{"type": "image", "imageId": "b"},
+			{"type": "p", "text": "The second paragraph"},
+			{"type": "image", "imageId": "a"},
+			{"type": "header", "text": "A header"},
+			{"type": "image", "imageId": "c"},
+			{"type": "image", "imageId": "b"},
+			{"type": "p", "text": "The last paragraph"}
+		],
+		"imageMap": {
+			"a": {"id": "a", "extension": "jpeg", "originalUrl": "` + imageUrl + `a.jpeg"},
+			"b": {"id": "b", "extension": "png", "originalUrl": "` + imageUrl + `b.png"},
+			"c": {"id": "c", "extension": "jpeg", "originalUrl": "` + imageUrl + `c.jpeg"},
+			"d": {"id": "d", "extension": "gif", "originalUrl": "` + imageUrl + `d.gif"}
+		}
+	}`)
+
+	postFolderPath := t.TempDir()
+	urlsSlice, _, err := processFanboxArticlePost(postBody, postFolderPath, &PixivFanboxDlOptions{
+		DlImages: true,
+		Configs:  &configs.Config{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the images follow the order of the blocks with the unreferenced images at the end
+	wantFilenames := []string{"1_b.png", "2_a.jpeg", "3_c.jpeg", "4_d.gif"}
+	if len(urlsSlice) != len(wantFilenames) {
+		t.Fatalf("got %d files, want %d", len(urlsSlice), len(wantFilenames))
+	}
+	for idx, urlInfo := range urlsSlice {
+		wantPath := filepath.Join(postFolderPath, utils.IMAGES_FOLDER, wantFilenames[idx])
+		if urlInfo.FilePath != wantPath {
+			t.Errorf("file %d path = %q, want %q", idx+1, urlInfo.FilePath, wantPath)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package pixivfanbox
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// Checks if the post's series matches any of the series IDs or names to download.
+//
+// Always returns true if no series were supplied while posts that
+// are not in any series will never match the supplied series.
+func (pf *PixivFanboxDlOptions) matchesSeries(series *models.FanboxSeries) bool {
+	if len(pf.Series) == 0 {
+		return true
+	}
+	if series == nil {
+		return false
+	}
+
+	for _, seriesFilter := range pf.Series {
+		if seriesFilter == series.Id || strings.EqualFold(seriesFilter, series.Title) {
+			pf.seriesMatches++
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the post folder path nested under a subfolder named after
+// the series of the post if the posts are being filtered by series.
+func (pf *PixivFanboxDlOptions) getSeriesFolderPath(postFolderPath string, series *models.FanboxSeries) string {
+	if len(pf.Series) == 0 || series == nil {
+		return postFolderPath
+	}
+
+	seriesName := utils.CleanPathName(series.Title)
+	if seriesName == "" {
+		seriesName = series.Id
+	}
+	return filepath.Join(
+		filepath.Dir(postFolderPath),
+		seriesName,
+		filepath.Base(postFolderPath),
+	)
+}
+
+// Prints a warning if none of the processed posts belong to the supplied series
+// which is usually due to the creators not having any series or a typo in the series name.
+func (pf *PixivFanboxDlOptions) warnIfNoSeriesMatches() {
+	if len(pf.Series) == 0 || pf.seriesMatches > 0 {
+		return
+	}
+	color.Yellow(
+		"None of the Pixiv Fanbox posts belong to the series %q.\nPlease check that the creator(s) have the series and the series ID or name is correct.",
+		strings.Join(pf.Series, ", "),
+	)
+}
@@ -0,0 +1,131 @@
+package pixivfanbox
+
+import (
+	"fmt"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Retrieves a page of the posts tagged with the tag name across all creators
+func getTaggedPostsPage(reqUrl string, params map[string]string, dlOptions *PixivFanboxDlOptions) (*models.FanboxTaggedPostsJson, error) {
+	useHttp3 := utils.IsHttp3Supported(utils.PIXIV_FANBOX, true)
+	sessionLabel, cookies := dlOptions.getSessionCookies()
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Method:    "GET",
+			Url:       reqUrl,
+			Cookies:   cookies,
+			Headers:   GetPixivFanboxHeaders(),
+			Params:    params,
+			UserAgent: dlOptions.Configs.UserAgent,
+			Http2:     !useHttp3,
+			Http3:     useHttp3,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"pixiv fanbox error %d: failed to get the tagged posts from %s, more info => %v",
+			utils.CONNECTION_ERROR,
+			reqUrl,
+			err,
+		)
+	}
+	if res.StatusCode != 200 {
+		res.Body.Close()
+		dlOptions.reportSessionStatus(sessionLabel, res.StatusCode)
+		return nil, fmt.Errorf(
+			"pixiv fanbox error %d: failed to get the tagged posts from %s due to a %s response",
+			utils.RESPONSE_ERROR,
+			reqUrl,
+			res.Status,
+		)
+	}
+
+	var resJson models.FanboxTaggedPostsJson
+	if err := utils.LoadJsonFromResponse(res, &resJson); err != nil {
+		return nil, err
+	}
+	return &resJson, nil
+}
+
+// Returns the IDs of the posts tagged with the tag name across all creators
+// by following the "nextUrl" of each page until the max page number has been reached.
+func getTaggedPosts(tagName, pageNum string, dlOptions *PixivFanboxDlOptions) ([]string, error) {
+	minPage, maxPage, hasMax, err := utils.GetMinMaxFromStr(pageNum)
+	if err != nil {
+		return nil, err
+	}
+
+	var postIds []string
+	reqUrl := fmt.Sprintf("%s/post.listTagged", utils.PIXIV_FANBOX_API_URL)
+	params := map[string]string{"tag": tagName}
+	for curPage := 1; reqUrl != ""; curPage++ {
+		if hasMax && curPage > maxPage {
+			break
+		}
+
+		resJson, err := getTaggedPostsPage(reqUrl, params, dlOptions)
+		if err != nil {
+			return postIds, err
+		}
+		if curPage >= minPage {
+			for _, post := range resJson.Body.Items {
+				postIds = append(postIds, post.Id)
+			}
+		}
+
+		// the next URL already contains the query parameters
+		reqUrl = resJson.Body.NextUrl
+		params = nil
+	}
+	return postIds, nil
+}
+
+// Retrieves the posts based on the slice of tag names and updates its slice of post IDs accordingly
+func (pf *PixivFanboxDl) getTagsPosts(dlOptions *PixivFanboxDlOptions) {
+	tagNamesLen := len(pf.TagNames)
+	baseMsg := "Getting post ID(s) from tag(s) on Pixiv Fanbox [%d/" + fmt.Sprintf("%d]...", tagNamesLen)
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished getting post ID(s) from %d tag(s) on Pixiv Fanbox!",
+			tagNamesLen,
+		),
+		fmt.Sprintf(
+			"Something went wrong while getting post IDs from %d tag(s) on Pixiv Fanbox!\nPlease refer to logs for more details.",
+			tagNamesLen,
+		),
+		tagNamesLen,
+	)
+	progress.Start()
+
+	var errSlice []error
+	for idx, tagName := range pf.TagNames {
+		retrievedPostIds, err := getTaggedPosts(
+			tagName,
+			pf.TagPageNums[idx],
+			dlOptions,
+		)
+		if err != nil {
+			errSlice = append(errSlice, err)
+		}
+		pf.PostIds = append(pf.PostIds, retrievedPostIds...)
+		progress.MsgIncrement(baseMsg)
+	}
+
+	hasErr := false
+	if len(errSlice) > 0 {
+		hasErr = true
+		utils.LogErrors(false, nil, utils.ERROR, errSlice...)
+	}
+	progress.Stop(hasErr)
+	pf.PostIds = utils.RemoveSliceDuplicates(pf.PostIds)
+}
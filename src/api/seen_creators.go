@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const seenCreatorsFilename = "seen_creators.json"
+
+// seenCreatorsRecord maps the creators, e.g. "fanbox/creatorId",
+// to the time they were first downloaded by a successful run.
+type seenCreatorsRecord struct {
+	mu       sync.Mutex
+	Creators map[string]time.Time `json:"creators"`
+	pending  map[string]bool      // new creators processed by this run
+}
+
+// seenCreators is nil if the only new creators mode is not enabled
+var seenCreators *seenCreatorsRecord
+
+// Returns the path of the record of the previously downloaded creators in the app's config directory
+func getSeenCreatorsPath() string {
+	return filepath.Join(utils.APP_PATH, seenCreatorsFilename)
+}
+
+// SetOnlyNewCreators loads the record of the previously downloaded creators from the app's config directory
+// if enabled so that only the creators that have not been downloaded by a successful run will be processed.
+func SetOnlyNewCreators(enabled bool) error {
+	if !enabled {
+		seenCreators = nil
+		return nil
+	}
+
+	record := &seenCreatorsRecord{
+		Creators: make(map[string]time.Time),
+		pending:  make(map[string]bool),
+	}
+	recordPath := getSeenCreatorsPath()
+	data, err := os.ReadFile(recordPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf(
+			"error %d: failed to read the record of the previously downloaded creators at %s, more info => %v",
+			utils.OS_ERROR,
+			recordPath,
+			err,
+		)
+	}
+	if len(data) > 0 {
+		var prevRecord seenCreatorsRecord
+		if err := json.Unmarshal(data, &prevRecord); err != nil {
+			return fmt.Errorf(
+				"error %d: the record of the previously downloaded creators at %s is corrupted, more info => %v",
+				utils.JSON_ERROR,
+				recordPath,
+				err,
+			)
+		}
+		if prevRecord.Creators != nil {
+			record.Creators = prevRecord.Creators
+		}
+	}
+	seenCreators = record
+	return nil
+}
+
+// IsNewCreator returns true if the creator of the platform has not been downloaded by a previous successful run
+// which will be recorded as seen by SaveSeenCreators. Always returns true if the only new creators mode is not enabled.
+func IsNewCreator(platform, creatorId string) bool {
+	if seenCreators == nil {
+		return true
+	}
+
+	creator := platform + "/" + creatorId
+	seenCreators.mu.Lock()
+	defer seenCreators.mu.Unlock()
+	if _, seen := seenCreators.Creators[creator]; seen {
+		return false
+	}
+	seenCreators.pending[creator] = true
+	return true
+}
+
+// FilterNewCreators returns the creator IDs and their corresponding page numbers
+// of the platform that have not been downloaded by a previous successful run.
+func FilterNewCreators(platform string, creatorIds, pageNums []string) ([]string, []string) {
+	if seenCreators == nil {
+		return creatorIds, pageNums
+	}
+
+	var newCreatorIds, newPageNums []string
+	for idx, creatorId := range creatorIds {
+		if !IsNewCreator(platform, creatorId) {
+			continue
+		}
+		newCreatorIds = append(newCreatorIds, creatorId)
+		newPageNums = append(newPageNums, pageNums[idx])
+	}
+
+	LogSkippedCreators(platform, len(creatorIds)-len(newCreatorIds))
+	return newCreatorIds, newPageNums
+}
+
+// LogSkippedCreators logs the number of creators of the platform that were skipped by IsNewCreator
+func LogSkippedCreators(platform string, skipped int) {
+	if skipped <= 0 {
+		return
+	}
+	utils.LogError(
+		nil,
+		fmt.Sprintf("skipped %d creator(s) on %s that were already downloaded by a previous run", skipped, platform),
+		false,
+		utils.INFO,
+	)
+}
+
+// SaveSeenCreators records the new creators processed by this run as seen.
+//
+// Should only be called after a successful run so that
+// the creators will be processed again if the run had failed.
+func SaveSeenCreators() error {
+	if seenCreators == nil {
+		return nil
+	}
+
+	seenCreators.mu.Lock()
+	defer seenCreators.mu.Unlock()
+	if len(seenCreators.pending) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for creator := range seenCreators.pending {
+		seenCreators.Creators[creator] = now
+	}
+
+	data, err := utils.MarshalJson(seenCreators)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal the record of the previously downloaded creators, more info => %v",
+			utils.JSON_ERROR,
+			err,
+		)
+	}
+
+	recordPath := getSeenCreatorsPath()
+	os.MkdirAll(filepath.Dir(recordPath), 0700)
+	if err := os.WriteFile(recordPath, data, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the record of the previously downloaded creators to %s, more info => %v",
+			utils.OS_ERROR,
+			recordPath,
+			err,
+		)
+	}
+	seenCreators.pending = make(map[string]bool)
+	return nil
+}
+
+// SkipSavingSeenCreators discards the new creators processed by this run
+// and returns the number of them, e.g. when the run had errors.
+func SkipSavingSeenCreators() int {
+	if seenCreators == nil {
+		return 0
+	}
+
+	seenCreators.mu.Lock()
+	defer seenCreators.mu.Unlock()
+	pendingCount := len(seenCreators.pending)
+	seenCreators.pending = make(map[string]bool)
+	return pendingCount
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+type rotatedSession struct {
+	// label is used in the logs instead of the session value
+	label   string
+	cookies []*http.Cookie
+}
+
+// SessionRotator rotates between multiple sessions of a platform
+// per request to spread the load across multiple accounts.
+//
+// Sessions that start returning authentication errors will be dropped from the rotation.
+type SessionRotator struct {
+	site     string
+	mu       sync.Mutex
+	sessions []*rotatedSession
+	idx      int
+}
+
+// NewSessionRotator verifies each of the given session cookie values
+// and returns a SessionRotator that rotates between them.
+func NewSessionRotator(site string, sessionIds []string, userAgent string) *SessionRotator {
+	sessions := make([]*rotatedSession, 0, len(sessionIds))
+	for idx, sessionId := range sessionIds {
+		sessions = append(sessions, &rotatedSession{
+			label: fmt.Sprintf("session #%d", idx+1),
+			cookies: []*http.Cookie{
+				VerifyAndGetCookie(site, sessionId, userAgent),
+			},
+		})
+	}
+	return &SessionRotator{
+		site:     site,
+		sessions: sessions,
+	}
+}
+
+// Len returns the number of sessions still in the rotation
+func (r *SessionRotator) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sessions)
+}
+
+// Next returns the label and cookies of the next session in the rotation.
+//
+// If all sessions have been dropped, an empty label and nil cookies will be returned.
+func (r *SessionRotator) Next() (string, []*http.Cookie) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.sessions) == 0 {
+		return "", nil
+	}
+
+	session := r.sessions[r.idx%len(r.sessions)]
+	r.idx++
+	utils.LogError(
+		nil,
+		fmt.Sprintf("%s: using %s for the request", utils.GetReadableSiteStr(r.site), session.label),
+		false,
+		utils.DEBUG,
+	)
+	return session.label, session.cookies
+}
+
+// ReportStatus drops the session with the given label from the
+// rotation if the response status code is an authentication error.
+func (r *SessionRotator) ReportStatus(label string, statusCode int) {
+	if statusCode != http.StatusUnauthorized && statusCode != http.StatusForbidden {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for idx, session := range r.sessions {
+		if session.label != label {
+			continue
+		}
+
+		r.sessions = append(r.sessions[:idx], r.sessions[idx+1:]...)
+		warning := fmt.Sprintf(
+			"%s: dropped %s from the rotation due to a %d response",
+			utils.GetReadableSiteStr(r.site),
+			label,
+			statusCode,
+		)
+		utils.LogError(nil, warning, false, utils.ERROR)
+		if len(r.sessions) == 0 {
+			color.Red("\nAll %s sessions have been dropped from the rotation!", utils.GetReadableSiteStr(r.site))
+		}
+		return
+	}
+}
@@ -0,0 +1,134 @@
+package cmds
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Returns the platform of the command's argument or exits the program if it is not supported
+func getAuthPlatform(args []string) string {
+	platform, err := utils.GetKeyringPlatform(args[0])
+	if err != nil {
+		color.Red(err.Error())
+		os.Exit(1)
+	}
+	return platform
+}
+
+var (
+	authSession string
+	authCmd     = &cobra.Command{
+		Use:   "auth",
+		Short: "Manage the session cookies saved in the system keyring",
+		Long: utils.CombineStringsWithNewline(
+			"Saves the session cookies of the platforms in your OS's keyring so that they do not have to be passed on each run.",
+			"The saved session will only be used if neither a session nor a cookie file is given for the platform.",
+			"Supported platforms: fantia, pixiv, fanbox, and kemono.",
+		),
+	}
+	authSetCmd = &cobra.Command{
+		Use:   "set <platform>",
+		Short: "Save the session cookie of a platform to the system keyring",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			platform := getAuthPlatform(args)
+			session := strings.TrimSpace(authSession)
+			if session == "" {
+				fmt.Fprint(color.Output, color.YellowString("Please enter your %s session cookie value: ", platform))
+				input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && input == "" {
+					color.Red("error %d: failed to read the session cookie value, more info => %v", utils.INPUT_ERROR, err)
+					os.Exit(1)
+				}
+				session = strings.TrimSpace(input)
+			}
+			if session == "" {
+				color.Red("error %d: the session cookie value cannot be empty", utils.INPUT_ERROR)
+				os.Exit(1)
+			}
+
+			if err := utils.SetKeyringSession(platform, session); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			color.Green("Saved the %s session to the system keyring!", platform)
+		},
+	}
+	authGetCmd = &cobra.Command{
+		Use:   "get <platform>",
+		Short: "Print the session cookie of a platform saved in the system keyring",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			platform := getAuthPlatform(args)
+			session, err := utils.GetKeyringSession(platform)
+			if err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			fmt.Println(session)
+		},
+	}
+	authRemoveCmd = &cobra.Command{
+		Use:   "remove <platform>",
+		Short: "Remove the session cookie of a platform from the system keyring",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			platform := getAuthPlatform(args)
+			if err := utils.RemoveKeyringSession(platform); err != nil {
+				if errors.Is(err, utils.ErrKeyringNotFound) {
+					color.Yellow("No %s session was saved in the system keyring.", platform)
+					return
+				}
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			color.Green("Removed the %s session from the system keyring!", platform)
+		},
+	}
+)
+
+// Reads the session of the platform from the system keyring if neither
+// a session nor a cookie file was given and sets it to the session variable of the command.
+//
+// Errors are ignored as the keyring is only a fallback, e.g. it may be unavailable on a headless server.
+func readKeyringSession(platform, cookieFile string, session *string) {
+	if *session != "" || cookieFile != "" {
+		return
+	}
+
+	if keyringSession, err := utils.GetKeyringSession(platform); err == nil {
+		*session = keyringSession
+	}
+}
+
+// Same as readKeyringSession but for platforms that support multiple sessions
+func readKeyringSessions(platform, cookieFile string, sessions *[]string) {
+	if len(*sessions) > 0 || cookieFile != "" {
+		return
+	}
+
+	if keyringSession, err := utils.GetKeyringSession(platform); err == nil {
+		*sessions = strings.Fields(keyringSession)
+	}
+}
+
+func init() {
+	authSetCmd.Flags().StringVar(
+		&authSession,
+		"session",
+		"",
+		utils.CombineStringsWithNewline(
+			"The session cookie value to save. If not given, you will be prompted for it",
+			"which avoids the value being saved in your shell's history.",
+		),
+	)
+	authCmd.AddCommand(authSetCmd, authGetCmd, authRemoveCmd)
+	RootCmd.AddCommand(authCmd)
+}
@@ -0,0 +1,196 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// A 10MB test file from Cloudflare's speed test
+	defaultBenchmarkUrl = "https://speed.cloudflare.com/__down?bytes=10000000"
+
+	// The suggested number of workers is the lowest one within this ratio of the best throughput
+	// as more workers beyond that point only increase the chance of being rate limited.
+	benchmarkTolerance = 0.9
+)
+
+type benchmarkResult struct {
+	workers  int
+	files    int
+	bytes    int64
+	duration time.Duration
+}
+
+// Returns the throughput of the benchmark result in bytes per second
+func (r *benchmarkResult) throughput() float64 {
+	if r.duration <= 0 {
+		return 0
+	}
+	return float64(r.bytes) / r.duration.Seconds()
+}
+
+var (
+	benchmarkUrl       string
+	benchmarkLevels    []int
+	benchmarkFiles     int
+	benchmarkUserAgent string
+	benchmarkCmd       = &cobra.Command{
+		Use:   "benchmark",
+		Short: "Measure the download throughput at various numbers of workers",
+		Long: utils.CombineStringsWithNewline(
+			"Downloads a test file, or the given URL, with the built-in download engine at various numbers of workers",
+			"and reports the throughput of each to suggest a value for the \"--workers\" flag.",
+		),
+		Run: func(cmd *cobra.Command, args []string) {
+			if benchmarkFiles < 1 {
+				color.Red("error %d: number of files must be at least 1, got %d", utils.INPUT_ERROR, benchmarkFiles)
+				os.Exit(1)
+			}
+			for _, workers := range benchmarkLevels {
+				if workers < 1 {
+					color.Red("error %d: number of workers must be at least 1, got %d", utils.INPUT_ERROR, workers)
+					os.Exit(1)
+				}
+			}
+			sort.Ints(benchmarkLevels)
+			// the files have to be downloaded right away to measure the throughput
+			request.SetPlanFirst(false)
+
+			tempDir, err := os.MkdirTemp("", "cultured-downloader-benchmark-")
+			if err != nil {
+				utils.LogError(err, "failed to create the temporary directory for the benchmark", true, utils.ERROR)
+			}
+			defer os.RemoveAll(tempDir)
+
+			results := make([]*benchmarkResult, 0, len(benchmarkLevels))
+			for _, workers := range benchmarkLevels {
+				if request.IsShuttingDown() {
+					break
+				}
+
+				color.Yellow("\nBenchmarking %d download(s) with %d worker(s)...", benchmarkFiles, workers)
+				results = append(results, runBenchmark(tempDir, workers))
+			}
+			printBenchmarkResults(results)
+		},
+	}
+)
+
+// Downloads the benchmark URL benchmarkFiles times with the given number of workers
+func runBenchmark(tempDir string, workers int) *benchmarkResult {
+	// the max workers takes precedence over dlOptions.MaxConcurrency
+	request.SetMaxWorkers(workers)
+
+	levelDir := filepath.Join(tempDir, fmt.Sprintf("workers_%d", workers))
+	toDownload := make([]*request.ToDownload, 0, benchmarkFiles)
+	for i := 0; i < benchmarkFiles; i++ {
+		toDownload = append(toDownload, &request.ToDownload{
+			Url:      benchmarkUrl,
+			FilePath: filepath.Join(levelDir, fmt.Sprintf("file_%d.bin", i)),
+		})
+	}
+
+	startBytes := request.GetTotalDownloadedBytes()
+	startTime := time.Now()
+	request.DownloadUrls(
+		toDownload,
+		&request.DlOptions{
+			MaxConcurrency: workers,
+		},
+		&configs.Config{
+			UserAgent:      benchmarkUserAgent,
+			OverwriteFiles: true,
+		},
+	)
+	result := &benchmarkResult{
+		workers:  workers,
+		files:    benchmarkFiles,
+		bytes:    request.GetTotalDownloadedBytes() - startBytes,
+		duration: time.Since(startTime),
+	}
+	os.RemoveAll(levelDir)
+	return result
+}
+
+// Prints the benchmark results as a table and the suggested number of workers
+func printBenchmarkResults(results []*benchmarkResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKERS\tFILES\tDOWNLOADED\tTIME\tTHROUGHPUT")
+	var best float64
+	for _, result := range results {
+		fmt.Fprintf(
+			w,
+			"%d\t%d\t%.2f MB\t%s\t%.2f MB/s\n",
+			result.workers,
+			result.files,
+			float64(result.bytes)/1e6,
+			result.duration.Round(time.Millisecond),
+			result.throughput()/1e6,
+		)
+		if result.throughput() > best {
+			best = result.throughput()
+		}
+	}
+	w.Flush()
+
+	if best == 0 {
+		color.Red("\nNothing was downloaded, please check the logs and the URL.")
+		return
+	}
+	for _, result := range results {
+		if result.throughput() >= best*benchmarkTolerance {
+			color.Green(
+				"\nSuggested number of workers: %d (use \"--workers %d\")",
+				result.workers,
+				result.workers,
+			)
+			return
+		}
+	}
+}
+
+func init() {
+	benchmarkCmd.Flags().StringVar(
+		&benchmarkUrl,
+		"url",
+		defaultBenchmarkUrl,
+		utils.CombineStringsWithNewline(
+			"URL of the file to download for the benchmark.",
+			"Use a file from the platform you download from for the most accurate suggestion.",
+		),
+	)
+	benchmarkCmd.Flags().IntSliceVar(
+		&benchmarkLevels,
+		"levels",
+		[]int{1, 2, 4, 8},
+		"Numbers of workers to benchmark, separated by a comma.",
+	)
+	benchmarkCmd.Flags().IntVar(
+		&benchmarkFiles,
+		"files",
+		8,
+		"Number of times to download the file for each number of workers.",
+	)
+	benchmarkCmd.Flags().StringVar(
+		&benchmarkUserAgent,
+		"user_agent",
+		"",
+		"Set a custom User-Agent header to use when downloading the file.",
+	)
+	RootCmd.AddCommand(benchmarkCmd)
+}
@@ -0,0 +1,91 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanDirPath   string
+	cleanOlderThan int
+	cleanDryRun    bool
+	cleanCmd       = &cobra.Command{
+		Use:   "clean",
+		Short: "Remove empty and stale partially downloaded files",
+		Long:  "Scans the download directory and removes the empty files, the stale .part files, and their resume state left behind by failed or incomplete downloads.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if cleanOlderThan < 0 {
+				color.Red("error %d: --older_than cannot be negative, got %d", utils.INPUT_ERROR, cleanOlderThan)
+				os.Exit(1)
+			}
+
+			dirPaths := []string{cleanDirPath}
+			if tempDirPath != "" {
+				dirPaths = append(dirPaths, tempDirPath)
+			}
+
+			action := "Removed"
+			if cleanDryRun {
+				action = "Would remove"
+			}
+
+			removedCount := 0
+			for _, dirPath := range dirPaths {
+				if !utils.PathExists(dirPath) {
+					color.Red("error %d: %s does not exist", utils.INPUT_ERROR, dirPath)
+					os.Exit(1)
+				}
+
+				removedPaths, err := utils.CleanDirectory(dirPath, time.Duration(cleanOlderThan)*time.Hour, cleanDryRun)
+				for _, removedPath := range removedPaths {
+					fmt.Fprintf(color.Output, "%s %s\n", action, removedPath)
+				}
+				removedCount += len(removedPaths)
+				if err != nil {
+					utils.LogError(err, "", false, utils.ERROR)
+				}
+			}
+
+			if removedCount == 0 {
+				color.Green("No empty or stale partially downloaded files found!")
+			} else {
+				color.Green("%s %d empty or stale partially downloaded file(s)!", action, removedCount)
+			}
+		},
+	}
+)
+
+func init() {
+	cleanCmd.Flags().StringVar(
+		&cleanDirPath,
+		"dir",
+		"",
+		utils.CombineStringsWithNewline(
+			"The directory to clean up, e.g. your download directory.",
+			"Only the empty files, the .part files of the incomplete downloads, and their resume state are removed,",
+			"where the hidden empty placeholder files like \".nomedia\" are kept.",
+		),
+	)
+	cleanCmd.MarkFlagRequired("dir")
+	cleanCmd.Flags().IntVar(
+		&cleanOlderThan,
+		"older_than",
+		utils.DEFAULT_CLEAN_OLDER_THAN,
+		utils.CombineStringsWithNewline(
+			"Only remove the empty files and the .part files that have not been modified for the given number of hours.",
+			"Keeps the incomplete downloads that another run of the program may still be resuming.",
+		),
+	)
+	cleanCmd.Flags().BoolVar(
+		&cleanDryRun,
+		"dry_run",
+		false,
+		"Only print the files that would be removed without removing them.",
+	)
+	RootCmd.AddCommand(cleanCmd)
+}
@@ -1,26 +1,153 @@
 package cmds
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
+// Sentinel value for the ID/URL flags to read newline-separated values from stdin instead
+const stdinSentinel = "-"
+
 func getMultipleIdsMsg() string {
-	return "For multiple IDs, separate them with a comma.\nExample: \"12345,67891\" (without the quotes)"
+	return "For multiple IDs, separate them with a comma.\nExample: \"12345,67891\" (without the quotes)\n" + 
+			"Use \"-\" to read newline-separated IDs from stdin instead."
+}
+
+// Replaces the "-" sentinel value in the given slices with the newline-separated values read from stdin.
+//
+// As stdin can only be consumed once, only one of the given slices can contain the sentinel value.
+func readStdinValues(slices ...*[]string) {
+	var stdinSlice *[]string
+	for _, slice := range slices {
+		if !utils.SliceContains(*slice, stdinSentinel) {
+			continue
+		}
+
+		if stdinSlice != nil {
+			color.Red(
+				"error %d: only one flag can read from stdin using \"%s\"",
+				utils.INPUT_ERROR,
+				stdinSentinel,
+			)
+			os.Exit(1)
+		}
+		stdinSlice = slice
+	}
+	if stdinSlice == nil {
+		return
+	}
+
+	var stdinValues []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if value := strings.TrimSpace(scanner.Text()); value != "" {
+			stdinValues = append(stdinValues, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		color.Red(
+			"error %d: failed to read from stdin, more info => %v",
+			utils.OS_ERROR,
+			err,
+		)
+		os.Exit(1)
+	}
+	if len(stdinValues) == 0 {
+		color.Yellow("No values were read from stdin...")
+	}
+
+	var newSlice []string
+	for _, value := range *stdinSlice {
+		if value == stdinSentinel {
+			newSlice = append(newSlice, stdinValues...)
+		} else {
+			newSlice = append(newSlice, value)
+		}
+	}
+	*stdinSlice = newSlice
+}
+
+// Reads the session value from the session file if given
+// and sets it to the session variable of the command.
+func readSessionFile(sessionFile string, session *string) {
+	if sessionFile == "" {
+		return
+	}
+
+	if *session != "" {
+		color.Red(
+			"error %d: cannot use both the \"--session\" and \"--session_file\" flags",
+			utils.INPUT_ERROR,
+		)
+		os.Exit(1)
+	}
+
+	sessionValue, err := utils.ReadSessionFile(sessionFile)
+	if err != nil {
+		color.Red(err.Error())
+		os.Exit(1)
+	}
+	*session = sessionValue
+}
+
+// Same as readSessionFile but allows multiple session values
+// in the session file, one per line, for platforms that support session rotation.
+func readSessionsFile(sessionFile string, sessions *[]string) {
+	if sessionFile == "" {
+		return
+	}
+
+	if len(*sessions) > 0 {
+		color.Red(
+			"error %d: cannot use both the \"--session\" and \"--session_file\" flags",
+			utils.INPUT_ERROR,
+		)
+		os.Exit(1)
+	}
+
+	sessionValues, err := utils.ReadSessionFile(sessionFile)
+	if err != nil {
+		color.Red(err.Error())
+		os.Exit(1)
+	}
+	*sessions = strings.Fields(sessionValues)
 }
 
 type textFilePath struct {
 	variable *string
 	desc     string
 }
+// Returns true if the command downloads from one of the platforms
+// instead of being a utility command like "clean" or "list".
+func isDownloadCmd(cmd *cobra.Command) bool {
+	switch cmd {
+	case fantiaCmd, pixivFanboxCmd, pixivCmd, kemonoCmd:
+		return true
+	}
+	return false
+}
+
 type commonFlags struct {
-	cmd             *cobra.Command
-	overwriteVar    *bool
-	cookieFileVar   *string
-	userAgentVar    *string
-	gdriveApiKeyVar *string  
-	logUrlsVar      *bool
-	textFile        textFilePath
+	cmd                   *cobra.Command
+	overwriteVar          *bool
+	cookieFileVar         *string
+	sessionFileVar        *string
+	userAgentVar          *string
+	gdriveApiKeyVar       *string
+	gdriveExportFormatVar *string
+	gdriveSkipNativeVar   *bool
+	gdriveMaxDepthVar     *int
+	gdriveMaxFilesVar     *int
+	logUrlsVar            *bool
+	mirrorPathVar         *bool
+	textFile              textFilePath
 }
 
 func init() {
@@ -28,9 +155,15 @@ func init() {
 		{
 			cmd: fantiaCmd,
 			overwriteVar:    &fantiaOverwrite,
+			mirrorPathVar:   &fantiaMirrorPath,
 			cookieFileVar:   &fantiaCookieFile,
+			sessionFileVar: &fantiaSessionFile,
 			userAgentVar:    &fantiaUserAgent,
 			gdriveApiKeyVar: &fantiaGdriveApiKey,
+			gdriveExportFormatVar: &fantiaGdriveExportFormat,
+			gdriveSkipNativeVar:   &fantiaGdriveSkipNative,
+			gdriveMaxDepthVar:     &fantiaGdriveMaxDepth,
+			gdriveMaxFilesVar:     &fantiaGdriveMaxFiles,
 			logUrlsVar:      &fantiaLogUrls,
 			textFile: textFilePath {
 				variable: &fantiaDlTextFile,
@@ -40,9 +173,15 @@ func init() {
 		{
 			cmd: pixivFanboxCmd,
 			overwriteVar:    &fanboxOverwriteFiles,
+			mirrorPathVar:   &fanboxMirrorPath,
 			cookieFileVar:   &fanboxCookieFile,
+			sessionFileVar: &fanboxSessionFile,
 			userAgentVar:    &fanboxUserAgent,
 			gdriveApiKeyVar: &fanboxGdriveApiKey,
+			gdriveExportFormatVar: &fanboxGdriveExportFormat,
+			gdriveSkipNativeVar:   &fanboxGdriveSkipNative,
+			gdriveMaxDepthVar:     &fanboxGdriveMaxDepth,
+			gdriveMaxFilesVar:     &fanboxGdriveMaxFiles,
 			logUrlsVar:      &fanboxLogUrls,
 			textFile: textFilePath {
 				variable: &fanboxDlTextFile,
@@ -52,7 +191,9 @@ func init() {
 		{
 			cmd: pixivCmd,
 			overwriteVar:  &pixivOverwrite,
+			mirrorPathVar:   &pixivMirrorPath,
 			cookieFileVar: &pixivCookieFile,
+			sessionFileVar: &pixivSessionFile,
 			userAgentVar:  &pixivUserAgent,
 			textFile: textFilePath {
 				variable: &pixivDlTextFile,
@@ -62,9 +203,15 @@ func init() {
 		{
 			cmd: kemonoCmd,
 			overwriteVar:    &kemonoOverwrite,
+			mirrorPathVar:   &kemonoMirrorPath,
 			cookieFileVar:   &kemonoCookieFile,
+			sessionFileVar: &kemonoSessionFile,
 			userAgentVar:    &kemonoUserAgent,
 			gdriveApiKeyVar: &kemonoGdriveApiKey,
+			gdriveExportFormatVar: &kemonoGdriveExportFormat,
+			gdriveSkipNativeVar:   &kemonoGdriveSkipNative,
+			gdriveMaxDepthVar:     &kemonoGdriveMaxDepth,
+			gdriveMaxFilesVar:     &kemonoGdriveMaxFiles,
 			logUrlsVar:      &kemonoLogUrls,
 			textFile: textFilePath {
 				variable: &kemonoDlTextFile,
@@ -84,6 +231,15 @@ func init() {
 				"Usually used for Pixiv Fanbox when there are incomplete downloads.",
 			),
 		)
+		cmd.Flags().BoolVar(
+			cmdInfo.mirrorPathVar,
+			"mirror_path",
+			false,
+			utils.CombineStringsWithNewline(
+				"Save the files based on the host and path of their URLs instead of the default post-based folder structure.",
+				"E.g. https://example.com/a/b/c.jpg will be saved to <download path>/example.com/a/b/c.jpg",
+			),
+		)
 		cmd.Flags().StringVarP(
 			cmdInfo.userAgentVar,
 			"user_agent",
@@ -107,6 +263,18 @@ func init() {
 				"Pass in a file path to your saved Netscape/Mozilla generated cookie file to use when downloading.",
 				"You can generate a cookie file by using the \"Get cookies.txt LOCALLY\" extension for your browser.",
 				"Chrome Extension URL: https://chrome.google.com/webstore/detail/get-cookiestxt-locally/cclelndahbckbenkjhflpdbgdldlbecc",
+				"Only the session cookie of the platform's domains will be used and it will also be sent to their subdomains, e.g. the CDNs.",
+				"To add a domain to a platform, add it to the \"platform_domains\" key in the config.json file,",
+				"e.g. \"platform_domains\": {\"pixiv\": [\"example-cdn.com\"]}.",
+			),
+		)
+		cmd.Flags().StringVar(
+			cmdInfo.sessionFileVar,
+			"session_file",
+			"",
+			utils.CombineStringsWithNewline(
+				"Pass in a file path to a text file containing only your session cookie value.",
+				"Use this instead of the \"--session\" flag to keep the session value out of your shell history.",
 			),
 		)
 		if cmdInfo.gdriveApiKeyVar != nil {
@@ -119,6 +287,42 @@ func init() {
 					"Guide: https://github.com/KJHJason/Cultured-Downloader/blob/main/doc/google_api_key_guide.md",
 				),
 			)
+			cmd.Flags().StringVar(
+				cmdInfo.gdriveExportFormatVar,
+				"gdrive_export_format",
+				"pdf",
+				utils.CombineStringsWithNewline(
+					"Format to export Google-native files like Google Docs, Sheets, and Slides to as they cannot be downloaded directly.",
+					fmt.Sprintf(
+						"Formats: %s (falls back to pdf if the format is not supported by the file type)",
+						strings.Join(gdrive.ACCEPTED_EXPORT_FORMATS, ", "),
+					),
+				),
+			)
+			cmd.Flags().BoolVar(
+				cmdInfo.gdriveSkipNativeVar,
+				"gdrive_skip_native",
+				false,
+				"Skip Google-native files like Google Docs, Sheets, and Slides instead of exporting them. The skipped files will be logged.",
+			)
+			cmd.Flags().IntVar(
+				cmdInfo.gdriveMaxDepthVar,
+				"gdrive_max_depth",
+				0,
+				utils.CombineStringsWithNewline(
+					"Max depth of subfolders to go into when downloading a Google Drive folder where 1 only downloads the files in the folder itself.",
+					"Use 0 for no limit. The folders that are not fully downloaded as a result will be logged.",
+				),
+			)
+			cmd.Flags().IntVar(
+				cmdInfo.gdriveMaxFilesVar,
+				"gdrive_max_files",
+				0,
+				utils.CombineStringsWithNewline(
+					"Max number of files to download from a single Google Drive folder link, including its subfolders.",
+					"Use 0 for no limit. The folders that are not fully downloaded as a result will be logged.",
+				),
+			)
 		}
 		if cmdInfo.logUrlsVar != nil {
 			cmd.Flags().BoolVarP(
@@ -0,0 +1,61 @@
+package cmds
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cookiesOutput string
+	cookiesCmd    = &cobra.Command{
+		Use:   "cookies",
+		Short: "Manage the cookie files used with the \"--cookie_file\" flag",
+	}
+	cookiesMergeCmd = &cobra.Command{
+		Use:   "merge <cookie file>...",
+		Short: "Merge multiple Netscape/JSON cookie files into one",
+		Long: utils.CombineStringsWithNewline(
+			"Merges the cookies of the given Netscape (.txt) or JSON (.json) cookie files into a single cookie file.",
+			"Cookies with the same domain and name are deduplicated by keeping the one with the latest expiry.",
+			"The format of the merged cookie file is based on the file extension of the \"--output\" flag.",
+		),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cookieLists := make([][]*http.Cookie, 0, len(args))
+			for _, cookieFilePath := range args {
+				cookies, err := utils.ParseCookieFile(cookieFilePath)
+				if err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+				cookieLists = append(cookieLists, cookies)
+			}
+
+			merged := utils.MergeCookies(cookieLists...)
+			if err := utils.WriteCookieFile(cookiesOutput, merged); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			color.Green("Merged %d cookie(s) from %d file(s) into %s!", len(merged), len(args), cookiesOutput)
+		},
+	}
+)
+
+func init() {
+	cookiesMergeCmd.Flags().StringVarP(
+		&cookiesOutput,
+		"output",
+		"o",
+		"cookies.txt",
+		utils.CombineStringsWithNewline(
+			"The file path to write the merged cookies to which will be overwritten if it exists.",
+			"Use a .txt file extension for the Netscape format or .json for the JSON format.",
+		),
+	)
+	cookiesCmd.AddCommand(cookiesMergeCmd)
+	RootCmd.AddCommand(cookiesCmd)
+}
@@ -10,26 +10,35 @@ import (
 )
 
 var (
-	fantiaDlTextFile       string
-	fantiaCookieFile       string
-	fantiaSession          string
-	fantiaFanclubIds       []string
-	fantiaPageNums         []string
-	fantiaPostIds          []string
-	fantiaDlGdrive         bool
-	fantiaGdriveApiKey     string
-	fantiaDlThumbnails     bool
-	fantiaDlImages         bool
-	fantiaDlAttachments    bool
-	fantiaOverwrite        bool
-	fantiaAutoSolveCaptcha bool
-	fantiaLogUrls          bool
-	fantiaUserAgent        string
-	fantiaCmd              = &cobra.Command{
+	fantiaDlTextFile         string
+	fantiaCookieFile         string
+	fantiaSessionFile        string
+	fantiaSession            string
+	fantiaFanclubIds         []string
+	fantiaPageNums           []string
+	fantiaPostIds            []string
+	fantiaDlGdrive           bool
+	fantiaGdriveApiKey       string
+	fantiaDlThumbnails       bool
+	fantiaDlImages           bool
+	fantiaDlAttachments      bool
+	fantiaOverwrite          bool
+	fantiaAutoSolveCaptcha   bool
+	fantiaLogUrls            bool
+	fantiaUserAgent          string
+	fantiaMirrorPath         bool
+	fantiaGdriveExportFormat string
+	fantiaGdriveSkipNative   bool
+	fantiaGdriveMaxDepth     int
+	fantiaGdriveMaxFiles     int
+	fantiaCmd                = &cobra.Command{
 		Use:   "fantia",
 		Short: "Download from Fantia",
 		Long:  "Supports downloads from Fantia Fanclubs and individual posts.",
 		Run: func(cmd *cobra.Command, args []string) {
+			readSessionFile(fantiaSessionFile, &fantiaSession)
+			readKeyringSession(utils.FANTIA, fantiaCookieFile, &fantiaSession)
+			readStdinValues(&fantiaFanclubIds, &fantiaPostIds)
 			if fantiaDlTextFile != "" {
 				postIds, fanclubInfoSlice := textparser.ParseFantiaTextFile(fantiaDlTextFile)
 				fantiaPostIds = append(fantiaPostIds, postIds...)
@@ -41,9 +50,14 @@ var (
 			}
 
 			fantiaConfig := &configs.Config{
-				OverwriteFiles: fantiaOverwrite,
-				UserAgent:      fantiaUserAgent,
-				LogUrls:        fantiaLogUrls,
+				OverwriteFiles:     fantiaOverwrite,
+				UserAgent:          fantiaUserAgent,
+				MirrorPath:         fantiaMirrorPath,
+				GdriveExportFormat: fantiaGdriveExportFormat,
+				GdriveSkipNative:   fantiaGdriveSkipNative,
+				GdriveMaxDepth:     fantiaGdriveMaxDepth,
+				GdriveMaxFiles:     fantiaGdriveMaxFiles,
+				LogUrls:            fantiaLogUrls,
 			}
 
 			var gdriveClient *gdrive.GDrive
@@ -51,7 +65,7 @@ var (
 				gdriveClient = gdrive.GetNewGDrive(
 					fantiaGdriveApiKey,
 					fantiaConfig,
-					utils.MAX_CONCURRENT_DOWNLOADS,
+					gdriveMaxDls,
 				)
 			}
 
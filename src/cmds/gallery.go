@@ -0,0 +1,126 @@
+package cmds
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const galleryFilename = "index.html"
+
+// File extensions that will be shown as thumbnails in the gallery
+var galleryImageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+	".bmp":  true,
+}
+
+type galleryFile struct {
+	Name    string
+	Path    string
+	IsImage bool
+}
+
+type galleryPost struct {
+	Platform string
+	Title    string
+	Date     string
+	Files    []*galleryFile
+}
+
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Cultured Downloader Gallery</title>
+<style>
+body { font-family: sans-serif; margin: 2em; background: #1e1e1e; color: #ddd; }
+a { color: #8ab4f8; }
+.post { margin-bottom: 2em; border-bottom: 1px solid #444; padding-bottom: 1em; }
+.post h2 { margin-bottom: 0.2em; }
+.meta { color: #999; font-size: 0.9em; }
+.images { display: flex; flex-wrap: wrap; gap: 0.5em; margin: 0.5em 0; }
+.images img { height: 160px; object-fit: cover; border-radius: 4px; }
+</style>
+</head>
+<body>
+<h1>Cultured Downloader Gallery</h1>
+{{range .}}<div class="post">
+<h2>{{.Title}}</h2>
+<div class="meta">{{.Platform}}{{if .Date}} &middot; {{.Date}}{{end}}</div>
+<div class="images">{{range .Files}}{{if .IsImage}}<a href="{{.Path}}"><img src="{{.Path}}" alt="{{.Name}}" loading="lazy"></a>{{end}}{{end}}</div>
+<ul>{{range .Files}}{{if not .IsImage}}<li><a href="{{.Path}}">{{.Name}}</a></li>{{end}}{{end}}</ul>
+</div>
+{{end}}</body>
+</html>
+`))
+
+// Returns the downloaded files in the post folder with paths relative to the download directory
+func getGalleryFiles(folderPath string) []*galleryFile {
+	var files []*galleryFile
+	filepath.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || utils.IsPartFile(path) {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(utils.DOWNLOAD_PATH, path)
+		if err != nil {
+			return nil
+		}
+		files = append(files, &galleryFile{
+			Name:    d.Name(),
+			Path:    filepath.ToSlash(relPath),
+			IsImage: galleryImageExts[strings.ToLower(filepath.Ext(path))],
+		})
+		return nil
+	})
+	return files
+}
+
+// Writes a static index.html gallery of the downloaded files of the processed posts to the download directory
+func writeGalleryHtml() error {
+	var posts []*galleryPost
+	for _, post := range api.GetRecordedPosts() {
+		files := getGalleryFiles(post.FolderPath)
+		if len(files) == 0 {
+			continue
+		}
+		posts = append(posts, &galleryPost{
+			Platform: post.Platform,
+			Title:    post.Title,
+			Date:     post.Date,
+			Files:    files,
+		})
+	}
+
+	galleryPath := filepath.Join(utils.DOWNLOAD_PATH, galleryFilename)
+	f, err := os.Create(galleryPath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create the gallery at %s, more info => %v",
+			utils.OS_ERROR,
+			galleryPath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	if err := galleryTemplate.Execute(f, posts); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the gallery to %s, more info => %v",
+			utils.OS_ERROR,
+			galleryPath,
+			err,
+		)
+	}
+	return nil
+}
@@ -10,35 +10,49 @@ import (
 )
 
 var (
-	kemonoDlTextFile    string
-	kemonoCookieFile    string
-	kemonoSession       string
-	kemonoCreatorUrls   []string
-	kemonoPageNums      []string
-	kemonoPostUrls      []string
-	kemonoDlGdrive      bool
-	kemonoGdriveApiKey  string
-	kemonoDlAttachments bool
-	kemonoOverwrite     bool
-	kemonoLogUrls       bool
-	kemonoDlFav         bool
-	kemonoUserAgent     string
-	kemonoCmd           = &cobra.Command{
+	kemonoDlTextFile         string
+	kemonoCookieFile         string
+	kemonoSessionFile        string
+	kemonoSession            string
+	kemonoCreatorUrls        []string
+	kemonoPageNums           []string
+	kemonoPostUrls           []string
+	kemonoDlGdrive           bool
+	kemonoGdriveApiKey       string
+	kemonoDlAttachments      bool
+	kemonoOverwrite          bool
+	kemonoLogUrls            bool
+	kemonoDlFav              bool
+	kemonoUserAgent          string
+	kemonoMirrorPath         bool
+	kemonoGdriveExportFormat string
+	kemonoGdriveSkipNative   bool
+	kemonoGdriveMaxDepth     int
+	kemonoGdriveMaxFiles     int
+	kemonoCmd                = &cobra.Command{
 		Use:   "kemono",
 		Short: "Download from Kemono Party",
 		Long:  "Supports downloads from creators and posts on Kemono Party.",
 		Run: func(cmd *cobra.Command, args []string) {
+			readSessionFile(kemonoSessionFile, &kemonoSession)
+			readKeyringSession(utils.KEMONO, kemonoCookieFile, &kemonoSession)
+			readStdinValues(&kemonoCreatorUrls, &kemonoPostUrls)
 			kemonoConfig := &configs.Config{
-				OverwriteFiles: kemonoOverwrite,
-				UserAgent:      kemonoUserAgent,
-				LogUrls:        kemonoLogUrls,
+				OverwriteFiles:     kemonoOverwrite,
+				UserAgent:          kemonoUserAgent,
+				MirrorPath:         kemonoMirrorPath,
+				GdriveExportFormat: kemonoGdriveExportFormat,
+				GdriveSkipNative:   kemonoGdriveSkipNative,
+				GdriveMaxDepth:     kemonoGdriveMaxDepth,
+				GdriveMaxFiles:     kemonoGdriveMaxFiles,
+				LogUrls:            kemonoLogUrls,
 			}
 			var gdriveClient *gdrive.GDrive
 			if kemonoGdriveApiKey != "" {
 				gdriveClient = gdrive.GetNewGDrive(
 					kemonoGdriveApiKey,
 					kemonoConfig,
-					utils.MAX_CONCURRENT_DOWNLOADS,
+					gdriveMaxDls,
 				)
 			}
 
@@ -93,7 +107,8 @@ var (
 
 func init() {
 	mutlipleUrlsMsg := "Multiple URLs can be supplied by separating them with a comma.\n" + 
-						"Example: \"https://kemono.party/service/user/123,https://kemono.party/service/user/456\" (without the quotes)"
+						"Example: \"https://kemono.party/service/user/123,https://kemono.party/service/user/456\" (without the quotes)\n" + 
+						"Use \"-\" to read newline-separated URLs from stdin instead."
 	kemonoCmd.Flags().StringVarP(
 		&kemonoSession,
 		"session",
@@ -104,7 +119,6 @@ func init() {
 			"Required to get pass Kemono Party's DDOS protection and to download from your favourites.",
 		),
 	)
-	kemonoCmd.MarkFlagRequired("session")
 	kemonoCmd.Flags().StringSliceVar(
 		&kemonoCreatorUrls,
 		"creator_url",
@@ -0,0 +1,111 @@
+package cmds
+
+import (
+	"os"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/api/kemono"
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCreatorUrls []string
+	listPageNums    []string
+	listSession     string
+	listSessionFile string
+	listUserAgent   string
+	listOutput      string
+	listCmd         = &cobra.Command{
+		Use:   "list",
+		Short: "List the posts of Kemono Party creators without downloading",
+		Long:  "Prints the post IDs, titles, dates, and file counts of Kemono Party creators as a table or JSON for browsing.",
+		Run: func(cmd *cobra.Command, args []string) {
+			readSessionFile(listSessionFile, &listSession)
+			readKeyringSession(utils.KEMONO, "", &listSession)
+
+			listOutput = strings.ToLower(listOutput)
+			utils.ValidateStrArgs(
+				listOutput,
+				utils.ACCEPTED_LIST_OUTPUT_FORMATS,
+				[]string{
+					"Output format must be either \"table\" or \"json\".",
+				},
+			)
+
+			kemonoDl := &kemono.KemonoDl{
+				CreatorUrls:     listCreatorUrls,
+				CreatorPageNums: listPageNums,
+			}
+			kemonoDl.ValidateArgs()
+
+			kemonoDlOptions := &kemono.KemonoDlOptions{
+				Configs: &configs.Config{
+					UserAgent: listUserAgent,
+				},
+				SessionCookieId: listSession,
+			}
+			kemonoDlOptions.ValidateArgs(listUserAgent)
+
+			// the progress output is written to stderr so that stdout only contains the list
+			color.Output = os.Stderr
+			posts := kemono.ListCreatorsPosts(kemonoDl.CreatorsToDl, kemonoDlOptions)
+			if err := utils.PrintPostSummaries(posts, listOutput); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	listCmd.Flags().StringSliceVar(
+		&listCreatorUrls,
+		"creator_url",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Kemono Party creator URL(s) to list the posts from.",
+			"Multiple URLs can be supplied by separating them with a comma.",
+		),
+	)
+	listCmd.MarkFlagRequired("creator_url")
+	listCmd.Flags().StringSliceVar(
+		&listPageNums,
+		"page_num",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Min and max page numbers to search for corresponding to the order of the supplied creator URL(s).",
+			"Format: \"num\", \"minNum-maxNum\", or \"\" to list all pages",
+			"Leave blank to list all pages from each creator.",
+		),
+	)
+	listCmd.Flags().StringVarP(
+		&listSession,
+		"session",
+		"s",
+		"",
+		"Your Kemono Party \"session\" cookie value to use for the requests to Kemono Party.",
+	)
+	listCmd.Flags().StringVar(
+		&listSessionFile,
+		"session_file",
+		"",
+		"Pass in a file path to a text file containing only your session cookie value.",
+	)
+	listCmd.Flags().StringVarP(
+		&listUserAgent,
+		"user_agent",
+		"u",
+		"",
+		"Set a custom User-Agent header to use when communicating with the API(s).",
+	)
+	listCmd.Flags().StringVar(
+		&listOutput,
+		"output",
+		"table",
+		"Output format of the list. Can be \"table\" or \"json\".",
+	)
+	RootCmd.AddCommand(listCmd)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/mobile"
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixiv/ugoira"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/KJHJason/Cultured-Downloader-CLI/cmds/textparser"
 	"github.com/spf13/cobra"
@@ -22,6 +23,7 @@ var (
 	pixivFfmpegPath          string
 	pixivStartOauth          bool
 	pixivRefreshToken        string
+	pixivSessionFile         string
 	pixivSession             string
 	deleteUgoiraZip          bool
 	ugoiraQuality            int
@@ -35,13 +37,29 @@ var (
 	pixivSearchMode          string
 	pixivRatingMode          string
 	pixivArtworkType         string
+	pixivPreferSize          string
 	pixivOverwrite           bool
 	pixivUserAgent           string
+	pixivMirrorPath          bool
 	pixivCmd                 = &cobra.Command{
 		Use:   "pixiv",
 		Short: "Download from Pixiv",
 		Long:  "Supports downloads from Pixiv by artwork ID, illustrator ID, tag name, and more.",
 		Run: func(cmd *cobra.Command, args []string) {
+			if request.IsRemoteStorage() {
+				// ugoira are converted with FFmpeg which reads and writes the local filesystem
+				color.Red(
+					fmt.Sprintf(
+						"error %d: Pixiv downloads cannot be used with --storage %q as the ugoira conversions need the local filesystem",
+						utils.INPUT_ERROR,
+						storageUri,
+					),
+				)
+				os.Exit(1)
+			}
+			readSessionFile(pixivSessionFile, &pixivSession)
+			readKeyringSession(utils.PIXIV, pixivCookieFile, &pixivSession)
+			readStdinValues(&pixivArtworkIds, &pixivIllustratorIds)
 			if pixivStartOauth {
 				err := pixivmobile.NewPixivMobile("", 10).StartOauthFlow()
 				if err != nil {
@@ -59,6 +77,7 @@ var (
 				FfmpegPath:     pixivFfmpegPath,
 				OverwriteFiles: pixivOverwrite,
 				UserAgent:      pixivUserAgent,
+				MirrorPath:     pixivMirrorPath,
 			}
 			pixivConfig.ValidateFfmpeg()
 
@@ -104,6 +123,7 @@ var (
 					SearchMode:      pixivSearchMode,
 					RatingMode:      pixivRatingMode,
 					ArtworkType:     pixivArtworkType,
+					PreferSize:      pixivPreferSize,
 					Configs:         pixivConfig,
 					RefreshToken:    pixivRefreshToken,
 				}
@@ -119,6 +139,7 @@ var (
 					SearchMode:      pixivSearchMode,
 					RatingMode:      pixivRatingMode,
 					ArtworkType:     pixivArtworkType,
+					PreferSize:      pixivPreferSize,
 					Configs:         pixivConfig,
 					SessionCookieId: pixivSession,
 				}
@@ -322,4 +343,17 @@ func init() {
 			"- If you're using the \"-pixiv_refresh_token\" flag and are downloading by tag names, only \"all\" is supported.",
 		),
 	)
+	pixivCmd.Flags().StringVar(
+		&pixivPreferSize,
+		"prefer_size",
+		"original",
+		utils.CombineStringsWithNewline(
+			"Preferred image size to download from Pixiv.",
+			"Preferred Size Options:",
+			"- original: Download the original full-resolution image",
+			"- large: Download the large variant (Pixiv's \"regular\" size) which is usually 1200px",
+			"- small: Download the small variant which is usually 540px",
+			"If the preferred size is not available, the next bigger size will be downloaded instead.",
+		),
+	)
 }
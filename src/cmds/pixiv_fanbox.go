@@ -1,6 +1,8 @@
 package cmds
 
 import (
+	"strings"
+
 	"github.com/KJHJason/Cultured-Downloader-CLI/api/pixivfanbox"
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive"
@@ -10,36 +12,57 @@ import (
 )
 
 var (
-	fanboxDlTextFile     string
-	fanboxCookieFile     string
-	fanboxSession        string
-	fanboxCreatorIds     []string
-	fanboxPageNums       []string
-	fanboxPostIds        []string
-	fanboxDlThumbnails   bool
-	fanboxDlImages       bool
-	fanboxDlAttachments  bool
-	fanboxDlGdrive       bool
-	fanboxGdriveApiKey   string
-	fanboxOverwriteFiles bool
-	fanboxLogUrls        bool
-	fanboxUserAgent      string
-	pixivFanboxCmd       = &cobra.Command{
+	fanboxDlTextFile         string
+	fanboxCookieFile         string
+	fanboxSessionFile        string
+	fanboxSessions           []string
+	fanboxCreatorIds         []string
+	fanboxPageNums           []string
+	fanboxPostIds            []string
+	fanboxDlThumbnails       bool
+	fanboxThumbnailSize      string
+	fanboxDlImages           bool
+	fanboxDlAttachments      bool
+	fanboxDlGdrive           bool
+	fanboxDlPlans            bool
+	fanboxGdriveApiKey       string
+	fanboxOverwriteFiles     bool
+	fanboxLogUrls            bool
+	fanboxUserAgent          string
+	fanboxMirrorPath         bool
+	fanboxGdriveExportFormat string
+	fanboxGdriveSkipNative   bool
+	fanboxGdriveMaxDepth     int
+	fanboxGdriveMaxFiles     int
+	fanboxFollowing          string
+	fanboxFollowingRegex     bool
+	fanboxTagNames           []string
+	fanboxTagPageNums        []string
+	fanboxSeries             []string
+	pixivFanboxCmd           = &cobra.Command{
 		Use:   "pixiv_fanbox",
 		Short: "Download from Pixiv Fanbox",
 		Long:  "Supports downloads from Pixiv Fanbox creators and individual posts.",
 		Run: func(cmd *cobra.Command, args []string) {
+			readSessionsFile(fanboxSessionFile, &fanboxSessions)
+			readKeyringSessions(utils.PIXIV_FANBOX, fanboxCookieFile, &fanboxSessions)
+			readStdinValues(&fanboxCreatorIds, &fanboxPostIds)
 			pixivFanboxConfig := &configs.Config{
-				OverwriteFiles: fanboxOverwriteFiles,
-				UserAgent:      fanboxUserAgent,
-				LogUrls:        fanboxLogUrls,
+				OverwriteFiles:     fanboxOverwriteFiles,
+				UserAgent:          fanboxUserAgent,
+				MirrorPath:         fanboxMirrorPath,
+				GdriveExportFormat: fanboxGdriveExportFormat,
+				GdriveSkipNative:   fanboxGdriveSkipNative,
+				GdriveMaxDepth:     fanboxGdriveMaxDepth,
+				GdriveMaxFiles:     fanboxGdriveMaxFiles,
+				LogUrls:            fanboxLogUrls,
 			}
 			var gdriveClient *gdrive.GDrive
 			if fanboxGdriveApiKey != "" {
 				gdriveClient = gdrive.GetNewGDrive(
 					fanboxGdriveApiKey,
 					pixivFanboxConfig,
-					utils.MAX_CONCURRENT_DOWNLOADS,
+					gdriveMaxDls,
 				)
 			}
 
@@ -56,22 +79,29 @@ var (
 				CreatorIds:      fanboxCreatorIds,
 				CreatorPageNums: fanboxPageNums,
 				PostIds:         fanboxPostIds,
+				TagNames:        fanboxTagNames,
+				TagPageNums:     fanboxTagPageNums,
+
+				FollowingPattern: fanboxFollowing,
+				FollowingRegex:   fanboxFollowingRegex,
 			}
 			pixivFanboxDl.ValidateArgs()
 
 			pixivFanboxDlOptions := &pixivfanbox.PixivFanboxDlOptions{
-				DlThumbnails:    fanboxDlThumbnails,
-				DlImages:        fanboxDlImages,
-				DlAttachments:   fanboxDlAttachments,
-				Configs:         pixivFanboxConfig,
-				GdriveClient:    gdriveClient,
-				DlGdrive:        fanboxDlGdrive,
-				SessionCookieId: fanboxSession,
+				DlThumbnails:     fanboxDlThumbnails,
+				ThumbnailSize:    fanboxThumbnailSize,
+				DlImages:         fanboxDlImages,
+				DlAttachments:    fanboxDlAttachments,
+				Configs:          pixivFanboxConfig,
+				GdriveClient:     gdriveClient,
+				DlGdrive:         fanboxDlGdrive,
+				DlPlans:          fanboxDlPlans,
+				SessionCookieIds: fanboxSessions,
 			}
 			if fanboxCookieFile != "" {
 				cookies, err := utils.ParseNetscapeCookieFile(
 					fanboxCookieFile,
-					fanboxSession,
+					strings.Join(fanboxSessions, ","),
 					utils.PIXIV_FANBOX,
 				)
 				if err != nil {
@@ -97,12 +127,16 @@ var (
 
 func init() {
 	mutlipleIdsMsg := getMultipleIdsMsg()
-	pixivFanboxCmd.Flags().StringVarP(
-		&fanboxSession,
+	pixivFanboxCmd.Flags().StringSliceVarP(
+		&fanboxSessions,
 		"session",
 		"s",
-		"",
-		"Your \"FANBOXSESSID\" cookie value to use for the requests to Pixiv Fanbox.",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Your \"FANBOXSESSID\" cookie value to use for the requests to Pixiv Fanbox.",
+			"Multiple sessions can be supplied by separating them with a comma or by repeating the flag",
+			"which will be rotated per request to spread the load across your accounts.",
+		),
 	)
 	pixivFanboxCmd.Flags().StringSliceVar(
 		&fanboxCreatorIds,
@@ -132,6 +166,51 @@ func init() {
 			mutlipleIdsMsg,
 		),
 	)
+	pixivFanboxCmd.Flags().StringSliceVar(
+		&fanboxTagNames,
+		"tag_name",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Tag names to download the posts of across all creators on Pixiv Fanbox.",
+			"For multiple tags, separate them with a comma.",
+			"Example: \"tag name 1, tagName2\"",
+		),
+	)
+	pixivFanboxCmd.Flags().StringSliceVar(
+		&fanboxTagPageNums,
+		"tag_page_num",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Min and max page numbers to search for corresponding to the order of the supplied tag name(s).",
+			"Format: \"num\", \"minNum-maxNum\", or \"\" to download all pages",
+			"Leave blank to search all pages for each tag name.",
+		),
+	)
+	pixivFanboxCmd.Flags().StringSliceVar(
+		&fanboxSeries,
+		"series",
+		[]string{},
+		utils.CombineStringsWithNewline(
+			"Series ID(s) or name(s) to only download the posts of, where the posts that are not in any of the series will be skipped.",
+			"The posts will be saved under a subfolder named after their series. The series names are case-insensitive.",
+			"For multiple series, separate them with a comma.",
+		),
+	)
+	pixivFanboxCmd.Flags().StringVar(
+		&fanboxFollowing,
+		"following",
+		"",
+		utils.CombineStringsWithNewline(
+			"Download from the creators you follow whose name or creator ID matches this glob pattern, e.g. \"*illust*\".",
+			"The pattern is case-insensitive and requires your session cookie. Use \"*\" to download from all followed creators.",
+		),
+	)
+	pixivFanboxCmd.Flags().BoolVar(
+		&fanboxFollowingRegex,
+		"following_regex",
+		false,
+		"Treat the \"--following\" pattern as a regular expression instead of a glob pattern.",
+	)
 	pixivFanboxCmd.Flags().BoolVarP(
 		&fanboxDlThumbnails,
 		"dl_thumbnails",
@@ -139,6 +218,17 @@ func init() {
 		true,
 		"Whether to download the thumbnail of a Pixiv Fanbox post.",
 	)
+	pixivFanboxCmd.Flags().StringVar(
+		&fanboxThumbnailSize,
+		"thumbnail_size",
+		"medium",
+		utils.CombineStringsWithNewline(
+			"Size of the thumbnail (cover image) to download from Pixiv Fanbox.",
+			"Thumbnail Size Options:",
+			"- medium: Download the variant shown on the post which is usually 1200x630",
+			"- original: Download the original full-resolution image",
+		),
+	)
 	pixivFanboxCmd.Flags().BoolVarP(
 		&fanboxDlImages,
 		"dl_images",
@@ -160,4 +250,13 @@ func init() {
 		true,
 		"Whether to download the Google Drive links of a Pixiv Fanbox post.",
 	)
+	pixivFanboxCmd.Flags().BoolVar(
+		&fanboxDlPlans,
+		"dl_plans",
+		false,
+		utils.CombineStringsWithNewline(
+			"Whether to save the support plans (tier names, fees, and descriptions) of the Pixiv Fanbox creator(s)",
+			"as a \"plans.json\" file in each creator's directory.",
+		),
+	)
 }
@@ -0,0 +1,58 @@
+package cmds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// Prints the summary of the downloads planned by the "--plan_first" flag and starts them
+// after asking the user for confirmation unless the "--yes" flag is used.
+//
+// Returns false if the user did not confirm the plan.
+func runDownloadPlan() bool {
+	if !request.HasDownloadPlan() {
+		return true
+	}
+	if request.IsShuttingDown() {
+		request.DiscardDownloadPlan()
+		return false
+	}
+
+	summary := request.EstimateDownloadPlan()
+	color.Yellow("\nDownload plan:")
+	fmt.Fprintf(color.Output, "Files: %d", summary.Files)
+	if summary.Posts > 0 {
+		fmt.Fprintf(color.Output, " from %d post(s)", summary.Posts)
+	}
+	fmt.Fprintln(color.Output)
+	fmt.Fprintf(color.Output, "Total size: %s", utils.FormatByteSize(summary.KnownSize))
+	if summary.UnknownSizes > 0 {
+		fmt.Fprintf(color.Output, " (excluding %d file(s) of unknown size)", summary.UnknownSizes)
+	}
+	fmt.Fprintln(color.Output)
+	if summary.FreeSpace >= 0 {
+		fmt.Fprintf(color.Output, "Free space: %s\n", utils.FormatByteSize(summary.FreeSpace))
+		if summary.KnownSize > summary.FreeSpace {
+			color.Red("The planned files will not fit in the free space of the download directory!")
+		}
+	}
+
+	if !planYes {
+		var answer string
+		fmt.Fprint(color.Output, color.YellowString("Start downloading? [y/N]: "))
+		fmt.Scanln(&answer)
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			discarded := request.DiscardDownloadPlan()
+			color.Yellow("The %d planned file(s) were not downloaded.", discarded)
+			return false
+		}
+	}
+
+	request.RunDownloadPlan()
+	return true
+}
@@ -2,14 +2,93 @@ package cmds
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
 var (
 	downloadPath string
+	debugChaos   bool
+	proxyUrl     string
+	startJitter  int
+	forceIpv4    bool
+	forceIpv6    bool
+	keyControls  bool
+	tempDirPath  string
+	httpUser     string
+	httpPass     string
+	httpAuthHost string
+	maxTotalSize string
+	etagCache    bool
+	writeSummary bool
+	prettyJson   bool
+	maxFailures  int
+	minDlSpeed   int
+	stallTimeout int
+	storageUri   string
+	indexPrefix  bool
+	configDir    string
+	minFreeSpace string
+	spaceTimeout int
+	newerThan    string
+	rawFilenames bool
+	workers      int
+	gdriveMaxDls int
+	needVideo    bool
+	needAttach   bool
+	needGdrive   bool
+	writeGallery bool
+	browserHdrs  bool
+	followRedir  bool
+	fileIndex    string
+	metricsAddr  string
+	dnsServer    string
+	resumeRun    bool
+	sidecarMode  string
+	outputTmpl   string
+	waveSize     int
+	wavePause    int
+	tlsPinFile   string
+	maxIdleConns int
+	idlePerHost  int
+	connsPerHost int
+	idleTimeout  int
+	downloader   string
+	postRetries  int
+	jsonLogs     bool
+	syncRun      bool
+	delRemoved   bool
+	acceptLang   string
+	keepMtime    bool
+	maxFileAge   string
+	verifyImgs   bool
+	creatorFails int
+	maxResSize   int
+	onlyNew      bool
+	dateFormat   string
+	maxRedirects int
+	postWorkers  int
+	gdriveLinks  string
+	deletedPosts string
+	dedupAction  string
+	planFirst    bool
+	planYes      bool
+	retryOn      string
+	noMkdir      bool
+	tailChunks   int
+	printFiles   string
+	obeyRobots   bool
+	hostErrors   int
+	hostCooldown string
+	postOrder    string
+	dumpHar      string
+	harBodies    bool
 	RootCmd      = &cobra.Command{
 		Use:     "cultured-downloader-cli",
 		Version: fmt.Sprintf(
@@ -19,6 +98,352 @@ var (
 		),
 		Short:   "Download images, videos, etc. from various websites like Fantia.",
 		Long:    "Cultured Downloader CLI is a command-line tool for downloading images, videos, etc. from various websites like Pixiv, Pixiv Fanbox, Fantia, and more.",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			runStartTime = time.Now()
+			if jsonLogs && printFiles != "" {
+				// both would be written to stdout and interleave with each other
+				color.Red(
+					fmt.Sprintf(
+						"error %d: the \"--json_logs\" and \"--print_files\" flags cannot be used together as both are written to stdout",
+						utils.INPUT_ERROR,
+					),
+				)
+				os.Exit(1)
+			}
+			utils.SetJsonLogs(jsonLogs)
+			if err := utils.SetPrintFiles(printFiles); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := utils.SetAppPath(configDir); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := utils.DeleteEmptyAndOldLogs(); err != nil {
+				utils.LogError(err, "", false, utils.ERROR)
+			}
+			request.HandleShutdownSignals()
+			utils.OnShutdown(func() {
+				flushState(cmd)
+				request.StopMetricsServer()
+			})
+
+			utils.SetPrettyJson(prettyJson)
+			utils.SetNoMkdir(noMkdir)
+			if debugChaos {
+				color.Yellow("Debug chaos mode is enabled, downloads will be randomly throttled and reset!")
+				request.EnableDebugChaos()
+			}
+
+			if proxyUrl != "" {
+				if err := request.SetGlobalProxy(proxyUrl); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+			request.SetBrowserHeaders(browserHdrs)
+			request.SetPreserveMtime(keepMtime)
+			request.SetVerifyImages(verifyImgs)
+			if err := request.SetMaxAge(maxFileAge); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetAcceptLanguage(acceptLang); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			request.SetFollowRedirects(followRedir)
+			if err := request.SetMaxRedirects(maxRedirects); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if keyControls {
+				request.EnableKeyboardControls()
+			}
+
+			if err := request.SetBasicAuth(httpUser, httpPass, httpAuthHost); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			// the state of the previous runs only applies to the download commands
+			if resumeRun && isDownloadCmd(cmd) {
+				if err := request.EnableResume(getRunKey(cmd, args)); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+			if delRemoved && !syncRun {
+				color.Red("The \"--delete_removed\" flag can only be used with the \"--sync\" flag.")
+				os.Exit(1)
+			}
+			if delRemoved && isDownloadCmd(cmd) {
+				if err := checkDeleteRemovedFlags(cmd); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+			if syncRun && isDownloadCmd(cmd) {
+				if err := request.EnableSync(getRunKey(cmd, args)); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+			if etagCache {
+				if err := request.EnableEtagCache(); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+
+			if err := request.SetMaxTotalSize(maxTotalSize); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			api.SetContentFilters(needVideo, needAttach, needGdrive)
+			api.SetRecordPosts(writeGallery)
+			if err := utils.SetSidecarOverwrite(sidecarMode); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := utils.SetOutputTemplate(outputTmpl); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := utils.SetDateFormat(dateFormat); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := utils.SetGdriveSaveLinks(gdriveLinks); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetDeletedPostPolicy(deletedPosts); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetDedup(dedupAction); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			request.SetPlanFirst(planFirst)
+			if err := request.SetRetryStatusCodes(retryOn); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			request.SetIndexPrefix(indexPrefix)
+			if err := request.SetFileIndices(fileIndex); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			request.SetRawFilenames(rawFilenames)
+			if err := api.SetNewerThanFile(newerThan); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.StartMetricsServer(metricsAddr); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetStorage(storageUri); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if request.IsRemoteStorage() {
+				if writeGallery {
+					color.Red(
+						fmt.Sprintf(
+							"error %d: --gallery cannot be used with --storage %q as the gallery links to the local files",
+							utils.INPUT_ERROR,
+							storageUri,
+						),
+					)
+					os.Exit(1)
+				}
+				color.Yellow(
+					"Note: the text files of the post passwords and external links will still be written to the local filesystem.",
+				)
+			}
+			if err := request.SetDownloader(downloader); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetMinFreeSpace(minFreeSpace, spaceTimeout); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetPostRetries(postRetries); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetDownloadWaves(waveSize, wavePause); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if cmd.Flags().Changed("workers") {
+				if err := request.SetMaxWorkers(workers); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+			if err := request.SetMaxPostWorkers(postWorkers); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetTailChunks(tailChunks); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			request.SetRespectRobots(obeyRobots)
+			if err := request.SetMaxErrorsPerHost(hostErrors); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetHostCooldown(hostCooldown); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetPostOrder(postOrder); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetDumpHar(dumpHar, harBodies); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if gdriveMaxDls < 1 {
+				color.Red(
+					"error %d: number of Google Drive workers must be at least 1, got %d",
+					utils.INPUT_ERROR,
+					gdriveMaxDls,
+				)
+				os.Exit(1)
+			}
+
+			if err := request.SetTempDir(tempDirPath); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetIpVersion(forceIpv4, forceIpv6); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetConnectionPool(maxIdleConns, idlePerHost, connsPerHost, idleTimeout); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetTlsPins(tlsPinFile); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetDnsServer(dnsServer); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetMaxConsecutiveFailures(maxFailures); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := request.SetMaxCreatorFailures(creatorFails); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := utils.SetMaxResponseSize(maxResSize); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+			if err := api.SetOnlyNewCreators(onlyNew); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetMinDownloadSpeed(minDlSpeed); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetStallTimeout(stallTimeout); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			if err := request.SetMaxStartJitter(startJitter); err != nil {
+				color.Red(err.Error())
+				os.Exit(1)
+			}
+
+			for site, siteDomains := range utils.GetPlatformDomainsConfig() {
+				if err := utils.AddPlatformDomains(site, siteDomains); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+			for site, siteProxyUrl := range utils.GetPlatformProxies() {
+				if err := request.SetPlatformProxy(site, siteProxyUrl); err != nil {
+					color.Red(err.Error())
+					os.Exit(1)
+				}
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if !isDownloadCmd(cmd) {
+				// the download plan, the seen creators, and the "--newer_than" file only apply to the download commands
+				flushState(cmd)
+				request.StopMetricsServer()
+				return
+			}
+
+			// nothing was downloaded if the plan was not confirmed so no files are considered removed
+			planConfirmed := runDownloadPlan()
+			if delRemoved && planConfirmed {
+				deleteRemovedFiles()
+			}
+			flushState(cmd)
+			if writeGallery {
+				if err := writeGalleryHtml(); err != nil {
+					utils.LogError(err, "", false, utils.ERROR)
+				}
+			}
+			if resumedCount := request.ResumedCount(); resumedCount > 0 {
+				color.Green(
+					"\nSkipped %d file(s) that were already downloaded by the previous run.",
+					resumedCount,
+				)
+			}
+			if filteredCount := api.FilteredPostCount(); filteredCount > 0 {
+				color.Yellow(
+					"\nSkipped %d post(s) that did not have the content required by the \"--require_*\" flags.",
+					filteredCount,
+				)
+			}
+			if planConfirmed {
+				saveSeenCreators()
+			} else {
+				api.SkipSavingSeenCreators()
+			}
+			if skippedCreators := request.GetSkippedCreators(); len(skippedCreators) > 0 {
+				color.Yellow("\nSkipped the rest of %d creator(s) due to too many failures:", len(skippedCreators))
+				for _, skippedCreator := range skippedCreators {
+					color.Yellow("- %s (%d failures)", skippedCreator.Creator, skippedCreator.Failures)
+				}
+			}
+			if err := api.TouchNewerThanFile(runStartTime); err != nil {
+				utils.LogError(err, "", false, utils.ERROR)
+			}
+			request.StopMetricsServer()
+			if err := utils.PrintDownloadedFiles(); err != nil {
+				utils.LogError(err, "", false, utils.ERROR)
+			}
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			if downloadPath != "" {
 				err := utils.SetDefaultDownloadPath(downloadPath)
@@ -46,5 +471,735 @@ func init() {
 			"had used the Cultured Downloader Python program, the program will automatically use the path you had set.",
 		),
 	)
+	RootCmd.PersistentFlags().StringVar(
+		&newerThan,
+		"newer_than",
+		"",
+		utils.CombineStringsWithNewline(
+			"Path to a reference file where only posts published after its modification time will be downloaded.",
+			"The file will be created or touched at the end of the run for the next incremental run.",
+			"Supported for Fantia, Pixiv Fanbox, Kemono Party, and Pixiv when using the \"--refresh_token\" flag.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&fileIndex,
+		"file_index",
+		"",
+		utils.CombineStringsWithNewline(
+			"Only download the files at these positions in each post, e.g. \"1,3,5-8\".",
+			"The positions are the same as the ones used by the \"--index_prefix\" flag.",
+			"Supported for Fantia, Pixiv Fanbox, and Kemono Party.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&writeGallery,
+		"gallery",
+		false,
+		utils.CombineStringsWithNewline(
+			"Generate a static \"index.html\" gallery in the download directory at the end of the run",
+			"with the downloaded files grouped per post for offline browsing.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&needVideo,
+		"require_video",
+		false,
+		utils.CombineStringsWithNewline(
+			"Only download posts that contain a video file, e.g. an .mp4 attachment.",
+			"Supported for Fantia, Pixiv Fanbox, and Kemono Party.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&needAttach,
+		"require_attachment",
+		false,
+		utils.CombineStringsWithNewline(
+			"Only download posts that contain a downloadable attachment.",
+			"Supported for Fantia, Pixiv Fanbox, and Kemono Party.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&needGdrive,
+		"require_gdrive",
+		false,
+		utils.CombineStringsWithNewline(
+			"Only download posts that contain a Google Drive link.",
+			"Supported for Fantia, Pixiv Fanbox, and Kemono Party.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&metricsAddr,
+		"metrics_addr",
+		"",
+		utils.CombineStringsWithNewline(
+			"Address to serve the download metrics on in the Prometheus format, e.g. \":9090\".",
+			"The metrics will be available on the \"/metrics\" path until the end of the run.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&outputTmpl,
+		"output_template",
+		"",
+		utils.CombineStringsWithNewline(
+			"Go template for the directory path of each post relative to the download directory,",
+			"e.g. \"{{.Platform}}/{{.CreatorName}}/{{.Year}}/{{.PostId}}_{{.Title}}\".",
+			"Available variables: Platform, Service (Kemono Party only), CreatorName, PostId, Title, Year, Month, Day, and Date (see \"--date_format\").",
+			"Each path component is sanitised separately. Otherwise, the default \"<Platform>/<Creator>/[<Post ID>] <Title>\" structure is used.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&dateFormat,
+		"date_format",
+		utils.DEFAULT_DATE_FORMAT,
+		utils.CombineStringsWithNewline(
+			"Go reference time layout of the Date variable in the \"--output_template\", e.g. \"20060102\" or \"2006-01-02_15-04\".",
+			"Defaults to the ISO 8601 date format.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&sidecarMode,
+		"overwrite_sidecars",
+		utils.SIDECAR_OVERWRITE_ALWAYS,
+		utils.CombineStringsWithNewline(
+			"Overwrite policy for the sidecar files like the metadata JSON files which is separate from the media files.",
+			"Sidecar Overwrite Options:",
+			"- always: Always refresh the sidecar files as the posts may have been edited",
+			"- never: Never overwrite the existing sidecar files",
+			"- media: Only overwrite the existing sidecar files if the media files are overwritten via the \"--overwrite\" flag",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&planFirst,
+		"plan_first",
+		false,
+		utils.CombineStringsWithNewline(
+			"Enumerate all the posts and their files before starting any of the downloads",
+			"and print a summary of the planned files with their total size and the free space of the download directory.",
+			"You will be asked to confirm the plan before the downloads are started unless the \"--yes\" flag is used.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&planYes,
+		"yes",
+		false,
+		"Start the planned downloads of the \"--plan_first\" flag without asking for confirmation.",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&deletedPosts,
+		"deleted_posts",
+		request.DELETED_POST_SKIP,
+		utils.CombineStringsWithNewline(
+			"How to handle the files of the posts that were deleted between listing and downloading them,",
+			"which is detected via the platform's not-found response of the post after one of its files could not be found.",
+			"Deleted Post Options:",
+			"- skip: Log the post as deleted or unavailable and skip its files without counting them as failed",
+			"- fail: Treat the files as failed downloads",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&dedupAction,
+		"dedup",
+		"",
+		utils.CombineStringsWithNewline(
+			"How to handle the downloaded files with the same content as an earlier downloaded file,",
+			"which are compared by their SHA-256 hash kept in the config directory across runs. Disabled by default.",
+			"Dedup Options:",
+			"- hardlink: Replace the duplicate with a hard link, falls back to a symbolic link across devices",
+			"- symlink: Replace the duplicate with a symbolic link",
+			"- copy: Keep the duplicate as a separate copy",
+			"- skip: Remove the duplicate and only keep the first file, the removed duplicates will not be downloaded again",
+			"Note: The duplicates are kept as copies with a warning if the links could not be created,",
+			"e.g. on Windows without the administrator privileges or the developer mode for the symbolic links.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&proxyUrl,
+		"proxy",
+		"",
+		utils.CombineStringsWithNewline(
+			"Proxy URL to use for all requests, e.g. \"http://127.0.0.1:8080\" or \"socks5://127.0.0.1:1080\".",
+			"To use a different proxy for a platform, add it to the \"proxies\" key in the config.json file,",
+			"e.g. \"proxies\": {\"fantia\": \"socks5://127.0.0.1:1080\"}, which will override this flag.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&configDir,
+		"config_dir",
+		"",
+		utils.CombineStringsWithNewline(
+			"Directory to store the program's persistent files like the config file, logs, and caches in.",
+			"Defaults to the \"Cultured-Downloader\" folder in your OS's config directory, e.g. \"%AppData%\" on Windows or \"~/.config\" on Linux.",
+			"The directory will be created if it does not exist.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&browserHdrs,
+		"browser_headers",
+		false,
+		utils.CombineStringsWithNewline(
+			"Add browser-like headers such as \"Accept\", \"Accept-Language\", and \"Sec-Fetch-*\" to the requests",
+			"which may help with the bot detection on stricter platforms.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&acceptLang,
+		"lang",
+		"",
+		utils.CombineStringsWithNewline(
+			"Value of the \"Accept-Language\" header to send, e.g. \"ja\" or \"en-US,en;q=0.9\", so that the metadata and directory names",
+			"use the post titles and descriptions in that language where the platform supports it. Leave blank to use the platforms' defaults.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&followRedir,
+		"follow_redirects",
+		true,
+		utils.CombineStringsWithNewline(
+			"Whether to follow redirects automatically.",
+			"Set to false, i.e. \"--follow_redirects=false\", to return the raw 3xx response instead for debugging.",
+			"Note that some downloads, e.g. Fantia's, will not work without it as the files are served from another host.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&maxRedirects,
+		"max_redirects",
+		utils.DEFAULT_MAX_REDIRECTS,
+		utils.CombineStringsWithNewline(
+			"Max number of redirects to follow per request before it fails with the URLs of the redirect chain,",
+			"e.g. when a misconfigured CDN is stuck in a redirect loop. Set to 0 to fail on any redirect.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&dnsServer,
+		"dns_server",
+		"",
+		utils.CombineStringsWithNewline(
+			"Custom DNS server to resolve the hostnames with instead of your system's resolver, e.g. \"1.1.1.1\" or \"1.1.1.1:53\".",
+			"Useful if your ISP's DNS is unreliable. Note that HTTP/2 will be used instead of HTTP/3.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&maxIdleConns,
+		"max_idle_conns",
+		utils.DEFAULT_MAX_IDLE_CONNS,
+		"Max number of idle connections kept open across all hosts for reuse. Set to 0 for no limit.",
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&idlePerHost,
+		"max_idle_conns_per_host",
+		utils.DEFAULT_MAX_IDLE_CONNS_PER_HOST,
+		utils.CombineStringsWithNewline(
+			"Max number of idle connections kept open per host for reuse.",
+			"Should be at least the number of workers, otherwise a new connection has to be opened for most files.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&connsPerHost,
+		"max_conns_per_host",
+		0,
+		utils.CombineStringsWithNewline(
+			"Max number of connections per host including the active ones. Set to 0 for no limit.",
+			"Lowering it reduces the chance of being rate limited on high-concurrency runs but the workers will have to wait for a free connection.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&idleTimeout,
+		"idle_conn_timeout",
+		utils.DEFAULT_IDLE_CONN_TIMEOUT,
+		utils.CombineStringsWithNewline(
+			"Seconds before an idle connection is closed. Set to 0 to keep them open until the end of the run.",
+			"Note that the connection pool settings do not apply to HTTP/3 which uses a single connection per host.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&tlsPinFile,
+		"verify_tls_pin",
+		"",
+		utils.CombineStringsWithNewline(
+			"Path to a JSON file of the expected SPKI hashes per host, e.g. {\"api.fanbox.cc\": [\"sha256/<base64 hash>\"]},",
+			"to pin the TLS certificates of the platforms and CDNs where a mismatch aborts the connection.",
+			"A leading dot in the host, e.g. \".pximg.net\", also matches its subdomains. Disabled by default",
+			"as the pins have to be updated when the certificates rotate. Note that HTTP/2 will be used instead of HTTP/3.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&resumeRun,
+		"resume",
+		false,
+		utils.CombineStringsWithNewline(
+			"Resume an interrupted run by skipping the files that were already downloaded by the previous run with the same arguments.",
+			"The completed files are checkpointed to a state file in the config directory as they are downloaded, so an interrupted post",
+			"resumes from its first incomplete file. The state file is replaced when the arguments change.",
+			"The incomplete downloads are also kept and continued from where they stopped unless the file has changed since.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&maxFileAge,
+		"max_age",
+		"",
+		utils.CombineStringsWithNewline(
+			"Re-download the existing files that were last modified longer than this ago, e.g. \"7d\", \"12h\", or \"30m\",",
+			"instead of skipping them for content that gets updated in place. Leave blank to always skip the existing files.",
+			"Note that the server's time will be used as the modification time with the \"--preserve_mtime\" flag.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&keepMtime,
+		"preserve_mtime",
+		false,
+		utils.CombineStringsWithNewline(
+			"Set the modification time of the downloaded files to the server's \"Last-Modified\" time instead of the download time",
+			"for accurate sorting of your archives. Files without a valid \"Last-Modified\" header will keep the download time.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&verifyImgs,
+		"verify_images",
+		false,
+		utils.CombineStringsWithNewline(
+			"Decode the downloaded JPEG, PNG, and GIF images to verify that they are not corrupted, e.g. an error page",
+			"that was served with an image extension, and re-download the ones that could not be decoded.",
+			"Note that this uses more CPU.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&syncRun,
+		"sync",
+		false,
+		utils.CombineStringsWithNewline(
+			"Only download the new or changed files compared to the manifest of the previous run with the same arguments.",
+			"Files whose size and ETag are unchanged will be skipped while the changed ones will be overwritten.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&delRemoved,
+		"delete_removed",
+		false,
+		utils.CombineStringsWithNewline(
+			"Used with the \"--sync\" flag to delete the local files that are no longer present remotely after asking for confirmation.",
+			"Nothing will be deleted if the run was interrupted or had any errors as the remote plan may be incomplete.",
+			"It cannot be used with the flags that filter the listing, e.g. \"--newer_than\" and \"--file_index\".",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&forceIpv4,
+		"force_ipv4",
+		false,
+		"Only use IPv4 when connecting to the platforms. Note that HTTP/2 will be used instead of HTTP/3.",
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&forceIpv6,
+		"force_ipv6",
+		false,
+		"Only use IPv6 when connecting to the platforms. Note that HTTP/2 will be used instead of HTTP/3.",
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&indexPrefix,
+		"index_prefix",
+		false,
+		utils.CombineStringsWithNewline(
+			"Prefix the downloaded filenames with a zero-padded index of their order in the post, e.g. \"001_\",",
+			"so that the files are sorted in the intended sequence. The padding width is based on the number of files in the post.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&keyControls,
+		"keyboard_controls",
+		false,
+		utils.CombineStringsWithNewline(
+			"Enable keyboard controls to pause and resume the downloads.",
+			"While downloading, type \"p\" and press ENTER to pause or type \"r\" and press ENTER to resume.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&startJitter,
+		"start_jitter",
+		utils.DEFAULT_START_JITTER,
+		utils.CombineStringsWithNewline(
+			"Max random delay in milliseconds between starting each of the first concurrent downloads.",
+			"Helps to avoid being rate limited by some CDNs. Set to 0 to start all downloads at once.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&maxFailures,
+		"max_consecutive_failures",
+		utils.DEFAULT_MAX_CONSECUTIVE_FAILURES,
+		utils.CombineStringsWithNewline(
+			"Abort the run after this many consecutive failed requests across all downloads where a request only counts",
+			"as failed once its retries have run out. The 404 and 410 responses of missing files are not counted.",
+			"Prevents sending thousands of doomed requests when your session cookie has expired. Set to 0 to disable.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&maxResSize,
+		"max_response_size",
+		utils.DEFAULT_MAX_RESPONSE_SIZE,
+		utils.CombineStringsWithNewline(
+			"Max size in MB of the API and metadata responses to read into memory, where a larger response will fail",
+			"instead of running out of memory. The downloaded files are not affected by this limit. Set to 0 for no limit.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&onlyNew,
+		"only_new_creators",
+		false,
+		utils.CombineStringsWithNewline(
+			"Only process the supplied or followed creators that have not been downloaded by a previous successful run.",
+			"The creators are recorded in the config directory after a run without any errors.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&creatorFails,
+		"max_creator_failures",
+		0,
+		utils.CombineStringsWithNewline(
+			"Skip the rest of a creator after this many failed requests or downloads of the creator, e.g. a deleted or private creator,",
+			"while continuing with the other creators. The skipped creators are reported in the run summary. Set to 0 to disable.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&minDlSpeed,
+		"min_dl_speed",
+		utils.DEFAULT_MIN_DL_SPEED,
+		utils.CombineStringsWithNewline(
+			"The assumed minimum download speed in KB/s used to scale the timeout of each download with its file size.",
+			"Lower this if you have a slow connection. Set to 0 to use a flat timeout of 25 minutes for all downloads.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&stallTimeout,
+		"stall_timeout",
+		utils.DEFAULT_STALL_TIMEOUT,
+		utils.CombineStringsWithNewline(
+			"Abort and retry a download if no data has been received for this many seconds.",
+			"Frees up the download slot of a stuck connection quickly instead of waiting for the timeout. Set to 0 to disable.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&httpUser,
+		"http_user",
+		"",
+		"Username for the HTTP Basic Auth of an authenticated gateway. Requires the \"--http_auth_host\" flag.",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&httpPass,
+		"http_pass",
+		"",
+		"Password for the HTTP Basic Auth of an authenticated gateway. Requires the \"--http_auth_host\" flag.",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&httpAuthHost,
+		"http_auth_host",
+		"",
+		utils.CombineStringsWithNewline(
+			"The host to send the HTTP Basic Auth credentials to, e.g. \"gateway.example.com\".",
+			"The credentials will not be sent to any other hosts, including redirects.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&etagCache,
+		"etag_cache",
+		false,
+		utils.CombineStringsWithNewline(
+			"Cache the platforms' metadata API responses by their ETag in the app's config directory.",
+			"On subsequent runs, unchanged metadata will not be downloaded again which speeds up incremental syncs.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&prettyJson,
+		"pretty_json",
+		true,
+		utils.CombineStringsWithNewline(
+			"Indent the JSON files saved by the program, like the run summary, to make them human-readable and easier to diff.",
+			"Set to false, i.e. \"--pretty_json=false\", to save them as compact JSON instead.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&rawFilenames,
+		"raw_filenames",
+		false,
+		utils.CombineStringsWithNewline(
+			"Only unescape the filenames derived from the URLs once instead of also decoding",
+			"double-encoded names like \"%2520\" and HTML entities like \"&amp;\" or using the Content-Disposition header's filename.",
+			"Use this if you need the filenames to match the ones downloaded by older versions of the program.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&writeSummary,
+		"run_summary",
+		false,
+		utils.CombineStringsWithNewline(
+			"Write a \"run-summary.json\" file to the download directory at the end of the run",
+			"containing the start and end time, the flags used (with secrets redacted), the download counts, the total bytes, and the failures.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&maxTotalSize,
+		"max_total_size",
+		"",
+		utils.CombineStringsWithNewline(
+			"Stop starting new downloads once the total downloaded size of this run exceeds this size, e.g. \"50GB\".",
+			"Any downloads that are in progress will still be completed. Leave blank for no limit.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&minFreeSpace,
+		"min_free_space",
+		"",
+		utils.CombineStringsWithNewline(
+			"Pause starting new downloads while the free space on the download volume is below this size, e.g. \"5GB\".",
+			"The downloads will resume once enough space has been freed. Leave blank to disable.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&spaceTimeout,
+		"free_space_timeout",
+		utils.DEFAULT_FREE_SPACE_TIMEOUT,
+		utils.CombineStringsWithNewline(
+			"Abort the run if the free space stays below the \"--min_free_space\" size for this many seconds.",
+			"Set to 0 to wait indefinitely.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&storageUri,
+		"storage",
+		"local",
+		utils.CombineStringsWithNewline(
+			"Where to write the downloaded files to, \"local\" or an S3-compatible bucket, e.g. \"s3://bucket/prefix\".",
+			"For S3, the files are streamed to the bucket using the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,",
+			"and optionally the AWS_SESSION_TOKEN, AWS_REGION, and AWS_ENDPOINT_URL environment variables.",
+			"The metadata files are written to the bucket as well while the text files of the post passwords",
+			"and external links are still written locally. Pixiv and \"--gallery\" are not supported with S3.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&downloader,
+		"downloader",
+		request.BUILTIN_DOWNLOADER,
+		utils.CombineStringsWithNewline(
+			"Engine to download the files with, \"builtin\" or \"aria2c\" to delegate the downloads to an external aria2c process",
+			"while this program still handles the posts and their metadata. The built-in engine will be used instead if aria2c is",
+			"not in your PATH, the \"--storage\" flag is not \"local\", or if aria2c failed to download a file.",
+			"It will also be used if aria2c cannot honour your network settings, i.e. the \"--verify_tls_pin\" or \"--force_ipv6\" flags,",
+			"a \"--dns_server\" with a port other than 53, or a proxy that is not a http proxy.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&workers,
+		"workers",
+		utils.MAX_CONCURRENT_DOWNLOADS,
+		utils.CombineStringsWithNewline(
+			"Max number of concurrent HTTP downloads from the platforms.",
+			fmt.Sprintf(
+				"If not set, Pixiv, Pixiv Fanbox, and Kemono Party will use %d workers instead to avoid being rate limited.",
+				utils.PIXIV_MAX_CONCURRENT_DOWNLOADS,
+			),
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&postWorkers,
+		"post_workers",
+		0,
+		utils.CombineStringsWithNewline(
+			"Max number of concurrent downloads of the files within a single post, on top of the \"--workers\" limit across all posts.",
+			"Lower it to download many posts at once with only a few files each instead of hammering the CDN with the files of one post.",
+			"Set to 0 for no limit per post.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&tailChunks,
+		"tail_chunks",
+		0,
+		utils.CombineStringsWithNewline(
+			"Split each of the last large files (16 MiB and above) of a batch into up to this many parallel Range requests",
+			"using the workers that have become idle, so that a few huge files do not hold up the end of a big run.",
+			"Only used for the local storage and when the server supports Range requests. Set to 0 to disable it.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&printFiles,
+		"print_files",
+		"",
+		utils.CombineStringsWithNewline(
+			"Print the paths of the downloaded files to stdout after the run for other tools to consume, e.g. to re-encode them.",
+			"The progress output will be written to stderr instead. Leave blank to disable it.",
+			"Print Files Options:",
+			"- lines: One file path per line",
+			"- nul: File paths separated by a NUL character, e.g. for \"xargs -0\"",
+			"- json: A JSON array of the file paths",
+			"Note: It cannot be used with the \"--json_logs\" flag as the logs are written to stdout as well.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&obeyRobots,
+		"respect_robots",
+		false,
+		utils.CombineStringsWithNewline(
+			"Respect the \"Crawl-delay\" directive of the robots.txt of each host by waiting for the delay between the requests to it.",
+			"Off by default as downloading your authenticated content is not crawling, but available if you want to be extra polite.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&hostErrors,
+		"max_errors_per_host",
+		0,
+		utils.CombineStringsWithNewline(
+			"Pause all the requests to a host for the \"--host_cooldown\" after this many failed request attempts to it within a minute",
+			"to avoid getting IP banned. Unlike \"--max_consecutive_failures\", only the failing host is paused. Set to 0 to disable it.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&hostCooldown,
+		"host_cooldown",
+		"5m",
+		"How long to pause the requests to a host for after it has reached the \"--max_errors_per_host\" limit, e.g. \"30s\" or \"5m\".",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&postOrder,
+		"order",
+		"",
+		utils.CombineStringsWithNewline(
+			"The order to download the posts in by their published date. Leave blank to keep the platform's natural order.",
+			"The files of a post are kept together in their original order so the index prefixes are not affected.",
+			"Posts with the same date keep their natural order and changing it does not invalidate the \"--resume\" state.",
+			"Order Options:",
+			"- asc: Oldest posts first",
+			"- desc: Newest posts first",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&dumpHar,
+		"dump_har",
+		"",
+		utils.CombineStringsWithNewline(
+			"Record all the HTTP requests and responses of the run to this HAR 1.2 file, e.g. \"session.har\", to attach to bug reports",
+			"or to inspect in a browser's developer tools. The cookies, authorisation headers, and tokens are redacted.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&harBodies,
+		"har_bodies",
+		false,
+		utils.CombineStringsWithNewline(
+			"Also record the textual request and response bodies, e.g. the API responses, up to 1 MiB each to the \"--dump_har\" file",
+			"with the values of their secret JSON and form fields redacted. The downloaded files are never recorded.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&jsonLogs,
+		"json_logs",
+		false,
+		utils.CombineStringsWithNewline(
+			"Write the logs to stdout as JSON objects, one per line, with the level, timestamp, message, and contextual fields",
+			"like the URL, post ID, and status code instead of the log file for log collectors, e.g. when running in Docker or Kubernetes.",
+			"The progress output will be written to stderr without colours instead.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&postRetries,
+		"post_retries",
+		0,
+		utils.CombineStringsWithNewline(
+			"Number of times to retry the files that failed to download after all the other files have been downloaded,",
+			"on top of the retries per request. Only the still-failing files are retried with a longer pause before each retry.",
+			"Stalled downloads are also cancelled and requeued up to this many times so that they do not tie up a worker.",
+		),
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&retryOn,
+		"retry_on",
+		"",
+		utils.CombineStringsWithNewline(
+			"Comma-separated status codes that should trigger a retry, e.g. \"429,500,502,503,520,522\",",
+			"overriding the built-in behaviour of retrying every unsuccessful status code.",
+			"The other unsuccessful status codes will then fail right away without being retried.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&waveSize,
+		"wave_size",
+		0,
+		utils.CombineStringsWithNewline(
+			"Download large batches of files in waves of this many files with a pause between each wave",
+			"as a gentler alternative to only limiting the concurrency. Set to 0 to download all the files at once.",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&wavePause,
+		"wave_pause",
+		utils.DEFAULT_WAVE_PAUSE,
+		"Seconds to pause between each wave of downloads when the \"--wave_size\" flag is set.",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&gdriveLinks,
+		"gdrive_save_links",
+		"",
+		utils.CombineStringsWithNewline(
+			"Write a shortcut file for every detected Google Drive link to the post's gdrive folder,",
+			"regardless of whether the Google Drive files are downloaded, e.g. without a Google Drive API key.",
+			"Shortcut Format Options:",
+			"- url: Internet Shortcut (.url) files that can be opened by double-clicking them",
+			"- txt: Plain text (.txt) files containing the link",
+		),
+	)
+	RootCmd.PersistentFlags().IntVar(
+		&gdriveMaxDls,
+		"gdrive_workers",
+		utils.MAX_CONCURRENT_DOWNLOADS,
+		"Max number of concurrent Google Drive downloads, which is independent of the \"--workers\" flag.",
+	)
+	RootCmd.PersistentFlags().StringVar(
+		&tempDirPath,
+		"temp_dir",
+		"",
+		utils.CombineStringsWithNewline(
+			"Directory to write the in-progress downloads (.part files) to before moving them to the download directory.",
+			"Useful if your download directory is on a slow or network drive.",
+			"Otherwise, the .part files will be written next to the downloaded files.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&noMkdir,
+		"no_mkdir",
+		false,
+		utils.CombineStringsWithNewline(
+			"Do not create any directories for the downloads and fail the downloads whose directories do not already exist instead.",
+			"Useful to only allow the pre-provisioned directory structure to be used.",
+		),
+	)
+	RootCmd.PersistentFlags().BoolVar(
+		&debugChaos,
+		"debug_chaos",
+		false,
+		"Inject random latency and connection resets into the download process for debugging purposes.",
+	)
+	RootCmd.PersistentFlags().MarkHidden("debug_chaos")
 	RootCmd.CompletionOptions.HiddenDefaultCmd = true
 }
+
+// Saves the caches, the HAR file, and the run summary to disk.
+//
+// Called at the end of the run or when the program is interrupted.
+func flushState(cmd *cobra.Command) {
+	if err := request.SaveHarFile(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+	if err := request.SaveEtagCache(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+	if err := request.SaveResumeState(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+	if err := request.SaveSyncManifest(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+	if err := request.SaveDedupIndex(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+
+	if writeSummary {
+		if err := writeRunSummary(cmd); err != nil {
+			utils.LogError(err, "", false, utils.ERROR)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package cmds
+
+import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/api"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// Records the new creators processed by this run as seen for the "--only_new_creators" flag.
+//
+// The creators will not be recorded if the run had any errors or was interrupted
+// so that they will be processed again by the next run.
+func saveSeenCreators() {
+	if request.IsShuttingDown() || len(utils.GetLoggedErrors()) > 0 {
+		if skipped := api.SkipSavingSeenCreators(); skipped > 0 {
+			color.Yellow(
+				"\nSkipped recording %d new creator(s) as downloaded as the run was interrupted or had errors.",
+				skipped,
+			)
+		}
+		return
+	}
+
+	if err := api.SaveSeenCreators(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+}
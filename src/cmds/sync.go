@@ -0,0 +1,90 @@
+package cmds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Max number of removed files to list before asking for the confirmation
+const maxListedRemovedFiles = 20
+
+// Flags that narrow down the listing of the posts or files so that the files
+// missing from the remote plan of the run are not necessarily removed remotely
+var listingFilterFlags = []string{
+	"newer_than",
+	"file_index",
+	"only_new_creators",
+	"require_video",
+	"require_attachment",
+	"require_gdrive",
+	"max_total_size",
+	"page_num",
+	"illustrator_page_num",
+	"tag_page_num",
+}
+
+// Returns an error if any of the flags that narrow down the listing are used with the "--delete_removed" flag
+func checkDeleteRemovedFlags(cmd *cobra.Command) error {
+	for _, flagName := range listingFilterFlags {
+		if flag := cmd.Flags().Lookup(flagName); flag != nil && flag.Changed {
+			return fmt.Errorf(
+				"error %d: the \"--delete_removed\" flag cannot be used with the \"--%s\" flag as the listing would be incomplete",
+				utils.INPUT_ERROR,
+				flagName,
+			)
+		}
+	}
+	return nil
+}
+
+// Deletes the local files that are no longer present remotely after asking the user for confirmation.
+//
+// Nothing will be deleted if the run had any errors, was interrupted, or did not check all the listed files
+// as some of the posts may be missing from the remote plan of the run.
+func deleteRemovedFiles() {
+	removed := request.GetRemovedSyncFiles()
+	if len(removed) == 0 {
+		return
+	}
+
+	if request.IsShuttingDown() || len(utils.GetLoggedErrors()) > 0 {
+		color.Yellow(
+			"\nSkipped deleting %d file(s) that are no longer present remotely as the run was interrupted or had errors.",
+			len(removed),
+		)
+		return
+	}
+	if !request.IsSyncPlanComplete() || len(request.GetSkippedCreators()) > 0 {
+		color.Yellow(
+			"\nSkipped deleting %d file(s) that are no longer present remotely as not all the listed files could be checked.",
+			len(removed),
+		)
+		return
+	}
+
+	color.Yellow("\nThe following %d file(s) are no longer present remotely:", len(removed))
+	for idx, filePath := range removed {
+		if idx == maxListedRemovedFiles {
+			color.Yellow("...and %d more", len(removed)-maxListedRemovedFiles)
+			break
+		}
+		fmt.Fprintln(color.Output, filePath)
+	}
+
+	var answer string
+	fmt.Fprint(color.Output, color.YellowString("Delete them? [y/N]: "))
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		color.Yellow("The files were not deleted.")
+		return
+	}
+
+	deleted := request.DeleteRemovedSyncFiles(removed)
+	color.Green("Deleted %d file(s) that are no longer present remotely.", deleted)
+}
@@ -25,6 +25,22 @@ type Config struct {
 
 	// UserAgent is the user agent to be used in the download process
 	UserAgent      string
+
+	// MirrorPath is a flag to save the files based on the URL's path
+	// components instead of the default post-based folder structure
+	MirrorPath     bool
+
+	// GdriveExportFormat is the format to export Google-native files like Google Docs to, e.g. "pdf"
+	GdriveExportFormat string
+
+	// GdriveSkipNative is a flag to skip Google-native files instead of exporting them
+	GdriveSkipNative bool
+
+	// GdriveMaxDepth is the max depth of subfolders to go into for Google Drive folders, 0 for no limit
+	GdriveMaxDepth int
+
+	// GdriveMaxFiles is the max number of files to download per Google Drive folder, 0 for no limit
+	GdriveMaxFiles int
 }
 
 func (c *Config) ValidateFfmpeg() {
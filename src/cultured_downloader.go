@@ -12,9 +12,5 @@ func main() {
 		utils.LogError(err, "", false, utils.ERROR)
 	}
 
-	if err := utils.DeleteEmptyAndOldLogs(); err != nil {
-		utils.LogError(err, "", false, utils.ERROR)
-	}
-
 	cmds.RootCmd.Execute()
 }
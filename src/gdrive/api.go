@@ -98,8 +98,51 @@ func (gdrive *GDrive) GetFolderContents(folderId, logPath string, config *config
 	return files, nil
 }
 
+// Keeps track of the limits while going through a GDrive folder and its subfolders
+type folderWalk struct {
+	fileCount     int
+	depthLimitHit bool
+	filesLimitHit bool
+}
+
 // Retrieves the content of a GDrive folder and its subfolders recursively using GDrive API v3
+//
+// The recursion will stop at the GDrive's max depth and max files limits if set.
 func (gdrive *GDrive) GetNestedFolderContents(folderId, logPath string, config *configs.Config) ([]*models.GdriveFileToDl, error) {
+	walk := &folderWalk{}
+	files, err := gdrive.getNestedFolderContents(folderId, logPath, config, 1, walk)
+	if err != nil {
+		return nil, err
+	}
+
+	if walk.depthLimitHit {
+		utils.LogError(
+			nil,
+			fmt.Sprintf(
+				"GDrive folder with ID of %s has subfolders deeper than the max depth of %d which were not downloaded",
+				folderId,
+				gdrive.maxDepth,
+			),
+			false,
+			utils.INFO,
+		)
+	}
+	if walk.filesLimitHit {
+		utils.LogError(
+			nil,
+			fmt.Sprintf(
+				"GDrive folder with ID of %s has more files than the max of %d, the remaining files were not downloaded",
+				folderId,
+				gdrive.maxFiles,
+			),
+			false,
+			utils.INFO,
+		)
+	}
+	return files, nil
+}
+
+func (gdrive *GDrive) getNestedFolderContents(folderId, logPath string, config *configs.Config, depth int, walk *folderWalk) ([]*models.GdriveFileToDl, error) {
 	var files []*models.GdriveFileToDl
 	folderContents, err := gdrive.GetFolderContents(folderId, logPath, config)
 	if err != nil {
@@ -107,14 +150,25 @@ func (gdrive *GDrive) GetNestedFolderContents(folderId, logPath string, config *
 	}
 
 	for _, file := range folderContents {
+		if gdrive.maxFiles > 0 && walk.fileCount >= gdrive.maxFiles {
+			walk.filesLimitHit = true
+			break
+		}
+
 		if file.MimeType == "application/vnd.google-apps.folder" {
-			subFolderFiles, err := gdrive.GetNestedFolderContents(file.Id, logPath, config)
+			if gdrive.maxDepth > 0 && depth >= gdrive.maxDepth {
+				walk.depthLimitHit = true
+				continue
+			}
+
+			subFolderFiles, err := gdrive.getNestedFolderContents(file.Id, logPath, config, depth+1, walk)
 			if err != nil {
 				return nil, err
 			}
 			files = append(files, subFolderFiles...)
 		} else {
 			files = append(files, file)
+			walk.fileCount++
 		}
 	}
 	return files, nil
@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -20,7 +21,7 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive/models"
 )
 
-func md5HashFile(file *os.File) (string, error) {
+func md5HashFile(file io.Reader) (string, error) {
 	md5Checksum := md5.New()
 	_, err := io.Copy(md5Checksum, file)
 	if err != nil {
@@ -34,36 +35,34 @@ func md5HashFile(file *os.File) (string, error) {
 }
 
 func checkIfCanSkipDl(filePath string, fileInfo *models.GdriveFileToDl) (bool, error) {
-	if !utils.PathExists(filePath) {
+	storage := request.GetStorage()
+	fileSize, err := storage.Size(filePath)
+	if err == os.ErrNotExist {
 		return false, nil
-	}
-
-	// check the md5 checksum and the file size
-	file, err := os.OpenFile(filePath, os.O_RDONLY, 0666)
-	if err != nil {
+	} else if err != nil {
 		return false, fmt.Errorf(
-			"gdrive error %d: failed to open file %q, more info => %v",
+			"gdrive error %d: failed to get the size of %q, more info => %v",
 			utils.OS_ERROR,
 			filePath,
 			err,
 		)
 	}
-	defer file.Close()
 
-	fileStatInfo, err := file.Stat()
+	// check the md5 checksum and the file size
+	if strconv.FormatInt(fileSize, 10) != fileInfo.Size {
+		return false, nil
+	}
+
+	file, err := storage.Open(filePath)
 	if err != nil {
 		return false, fmt.Errorf(
-			"gdrive error %d: failed to get file stat info of %q, more info => %v",
+			"gdrive error %d: failed to open file %q, more info => %v",
 			utils.OS_ERROR,
 			filePath,
 			err,
 		)
 	}
-
-	fileSize := fileStatInfo.Size()
-	if strconv.FormatInt(fileSize, 10) != fileInfo.Size {
-		return false, nil
-	}
+	defer file.Close()
 
 	md5Checksum, err := md5HashFile(file)
 	if err != nil {
@@ -95,6 +94,56 @@ func (gdrive *GDrive) DownloadFile(fileInfo *models.GdriveFileToDl, filePath str
 	defer signal.Stop(sigs)
 
 	queue <- struct{}{}
+
+	// the file will be downloaded to a .part file first which will be resumed from
+	// where it left off if the download was interrupted and the storage backend supports it.
+	for i := 1; i <= utils.RETRY_COUNTER; i++ {
+		var retryable bool
+		retryable, err = gdrive.downloadToStorage(ctx, fileInfo, filePath, config)
+		if err == nil || !retryable {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := verifyDownloadedFile(filePath, fileInfo); err != nil {
+		return err
+	}
+	utils.RecordDownloadedFile(filePath)
+	return nil
+}
+
+// Closes the incomplete file without removing it so that its download can be resumed
+// or removes it if the storage backend cannot keep the incomplete file.
+func keepPartFile(file request.FileWriter) {
+	if closer, ok := file.(io.Closer); ok {
+		closer.Close()
+	} else {
+		file.Abort()
+	}
+}
+
+// Downloads the GDrive file to the storage backend where the download is resumed using a Range request
+// if the storage backend supports it and the .part file of an interrupted download already exists.
+//
+// The file is only committed once its size matches the file's GDrive metadata.
+// Returns true if the error was due to an interrupted download that can be retried.
+func (gdrive *GDrive) downloadToStorage(ctx context.Context, fileInfo *models.GdriveFileToDl, filePath string, config *configs.Config) (bool, error) {
+	storage := request.GetStorage()
+	partialStorage, canResume := storage.(request.PartialStorage)
+	var offset int64
+	if canResume {
+		if partFileSize, err := partialStorage.PartSize(filePath); err == nil {
+			offset = partFileSize
+		}
+	}
+
+	headers := map[string]string{}
+	if offset > 0 {
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	}
 	params := map[string]string{
 		"key":              gdrive.apiKey,
 		"alt":              "media", // to tell Google that we are downloading the file
@@ -106,6 +155,7 @@ func (gdrive *GDrive) DownloadFile(fileInfo *models.GdriveFileToDl, filePath str
 			Url:       url,
 			Method:    "GET",
 			Timeout:   gdrive.downloadTimeout,
+			Headers:   headers,
 			Params:    params,
 			Context:   ctx,
 			UserAgent: config.UserAgent,
@@ -114,28 +164,144 @@ func (gdrive *GDrive) DownloadFile(fileInfo *models.GdriveFileToDl, filePath str
 		},
 	)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		return getFailedApiCallErr(res)
+
+	var file request.FileWriter
+	switch {
+	case res.StatusCode == http.StatusPartialContent && offset > 0:
+		file, err = partialStorage.OpenAppend(filePath)
+	case res.StatusCode == http.StatusOK:
+		// the range was ignored, hence the download has to start over
+		offset = 0
+		file, err = storage.Create(filePath, res.ContentLength)
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the .part file is already complete or is larger than the file
+		// which will be checked against the file's size below
+		file, err = partialStorage.OpenAppend(filePath)
+	default:
+		return false, getFailedApiCallErr(res)
+	}
+	if err != nil {
+		return false, fmt.Errorf(
+			"gdrive error %d: failed to open file %q, more info => %v",
+			utils.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	var written int64
+	if res.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		written, err = io.Copy(file, res.Body)
 	}
-	return request.DlToFile(res, url, filePath)
+	if err != nil {
+		if canResume {
+			keepPartFile(file)
+		} else {
+			file.Abort()
+		}
+		if err == context.Canceled {
+			return false, err
+		}
+		return true, fmt.Errorf(
+			"gdrive error %d: download of %q was interrupted, more info => %v",
+			utils.DOWNLOAD_ERROR,
+			fileInfo.Name,
+			err,
+		)
+	}
+
+	// If the .part file is smaller than expected, it is kept so that the download
+	// can be resumed on the next run. Otherwise, the corrupted .part file will be deleted.
+	fileSize := offset + written
+	if expectedSize, err := strconv.ParseInt(fileInfo.Size, 10, 64); err == nil && fileSize != expectedSize {
+		if canResume && fileSize < expectedSize {
+			keepPartFile(file)
+		} else {
+			file.Abort()
+		}
+		return false, fmt.Errorf(
+			"gdrive error %d: size mismatch for %q, expected %d bytes but got %d bytes",
+			utils.DOWNLOAD_ERROR,
+			fileInfo.Name,
+			expectedSize,
+			fileSize,
+		)
+	}
+	if err := file.Commit(); err != nil {
+		return false, fmt.Errorf(
+			"gdrive error %d: failed to save file %q, more info => %v",
+			utils.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	return false, nil
 }
 
-func filterDownloads(files []*models.GdriveFileToDl) []*models.GdriveFileToDl {
+// Verifies the md5 checksum of the downloaded file against the file's GDrive metadata
+// and deletes the corrupted file so that it will be downloaded again.
+func verifyDownloadedFile(filePath string, fileInfo *models.GdriveFileToDl) error {
+	if fileInfo.Md5Checksum == "" {
+		return nil
+	}
+
+	storage := request.GetStorage()
+	file, err := storage.Open(filePath)
+	if err != nil {
+		return fmt.Errorf(
+			"gdrive error %d: failed to open file %q, more info => %v",
+			utils.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	md5Checksum, err := md5HashFile(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	if md5Checksum != fileInfo.Md5Checksum {
+		storage.Remove(filePath)
+		return fmt.Errorf(
+			"gdrive error %d: md5 checksum mismatch for %q, expected %s but got %s",
+			utils.DOWNLOAD_ERROR,
+			fileInfo.Name,
+			fileInfo.Md5Checksum,
+			md5Checksum,
+		)
+	}
+	return nil
+}
+
+// Filters out the files that cannot be downloaded and sets the
+// export format of the Google-native files that will be exported instead.
+func (gdrive *GDrive) filterDownloads(files []*models.GdriveFileToDl) []*models.GdriveFileToDl {
 	var notAllowedForDownload []*models.GdriveFileToDl
 	allowedForDownload := make([]*models.GdriveFileToDl, 0, len(files))
 	for _, file := range files {
-		if strings.Contains(file.MimeType, "application/vnd.google-apps") {
-			notAllowedForDownload = append(notAllowedForDownload, file)
-		} else {
+		if !isGoogleNativeFile(file.MimeType) {
+			allowedForDownload = append(allowedForDownload, file)
+			continue
+		}
+
+		if !gdrive.skipNative {
+			file.ExportFormat = getExportFormat(file.MimeType, gdrive.exportFormat)
+		}
+		if file.ExportFormat != "" {
 			allowedForDownload = append(allowedForDownload, file)
+		} else {
+			notAllowedForDownload = append(notAllowedForDownload, file)
 		}
 	}
 
 	if len(notAllowedForDownload) > 0 {
 		noticeMsg := "The following files are not allowed for download:\n"
+		if gdrive.skipNative {
+			noticeMsg = "The following files were skipped as they are Google-native files or cannot be downloaded:\n"
+		}
 		for _, file := range notAllowedForDownload {
 			noticeMsg += fmt.Sprintf(
 				"Filename: %s (ID: %s, MIME Type: %s)\n",
@@ -167,14 +333,14 @@ func processGdriveDlError(errChan chan *models.GdriveError, progress *spinner.Sp
 
 	if killProgram {
 		progress.KillProgram(
-			"Stopped downloading GDrive files (incomplete downloads will be deleted)...",
+			"Stopped downloading GDrive files (incomplete downloads will be resumed on the next run)...",
 		)
 	}
 }
 
 // Downloads the multiple GDrive file in parallel using GDrive API v3
 func (gdrive *GDrive) DownloadMultipleFiles(files []*models.GdriveFileToDl, config *configs.Config) {
-	allowedForDownload := filterDownloads(files)
+	allowedForDownload := gdrive.filterDownloads(files)
 	if len(allowedForDownload) == 0 {
 		return
 	}
@@ -214,10 +380,15 @@ func (gdrive *GDrive) DownloadMultipleFiles(files []*models.GdriveFileToDl, conf
 				<-queue
 			}()
 
-			os.MkdirAll(file.FilePath, 0755)
 			filePath := filepath.Join(file.FilePath, file.Name)
-
-			err := gdrive.DownloadFile(file, filePath, config, queue)
+			err := request.GetStorage().MkdirAll(file.FilePath)
+			if err == nil {
+				if file.ExportFormat != "" {
+					err = gdrive.ExportFile(file, filePath+"."+file.ExportFormat, config, queue)
+				} else {
+					err = gdrive.DownloadFile(file, filePath, config, queue)
+				}
+			}
 			if err != nil && err != context.Canceled {
 				err = fmt.Errorf(
 					"failed to download file: %s (ID: %s, MIME Type: %s)\nRefer to error details below:\n%v",
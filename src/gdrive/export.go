@@ -0,0 +1,128 @@
+package gdrive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/gdrive/models"
+	"github.com/KJHJason/Cultured-Downloader-CLI/request"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const GOOGLE_NATIVE_MIME_PREFIX = "application/vnd.google-apps"
+
+var (
+	ACCEPTED_EXPORT_FORMATS = []string{
+		"pdf",
+		"docx",
+		"xlsx",
+		"pptx",
+	}
+	exportMimeTypes = map[string]string{
+		"pdf":  "application/pdf",
+		"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	}
+
+	// The export formats supported by each Google-native file type.
+	// Other Google-native types like folders, forms, and shortcuts cannot be exported.
+	nativeExportFormats = map[string][]string{
+		GOOGLE_NATIVE_MIME_PREFIX + ".document":     {"pdf", "docx"},
+		GOOGLE_NATIVE_MIME_PREFIX + ".spreadsheet":  {"pdf", "xlsx"},
+		GOOGLE_NATIVE_MIME_PREFIX + ".presentation": {"pdf", "pptx"},
+		GOOGLE_NATIVE_MIME_PREFIX + ".drawing":      {"pdf"},
+	}
+)
+
+func isGoogleNativeFile(mimeType string) bool {
+	return strings.HasPrefix(mimeType, GOOGLE_NATIVE_MIME_PREFIX)
+}
+
+// Returns the format to export the Google-native file to based on the preferred format.
+//
+// Falls back to pdf if the preferred format is not supported by the file type
+// and returns an empty string if the file type cannot be exported at all.
+func getExportFormat(mimeType, preferredFormat string) string {
+	supportedFormats, ok := nativeExportFormats[mimeType]
+	if !ok {
+		return ""
+	}
+	if utils.SliceContains(supportedFormats, preferredFormat) {
+		return preferredFormat
+	}
+	return "pdf"
+}
+
+// Exports the given Google-native file like Google Docs to the file's
+// export format using the GDrive API v3 and saves it to the file path.
+func (gdrive *GDrive) ExportFile(fileInfo *models.GdriveFileToDl, filePath string, config *configs.Config, queue chan struct{}) error {
+	storage := request.GetStorage()
+	if storage.Exists(filePath) && !config.OverwriteFiles {
+		return nil
+	}
+
+	// Create a context that can be cancelled when SIGINT/SIGTERM signal is received
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Catch SIGINT/SIGTERM signal and cancel the context when received
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancel()
+	}()
+	defer signal.Stop(sigs)
+
+	queue <- struct{}{}
+	params := map[string]string{
+		"key":      gdrive.apiKey,
+		"mimeType": exportMimeTypes[fileInfo.ExportFormat],
+	}
+	url := fmt.Sprintf("%s/%s/export", gdrive.apiUrl, fileInfo.Id)
+	res, err := request.CallRequest(
+		&request.RequestArgs{
+			Url:       url,
+			Method:    "GET",
+			Timeout:   gdrive.downloadTimeout,
+			Params:    params,
+			Context:   ctx,
+			UserAgent: config.UserAgent,
+			Http2:     !HTTP3_SUPPORTED,
+			Http3:     HTTP3_SUPPORTED,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return getFailedApiCallErr(res)
+	}
+
+	file, err := storage.Create(filePath, res.ContentLength)
+	if err != nil {
+		return fmt.Errorf(
+			"gdrive error %d: failed to create file %q, more info => %v",
+			utils.OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	if _, err = io.Copy(file, res.Body); err != nil {
+		file.Abort()
+		return err
+	}
+	if err := file.Commit(); err != nil {
+		return err
+	}
+	utils.RecordDownloadedFile(filePath)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/request"
@@ -32,6 +33,10 @@ type GDrive struct {
 	timeout            int    // timeout in seconds for GDrive API v3
 	downloadTimeout    int    // timeout in seconds for GDrive file downloads
 	maxDownloadWorkers int    // max concurrent workers for downloading files
+	exportFormat       string // format to export Google-native files to
+	skipNative         bool   // skip Google-native files instead of exporting them
+	maxDepth           int    // max depth of subfolders to go into, 0 for no limit
+	maxFiles           int    // max number of files per folder link, 0 for no limit
 }
 
 // Returns a GDrive structure with the given API key and max download workers
@@ -42,6 +47,31 @@ func GetNewGDrive(apiKey string, config *configs.Config, maxDownloadWorkers int)
 		timeout:            15,
 		downloadTimeout:    900, // 15 minutes
 		maxDownloadWorkers: maxDownloadWorkers,
+		exportFormat:       "pdf",
+		skipNative:         config.GdriveSkipNative,
+		maxDepth:           config.GdriveMaxDepth,
+		maxFiles:           config.GdriveMaxFiles,
+	}
+	if config.GdriveExportFormat != "" {
+		gdrive.exportFormat = utils.ValidateStrArgs(
+			strings.ToLower(config.GdriveExportFormat),
+			ACCEPTED_EXPORT_FORMATS,
+			[]string{
+				fmt.Sprintf(
+					"gdrive error %d: Google Drive export format %q is not supported.",
+					utils.INPUT_ERROR,
+					config.GdriveExportFormat,
+				),
+			},
+		)
+	}
+
+	if gdrive.maxDepth < 0 || gdrive.maxFiles < 0 {
+		color.Red(
+			"gdrive error %d: Google Drive max depth and max files cannot be negative.",
+			utils.INPUT_ERROR,
+		)
+		os.Exit(1)
 	}
 
 	gdriveIsValid, err := gdrive.GDriveKeyIsValid(config.UserAgent)
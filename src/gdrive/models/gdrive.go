@@ -29,6 +29,9 @@ type GdriveFileToDl struct {
 	MimeType    string
 	Md5Checksum string
 	FilePath    string
+
+	// ExportFormat is the format to export Google-native files to, e.g. "pdf"
+	ExportFormat string
 }
 
 type GdriveError struct {
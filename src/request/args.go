@@ -41,6 +41,15 @@ type RequestArgs struct {
 
 	// RequestHandler is the main function that will be called to make the request.
 	RequestHandler RequestHandler
+
+	// OnRetry is called before the request is retried, e.g. to show the retry on the progress.
+	OnRetry func()
+
+	// creator of the file being downloaded to skip it if the creator has too many failures
+	creator string
+
+	// tail of the batch of the file being downloaded to split it into chunks at the end of the batch
+	tail *dlTail
 }
 
 var (
@@ -0,0 +1,396 @@
+package request
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+const (
+	BUILTIN_DOWNLOADER = "builtin"
+	ARIA2C_DOWNLOADER  = "aria2c"
+)
+
+var (
+	// aria2cPath is the path to the aria2c executable, empty to use the built-in download engine
+	aria2cPath string
+
+	// errAria2cFailed is returned when aria2c failed to download the file
+	// so that the download can be retried with the built-in download engine.
+	errAria2cFailed = errors.New("aria2c failed to download the file")
+
+	// aria2cWarnedReasons stores the reasons that aria2c could not be used for
+	// so that each warning is only printed once per run.
+	aria2cWarnedReasons sync.Map
+
+	// Matches the completed size in aria2c's progress readout, e.g. "[#2089b0 400.0KiB/33.2MiB(1%) CN:1 DL:115.7KiB]"
+	aria2cProgressRegex = regexp.MustCompile(`\[#\w+\s+(?P<size>[\d.]+)(?P<unit>[KMGT]i)?B`)
+	aria2cSizeIndex     = aria2cProgressRegex.SubexpIndex("size")
+	aria2cUnitIndex     = aria2cProgressRegex.SubexpIndex("unit")
+	aria2cUnitMultiples = map[string]float64{
+		"":   1,
+		"Ki": 1 << 10,
+		"Mi": 1 << 20,
+		"Gi": 1 << 30,
+		"Ti": 1 << 40,
+	}
+)
+
+// SetDownloader sets the engine used to download the files, either "builtin" or "aria2c".
+//
+// The enumeration of the posts and their metadata will still be handled by this program while aria2c
+// only downloads the files. If aria2c is not installed or the files are not saved to the local filesystem,
+// the built-in download engine will be used instead.
+func SetDownloader(downloader string) error {
+	switch strings.ToLower(downloader) {
+	case "", BUILTIN_DOWNLOADER:
+		aria2cPath = ""
+		return nil
+	case ARIA2C_DOWNLOADER:
+		if _, ok := dlStorage.(*localStorage); !ok {
+			color.Yellow("aria2c can only download to the local filesystem, the built-in download engine will be used instead.")
+			aria2cPath = ""
+			return nil
+		}
+
+		execPath, err := exec.LookPath(ARIA2C_DOWNLOADER)
+		if err != nil {
+			color.Yellow("aria2c was not found in your PATH, the built-in download engine will be used instead.")
+			aria2cPath = ""
+			return nil
+		}
+		aria2cPath = execPath
+		return nil
+	default:
+		return fmt.Errorf(
+			"error %d: invalid downloader %q, expected %q or %q",
+			utils.INPUT_ERROR,
+			downloader,
+			BUILTIN_DOWNLOADER,
+			ARIA2C_DOWNLOADER,
+		)
+	}
+}
+
+// Returns the reason why aria2c cannot download the URL with the same network configurations
+// as the built-in download engine, e.g. without the proxy which would leak the user's IP address,
+// or an empty string if aria2c can be used.
+func getAria2cUnsupportedReason(reqUrl string) string {
+	if len(tlsPins) > 0 {
+		return "aria2c does not support the \"--verify_tls_pin\" flag"
+	}
+	if ipNetwork == "tcp6" {
+		return "aria2c does not support the \"--force_ipv6\" flag"
+	}
+	if dnsServerAddr != "" {
+		if _, port, _ := net.SplitHostPort(dnsServerAddr); port != "53" {
+			return "aria2c only supports DNS servers on port 53 for the \"--dns_server\" flag"
+		}
+	}
+	if proxyUrl := getProxy(reqUrl); proxyUrl != nil && proxyUrl.Scheme != "http" {
+		return fmt.Sprintf("aria2c does not support %s proxies", proxyUrl.Scheme)
+	}
+	return ""
+}
+
+// Writes the URL and its options to a temporary aria2c input file for the "--input-file" option
+// so that the headers, e.g. Authorization, and the proxy credentials are not exposed in the command line
+// arguments which can be read by the other users on the system.
+//
+// Note: os.CreateTemp creates the file with 0600 permissions so that only the user can read it.
+func writeAria2cInputFile(reqUrl string, options []string) (string, error) {
+	var content strings.Builder
+	content.WriteString(reqUrl + "\n")
+	for _, option := range options {
+		if strings.ContainsAny(option, "\r\n") {
+			return "", fmt.Errorf("aria2c option %q contains a line break", strings.SplitN(option, "=", 2)[0])
+		}
+		content.WriteString(" " + option + "\n")
+	}
+
+	inputFile, err := os.CreateTemp("", "cultured-downloader-aria2c-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer inputFile.Close()
+	if _, err := inputFile.WriteString(content.String()); err != nil {
+		os.Remove(inputFile.Name())
+		return "", err
+	}
+	return inputFile.Name(), nil
+}
+
+// Writes the cookies to a temporary Netscape cookie file for aria2c's "--load-cookies" option
+// so that aria2c will only send the cookies to their domains, e.g. not to the CDN after a redirect.
+func writeAria2cCookieFile(reqUrl string, cookies []*http.Cookie) (string, error) {
+	parsedUrl, err := url.Parse(reqUrl)
+	if err != nil {
+		return "", err
+	}
+
+	cookieFile, err := os.CreateTemp("", "cultured-downloader-cookies-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer cookieFile.Close()
+	cookieFileContent := utils.FormatNetscapeCookies(expandPlatformCookies(cookies), parsedUrl.Hostname())
+	if _, err := cookieFile.WriteString(cookieFileContent); err != nil {
+		os.Remove(cookieFile.Name())
+		return "", err
+	}
+	return cookieFile.Name(), nil
+}
+
+// Returns the completed size in bytes from a line of aria2c's progress readout
+func parseAria2cProgress(line string) (int64, bool) {
+	matched := aria2cProgressRegex.FindStringSubmatch(line)
+	if matched == nil {
+		return 0, false
+	}
+
+	size, err := strconv.ParseFloat(matched[aria2cSizeIndex], 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(size * aria2cUnitMultiples[matched[aria2cUnitIndex]]), true
+}
+
+// Splits aria2c's output on both carriage returns and newlines as the progress readout may be redrawn in place
+func scanAria2cLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Runs aria2c to download the URL to the .part file of the file path and moves it to the file path on completion.
+//
+// The progress readout of aria2c is parsed to keep track of the downloaded bytes.
+func runAria2c(ctx context.Context, reqArgs *RequestArgs, filePath string) error {
+	// aria2c's requests are paused and spaced out like the requests sent by this program
+	aria2cReq, err := http.NewRequestWithContext(ctx, "GET", reqArgs.Url, nil)
+	if err != nil {
+		return err
+	}
+	aria2cReq.Header.Set("User-Agent", reqArgs.UserAgent)
+	if err := waitForHostCooldown(ctx, aria2cReq.URL.Host); err != nil {
+		return err
+	}
+	if err := waitForCrawlDelay(aria2cReq); err != nil {
+		return err
+	}
+
+	partFilePath := GetPartFilePath(filePath)
+	args := []string{
+		"--dir=" + filepath.Dir(partFilePath),
+		"--out=" + filepath.Base(partFilePath),
+		"--allow-overwrite=true",
+		"--auto-file-renaming=false",
+		"--file-allocation=none",
+		"--console-log-level=error",
+		"--download-result=hide",
+		"--summary-interval=1",
+		"--show-console-readout=true",
+		"--enable-color=false",
+		// aria2c's own retries would bypass the host's breaker and crawl delay
+		// so the failed download is retried by the built-in download engine instead
+		"--max-tries=1",
+		fmt.Sprintf("--connect-timeout=%d", utils.CONNECT_TIMEOUT),
+	}
+	if ipNetwork == "tcp4" {
+		args = append(args, "--disable-ipv6=true")
+	}
+	if dnsServerAddr != "" {
+		dnsHost, _, _ := net.SplitHostPort(dnsServerAddr)
+		args = append(args, "--async-dns=true", "--async-dns-server="+dnsHost)
+	}
+
+	inputOptions := []string{"user-agent=" + reqArgs.UserAgent}
+	headers := make(http.Header, len(reqArgs.Headers))
+	for key, value := range reqArgs.Headers {
+		headers.Set(key, value)
+	}
+	headerKeys := make([]string, 0, len(headers))
+	for key := range headers {
+		headerKeys = append(headerKeys, key)
+	}
+	sort.Strings(headerKeys)
+	for _, key := range headerKeys {
+		inputOptions = append(inputOptions, fmt.Sprintf("header=%s: %s", key, headers.Get(key)))
+	}
+	if proxyUrl := getProxy(reqArgs.Url); proxyUrl != nil {
+		inputOptions = append(inputOptions, "all-proxy=http://"+proxyUrl.Host)
+		if proxyUrl.User != nil {
+			proxyPassword, _ := proxyUrl.User.Password()
+			inputOptions = append(
+				inputOptions,
+				"all-proxy-user="+proxyUrl.User.Username(),
+				"all-proxy-passwd="+proxyPassword,
+			)
+		}
+	}
+	inputFilePath, err := writeAria2cInputFile(reqArgs.Url, inputOptions)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the input file for aria2c, more info => %v",
+			utils.OS_ERROR,
+			err,
+		)
+	}
+	defer os.Remove(inputFilePath)
+	args = append(args, "--input-file="+inputFilePath)
+
+	if len(reqArgs.Cookies) > 0 {
+		cookieFilePath, err := writeAria2cCookieFile(reqArgs.Url, reqArgs.Cookies)
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: failed to write the cookies for aria2c, more info => %v",
+				utils.OS_ERROR,
+				err,
+			)
+		}
+		defer os.Remove(cookieFilePath)
+		args = append(args, "--load-cookies="+cookieFilePath)
+	}
+
+	cmd := exec.CommandContext(ctx, aria2cPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var reported int64
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(scanAria2cLines)
+	for scanner.Scan() {
+		if completed, ok := parseAria2cProgress(scanner.Text()); ok && completed > reported {
+			addDownloadedBytes(completed - reported)
+			reported = completed
+		}
+	}
+
+	removePartFile := func() {
+		os.Remove(partFilePath)
+		os.Remove(partFilePath + ".aria2") // aria2c's control file
+	}
+	if err := cmd.Wait(); err != nil {
+		removePartFile()
+		if ctx.Err() != nil {
+			return context.Canceled
+		}
+		recordHostError(aria2cReq.URL.Host)
+		return fmt.Errorf("%v, more info => %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// the readout is only printed every second which will not include the last bytes
+	if fileSize, err := getLocalFileSize(partFilePath); err == nil && fileSize > reported {
+		addDownloadedBytes(fileSize - reported)
+	}
+	if err := dlStorage.Rename(partFilePath, filePath); err != nil {
+		removePartFile()
+		return err
+	}
+	return nil
+}
+
+// Downloads the file with aria2c based on the response of the HEAD request.
+//
+// errAria2cFailed is returned if aria2c failed or cannot be used with the user's network configurations
+// so that the caller can fall back to the built-in download engine.
+func downloadWithAria2c(ctx context.Context, headRes *http.Response, reqArgs *RequestArgs, filePath, filenamePrefix string, fileReqContentLength int64, overwriteExistingFile bool) error {
+	if reason := getAria2cUnsupportedReason(reqArgs.Url); reason != "" {
+		if _, warned := aria2cWarnedReasons.LoadOrStore(reason, true); !warned {
+			color.Yellow("%s, the built-in download engine will be used instead.", reason)
+		}
+		return errAria2cFailed
+	}
+
+	filePath, err := getFullFilePath(headRes, filePath)
+	if err != nil {
+		return err
+	}
+	filePath = addFilenamePrefix(filePath, filenamePrefix)
+
+	if checkIfCanSkipDl(fileReqContentLength, filePath, overwriteExistingFile) {
+		recordSynced(reqArgs.Url, filePath, headRes)
+		recordDlStat(reqArgs.Url, dlSkipped)
+		return errDlSkipped
+	}
+	if err := checkUnexpectedHtml(headRes, reqArgs.Url, filePath, false); err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	if err := runAria2c(ctx, reqArgs, filePath); err != nil {
+		if err == context.Canceled {
+			return err
+		}
+		utils.LogError(
+			err,
+			fmt.Sprintf(
+				"aria2c failed to download %s after %s, retrying with the built-in download engine",
+				reqArgs.Url,
+				time.Since(startTime).Round(time.Second),
+			),
+			false,
+			utils.ERROR,
+		)
+		return errAria2cFailed
+	}
+	if err := checkCommittedSize(reqArgs.Url, filePath, fileReqContentLength); err != nil {
+		utils.LogError(
+			err,
+			fmt.Sprintf("retrying %s with the built-in download engine", reqArgs.Url),
+			false,
+			utils.ERROR,
+		)
+		return errAria2cFailed
+	}
+	if err := verifyImage(filePath); err != nil {
+		utils.LogError(
+			err,
+			fmt.Sprintf("retrying %s with the built-in download engine", reqArgs.Url),
+			false,
+			utils.ERROR,
+		)
+		return errAria2cFailed
+	}
+	applyServerMtime(filePath, headRes)
+	if dedupFile(filePath) {
+		markSyncPlanned(reqArgs.Url, filePath)
+		recordDlStat(reqArgs.Url, dlSkipped)
+		return errDlDuplicate
+	}
+	recordSynced(reqArgs.Url, filePath, headRes)
+	recordDlStat(reqArgs.Url, dlDownloaded)
+	utils.RecordDownloadedFile(filePath)
+	return nil
+}
@@ -0,0 +1,68 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// basicAuth holds the HTTP Basic Auth credentials for an authenticated gateway.
+//
+// Note: the credentials should never be logged.
+type basicAuth struct {
+	username string
+	password string
+	host     string
+}
+
+var gatewayAuth *basicAuth
+
+// SetBasicAuth sets the HTTP Basic Auth credentials which will
+// only be sent to the given host, e.g. "gateway.example.com".
+func SetBasicAuth(username, password, host string) error {
+	if username == "" && password == "" {
+		gatewayAuth = nil
+		return nil
+	}
+
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return fmt.Errorf(
+			"error %d: the \"--http_auth_host\" flag is required when using HTTP Basic Auth",
+			utils.INPUT_ERROR,
+		)
+	}
+	gatewayAuth = &basicAuth{
+		username: username,
+		password: password,
+		host:     host,
+	}
+	return nil
+}
+
+// Checks if the credentials can be sent to the request's host
+func (a *basicAuth) matches(req *http.Request) bool {
+	return a != nil && strings.ToLower(req.URL.Hostname()) == a.host
+}
+
+// addBasicAuth adds the Basic Auth credentials to the
+// request only if the request is to the configured host.
+func addBasicAuth(req *http.Request) {
+	if gatewayAuth.matches(req) {
+		req.SetBasicAuth(gatewayAuth.username, gatewayAuth.password)
+	}
+}
+
+// checkAuthRedirect removes the Authorization header if the
+// redirected request is not to the configured host so that the credentials won't be leaked.
+func checkAuthRedirect(req *http.Request, via []*http.Request) error {
+	if err := checkRedirect(req, via); err != nil {
+		return err
+	}
+	if !gatewayAuth.matches(req) {
+		req.Header.Del("Authorization")
+	}
+	return nil
+}
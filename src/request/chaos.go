@@ -0,0 +1,69 @@
+package request
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Debug chaos mode is used for reproducing timeout, retry, and
+// resume related bugs without needing an actual flaky network.
+//
+// It is only enabled via the hidden --debug_chaos flag.
+var debugChaos bool
+
+const (
+	// chance in percentage of a connection reset per read
+	CHAOS_RESET_CHANCE = 1
+
+	// chance in percentage of a latency spike per read
+	CHAOS_LATENCY_CHANCE = 5
+
+	// max latency in milliseconds to inject per read or request
+	CHAOS_MAX_LATENCY = 2000
+)
+
+var errChaosReset = errors.New("debug chaos: connection reset by peer (simulated)")
+
+// EnableDebugChaos enables the injection of latency and
+// connection resets into the download path of the program.
+func EnableDebugChaos() {
+	debugChaos = true
+}
+
+// injectChaosLatency sleeps for a random duration if debug chaos is enabled
+func injectChaosLatency() {
+	if !debugChaos {
+		return
+	}
+	clock.Sleep(time.Duration(rand.Intn(CHAOS_MAX_LATENCY)) * time.Millisecond)
+}
+
+type chaosReader struct {
+	body io.ReadCloser
+}
+
+func (r *chaosReader) Read(p []byte) (int, error) {
+	roll := rand.Intn(100)
+	if roll < CHAOS_RESET_CHANCE {
+		return 0, errChaosReset
+	} else if roll < CHAOS_RESET_CHANCE+CHAOS_LATENCY_CHANCE {
+		injectChaosLatency()
+	}
+	return r.body.Read(p)
+}
+
+func (r *chaosReader) Close() error {
+	return r.body.Close()
+}
+
+// wrapChaosBody wraps the response body with a reader that
+// randomly stalls or resets the connection if debug chaos is enabled.
+func wrapChaosBody(res *http.Response) {
+	if !debugChaos || res == nil || res.Body == nil {
+		return
+	}
+	res.Body = &chaosReader{body: res.Body}
+}
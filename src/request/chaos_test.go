@@ -0,0 +1,23 @@
+package request
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChaosLatencyUsesClock(t *testing.T) {
+	c := useFakeClock(t)
+	debugChaos = true
+	t.Cleanup(func() { debugChaos = false })
+
+	start := time.Now()
+	for i := 0; i < 50; i++ {
+		injectChaosLatency()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the chaos latency slept for %v instead of going through the clock", elapsed)
+	}
+	if c.Slept() == 0 {
+		t.Error("the chaos latency was not injected via the clock")
+	}
+}
@@ -0,0 +1,85 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// circuitBreaker aborts the run after too many consecutive failed
+// requests across all goroutines, e.g. due to an expired session cookie,
+// instead of grinding through the retries of every remaining request.
+//
+// A request only counts as failed once its retries have run out.
+type circuitBreaker struct {
+	mu                  sync.Mutex
+	maxFailures         int
+	consecutiveFailures int
+	tripped             bool // whether the program is already aborting
+}
+
+var reqBreaker = &circuitBreaker{
+	maxFailures: utils.DEFAULT_MAX_CONSECUTIVE_FAILURES,
+}
+
+// SetMaxConsecutiveFailures sets the number of consecutive failed requests
+// across the run before the program aborts. Set it to 0 to disable the check.
+func SetMaxConsecutiveFailures(maxFailures int) error {
+	if maxFailures < 0 {
+		return fmt.Errorf(
+			"error %d: max consecutive failures cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			maxFailures,
+		)
+	}
+
+	reqBreaker.mu.Lock()
+	defer reqBreaker.mu.Unlock()
+	reqBreaker.maxFailures = maxFailures
+	return nil
+}
+
+// Returns true for the status codes of the files that do not exist (anymore)
+// which do not count towards the consecutive failures.
+func isMissingStatus(statusCode int) bool {
+	return statusCode == http.StatusNotFound || statusCode == http.StatusGone
+}
+
+func (cb *circuitBreaker) recordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.consecutiveFailures = 0
+}
+
+// Records a request that failed after all of its retries and aborts the program if the threshold has been reached.
+//
+// The program exits via the shutdown hooks so that the state of the run is flushed to disk first.
+func (cb *circuitBreaker) recordFailure(reqUrl, reason string) {
+	cb.mu.Lock()
+	cb.consecutiveFailures++
+	failures := cb.consecutiveFailures
+	if cb.maxFailures == 0 || failures < cb.maxFailures || cb.tripped {
+		cb.mu.Unlock()
+		return
+	}
+	cb.tripped = true
+	cb.mu.Unlock()
+
+	errMsg := fmt.Sprintf(
+		"error %d: aborting as the last %d requests have failed consecutively, last failed request to %s due to %s.\n"+
+			"This is usually caused by an expired session cookie, being rate limited, or a network issue.\n"+
+			"Please check and try again later or increase the \"--max_consecutive_failures\" threshold.",
+		utils.CONNECTION_ERROR,
+		failures,
+		reqUrl,
+		reason,
+	)
+	utils.LogErrorFields(nil, errMsg, false, utils.ERROR, utils.LogFields{Code: utils.CONNECTION_ERROR, Url: reqUrl})
+	if !utils.JsonLogsEnabled() {
+		color.Red(errMsg)
+	}
+	utils.ExitGracefully(1)
+}
@@ -0,0 +1,63 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+func TestCircuitBreakerCountsRequestsNotRetries(t *testing.T) {
+	useFakeClock(t)
+	tests := []struct {
+		name         string
+		status       int
+		wantFailures int
+	}{
+		{"retried server error", http.StatusServiceUnavailable, 1},
+		{"missing file", http.StatusNotFound, 0},
+		{"deleted file", http.StatusGone, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the breaker would trip if each of the retries counted as a failure
+			reqBreaker = &circuitBreaker{maxFailures: utils.RETRY_COUNTER}
+			t.Cleanup(func() {
+				reqBreaker = &circuitBreaker{maxFailures: utils.DEFAULT_MAX_CONSECUTIVE_FAILURES}
+			})
+
+			var attempts atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(test.status)
+			}))
+			defer srv.Close()
+
+			_, err := CallRequest(&RequestArgs{
+				Url:         srv.URL,
+				Method:      "GET",
+				Timeout:     10,
+				Http2:       true,
+				CheckStatus: true,
+			})
+			if err == nil {
+				t.Fatal("CallRequest() succeeded, want an error")
+			}
+			if got := attempts.Load(); got != utils.RETRY_COUNTER {
+				t.Errorf("sent %d attempts, want %d", got, utils.RETRY_COUNTER)
+			}
+
+			reqBreaker.mu.Lock()
+			defer reqBreaker.mu.Unlock()
+			if reqBreaker.consecutiveFailures != test.wantFailures {
+				t.Errorf("consecutive failures = %d, want %d", reqBreaker.consecutiveFailures, test.wantFailures)
+			}
+			if reqBreaker.tripped {
+				t.Error("the retries of a single request tripped the breaker")
+			}
+		})
+	}
+}
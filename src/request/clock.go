@@ -0,0 +1,45 @@
+package request
+
+import (
+	"time"
+)
+
+// Clock is the source of the current time and the delays used by the
+// retry, throttling, and waiting logic of the request package.
+//
+// A fake clock can be set via SetClock so that the logic can be tested without any real waits.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// Sleep blocks for the given duration
+	Sleep(d time.Duration)
+
+	// After returns a channel that receives the current time after the given duration
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the default Clock that uses the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+var clock Clock = realClock{}
+
+// SetClock sets the clock used by the request package, nil to restore the real clock
+func SetClock(c Clock) {
+	if c == nil {
+		c = realClock{}
+	}
+	clock = c
+}
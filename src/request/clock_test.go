@@ -0,0 +1,56 @@
+package request
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves forward when it is slept on or waited for
+// so that the delays are skipped while still being recorded.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept time.Duration // total duration slept or waited for
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d > 0 {
+		c.now = c.now.Add(d)
+		c.slept += d
+	}
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.Sleep(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func (c *fakeClock) Slept() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.slept
+}
+
+// Sets a new fake clock for the test and restores the real clock afterwards
+func useFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	c := newFakeClock()
+	SetClock(c)
+	t.Cleanup(func() { SetClock(nil) })
+	return c
+}
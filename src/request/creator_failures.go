@@ -0,0 +1,131 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// errCreatorSkipped is returned internally when the file's creator has been skipped due to too many failures
+var errCreatorSkipped = errors.New("download skipped as the creator has too many failures")
+
+// SkippedCreator is a creator whose remaining requests and downloads
+// were skipped after reaching the max number of failures for a creator.
+type SkippedCreator struct {
+	Creator  string `json:"creator"`
+	Failures int    `json:"failures"`
+	Reason   string `json:"reason"`
+}
+
+// creatorFailures skips the rest of a creator, e.g. a deleted or private creator,
+// after too many failures while continuing with the other creators of the run.
+type creatorFailures struct {
+	mu          sync.Mutex
+	maxFailures int
+	failures    map[string]int
+	skipped     map[string]*SkippedCreator
+}
+
+var creatorFails = &creatorFailures{
+	failures: make(map[string]int),
+	skipped:  make(map[string]*SkippedCreator),
+}
+
+// SetMaxCreatorFailures sets the number of failed requests or downloads of a creator
+// before the rest of the creator is skipped. Set it to 0 to disable the check.
+func SetMaxCreatorFailures(maxFailures int) error {
+	if maxFailures < 0 {
+		return fmt.Errorf(
+			"error %d: max creator failures cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			maxFailures,
+		)
+	}
+
+	creatorFails.mu.Lock()
+	defer creatorFails.mu.Unlock()
+	creatorFails.maxFailures = maxFailures
+	return nil
+}
+
+// SetCreator sets the creator of the files to download so that
+// the files can be skipped if the creator has too many failures.
+//
+// The creator should be prefixed with the platform, e.g. "fanbox/creatorId".
+func SetCreator(urlsToDownload []*ToDownload, creator string) {
+	for _, toDownload := range urlsToDownload {
+		toDownload.Creator = creator
+	}
+}
+
+// IsCreatorSkipped checks if the rest of the creator should be skipped due to too many failures
+func IsCreatorSkipped(creator string) bool {
+	if creator == "" {
+		return false
+	}
+
+	creatorFails.mu.Lock()
+	defer creatorFails.mu.Unlock()
+	_, skipped := creatorFails.skipped[creator]
+	return skipped
+}
+
+// RecordCreatorFailure records a failed request or download of the creator
+// and logs the reason once the creator has reached the max number of failures.
+func RecordCreatorFailure(creator string, err error) {
+	if creator == "" || err == nil {
+		return
+	}
+
+	creatorFails.mu.Lock()
+	defer creatorFails.mu.Unlock()
+	if creatorFails.maxFailures == 0 {
+		return
+	}
+	if skippedCreator, ok := creatorFails.skipped[creator]; ok {
+		skippedCreator.Failures++
+		return
+	}
+
+	creatorFails.failures[creator]++
+	failures := creatorFails.failures[creator]
+	if failures < creatorFails.maxFailures {
+		return
+	}
+
+	creatorFails.skipped[creator] = &SkippedCreator{
+		Creator:  creator,
+		Failures: failures,
+		Reason:   err.Error(),
+	}
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"skipping the rest of %s as it has failed %d times, last failed due to %v\n"+
+				"This is usually caused by the creator being deleted or private.",
+			creator,
+			failures,
+			err,
+		),
+		false,
+		utils.ERROR,
+	)
+}
+
+// GetSkippedCreators returns the creators that were skipped due to too many failures sorted by the creator
+func GetSkippedCreators() []*SkippedCreator {
+	creatorFails.mu.Lock()
+	defer creatorFails.mu.Unlock()
+
+	skippedCreators := make([]*SkippedCreator, 0, len(creatorFails.skipped))
+	for _, skippedCreator := range creatorFails.skipped {
+		skippedCreators = append(skippedCreators, skippedCreator)
+	}
+	sort.Slice(skippedCreators, func(i, j int) bool {
+		return skippedCreators[i].Creator < skippedCreators[j].Creator
+	})
+	return skippedCreators
+}
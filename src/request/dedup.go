@@ -0,0 +1,366 @@
+package request
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+const (
+	// Replace the duplicate with a hard link to the first file with the same content
+	DEDUP_HARDLINK = "hardlink"
+
+	// Replace the duplicate with a symbolic link to the first file with the same content
+	DEDUP_SYMLINK = "symlink"
+
+	// Keep the duplicate as a separate copy of the file
+	DEDUP_COPY = "copy"
+
+	// Remove the duplicate and only keep the first file with the same content
+	DEDUP_SKIP = "skip"
+)
+
+var ACCEPTED_DEDUP_ACTIONS = []string{
+	DEDUP_HARDLINK,
+	DEDUP_SYMLINK,
+	DEDUP_COPY,
+	DEDUP_SKIP,
+}
+
+// Windows system error codes for when a link could not be created
+const (
+	winErrorNotSameDevice    = 17
+	winErrorPrivilegeNotHeld = 1314
+)
+
+// errDlDuplicate is returned internally when the downloaded file was removed
+// as a duplicate or was not downloaded as it is a known duplicate of an existing file
+var errDlDuplicate = errors.New("download skipped as the file is a duplicate")
+
+// dedupIndex is the content-hash index of the downloaded files kept across runs
+type dedupIndex struct {
+	// Hashes maps the SHA-256 hash of the downloaded files to the first file path with that content
+	Hashes map[string]string `json:"hashes"`
+
+	// Duplicates maps the file paths removed by the skip action to the file path with the same content
+	Duplicates map[string]string `json:"duplicates"`
+}
+
+var (
+	// dedupAction is how the downloaded files with the same content as
+	// an earlier downloaded file are handled, an empty string disables it.
+	dedupAction string
+
+	dedupMu      sync.Mutex
+	dedupFiles   = newDedupIndex()
+	dedupChanged bool
+
+	// Whether the user has been warned that the links will be copies instead
+	dedupCopyWarned atomic.Bool
+)
+
+func newDedupIndex() *dedupIndex {
+	return &dedupIndex{
+		Hashes:     make(map[string]string),
+		Duplicates: make(map[string]string),
+	}
+}
+
+// Returns the path of the dedup index file in the app's config directory
+func getDedupIndexPath() string {
+	return filepath.Join(utils.APP_PATH, "dedup_index.json")
+}
+
+// SetDedup sets how the downloaded files with the same content as an earlier downloaded file are handled
+// and loads the dedup index of the previous runs from the app's config directory.
+//
+// The files are compared by their SHA-256 hash after they have been downloaded,
+// where an empty action disables the hashing of the files.
+func SetDedup(action string) error {
+	action = strings.ToLower(action)
+	if action != "" && !utils.SliceContains(ACCEPTED_DEDUP_ACTIONS, action) {
+		return fmt.Errorf(
+			"error %d: invalid dedup action %q, expected one of %s",
+			utils.INPUT_ERROR,
+			action,
+			strings.Join(ACCEPTED_DEDUP_ACTIONS, ", "),
+		)
+	}
+
+	index := newDedupIndex()
+	if action != "" {
+		indexPath := getDedupIndexPath()
+		data, err := os.ReadFile(indexPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf(
+				"error %d: failed to read the dedup index at %s, more info => %v",
+				utils.OS_ERROR,
+				indexPath,
+				err,
+			)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, index); err != nil {
+				utils.LogError(
+					err,
+					fmt.Sprintf("the dedup index at %s is corrupted and will be reset", indexPath),
+					false,
+					utils.ERROR,
+				)
+				index = newDedupIndex()
+			}
+			if index.Hashes == nil {
+				index.Hashes = make(map[string]string)
+			}
+			if index.Duplicates == nil {
+				index.Duplicates = make(map[string]string)
+			}
+		}
+	}
+
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	dedupAction = action
+	dedupFiles = index
+	dedupChanged = false
+	dedupCopyWarned.Store(false)
+	return nil
+}
+
+// SaveDedupIndex writes the dedup index to the app's config directory if it has changed
+func SaveDedupIndex() error {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	if dedupAction == "" || !dedupChanged {
+		return nil
+	}
+
+	data, err := json.Marshal(dedupFiles)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal the dedup index, more info => %v",
+			utils.JSON_ERROR,
+			err,
+		)
+	}
+
+	os.MkdirAll(utils.APP_PATH, 0700)
+	indexPath := getDedupIndexPath()
+	if err := os.WriteFile(indexPath, data, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the dedup index to %s, more info => %v",
+			utils.OS_ERROR,
+			indexPath,
+			err,
+		)
+	}
+	dedupChanged = false
+	return nil
+}
+
+// Checks if the file was removed as a duplicate by a previous download
+// and the file with the same content still exists so that it is not downloaded again.
+func isRemovedDuplicate(filePath string) bool {
+	if dedupAction != DEDUP_SKIP {
+		return false
+	}
+
+	dedupMu.Lock()
+	original, ok := dedupFiles.Duplicates[filePath]
+	dedupMu.Unlock()
+	return ok && !dlStorage.Exists(filePath) && dlStorage.Exists(original)
+}
+
+// Checks if the error was due to the hard link crossing devices (EXDEV)
+func isCrossDeviceErr(err error) bool {
+	if errors.Is(err, syscall.EXDEV) {
+		return true
+	}
+
+	var errno syscall.Errno
+	if runtime.GOOS == "windows" && errors.As(err, &errno) {
+		return errno == winErrorNotSameDevice
+	}
+	return false
+}
+
+// Checks if the error was due to Windows only allowing symbolic links to be
+// created with the administrator privileges or in developer mode
+func isSymlinkPrivilegeErr(err error) bool {
+	var errno syscall.Errno
+	if runtime.GOOS == "windows" && errors.As(err, &errno) {
+		return errno == winErrorPrivilegeNotHeld
+	}
+	return false
+}
+
+// Returns the hex-encoded SHA-256 hash of the content of the file
+func hashFile(filePath string) (string, error) {
+	file, err := dlStorage.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Returns the first downloaded file path with the same content as the file
+// or an empty string if it is the first one, in which case it is added to the index.
+func getDuplicateOf(filePath, hash string) string {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	original, ok := dedupFiles.Hashes[hash]
+	if ok && original != filePath && dlStorage.Exists(original) {
+		return original
+	}
+	dedupFiles.Hashes[hash] = filePath
+	delete(dedupFiles.Duplicates, filePath)
+	dedupChanged = true
+	return ""
+}
+
+// Records the file removed by the skip action as a duplicate of the original
+func recordRemovedDuplicate(filePath, original string) {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	dedupFiles.Duplicates[filePath] = original
+	dedupChanged = true
+}
+
+// Warns once that the duplicates are kept as copies as the links could not be created
+func warnDedupCopy(reason string) {
+	if dedupCopyWarned.CompareAndSwap(false, true) {
+		color.Yellow("Warning: %s, the duplicate files will be kept as copies instead.", reason)
+	}
+}
+
+// Replaces the duplicate with a symbolic link to the original or keeps it as a copy if the link could not be created
+func symlinkDuplicate(linkStorage LinkStorage, original, filePath string) {
+	err := linkStorage.Symlink(original, filePath)
+	if err == nil {
+		return
+	}
+
+	if isSymlinkPrivilegeErr(err) {
+		warnDedupCopy("creating symbolic links requires the administrator privileges or the developer mode on Windows")
+		return
+	}
+	utils.LogError(
+		fmt.Errorf(
+			"error %d: failed to link %s to %s, keeping it as a copy, more info => %v",
+			utils.OS_ERROR,
+			filePath,
+			original,
+			err,
+		),
+		"",
+		false,
+		utils.ERROR,
+	)
+}
+
+// Handles the downloaded file based on the dedup action if an earlier downloaded
+// file has the same content and returns true if it was removed.
+func dedupFile(filePath string) bool {
+	if dedupAction == "" {
+		return false
+	}
+
+	hash, err := hashFile(filePath)
+	if err != nil {
+		utils.LogError(
+			fmt.Errorf(
+				"error %d: failed to hash %s for the dedup, more info => %v",
+				utils.OS_ERROR,
+				filePath,
+				err,
+			),
+			"",
+			false,
+			utils.ERROR,
+		)
+		return false
+	}
+	original := getDuplicateOf(filePath, hash)
+	if original == "" {
+		return false
+	}
+
+	switch dedupAction {
+	case DEDUP_SKIP:
+		if err := dlStorage.Remove(filePath); err != nil {
+			utils.LogError(
+				fmt.Errorf(
+					"error %d: failed to remove %s which is a duplicate of %s, more info => %v",
+					utils.OS_ERROR,
+					filePath,
+					original,
+					err,
+				),
+				"",
+				false,
+				utils.ERROR,
+			)
+			return false
+		}
+		recordRemovedDuplicate(filePath, original)
+		utils.LogError(
+			nil,
+			fmt.Sprintf("removed %s as it is a duplicate of %s", filePath, original),
+			false,
+			utils.INFO,
+		)
+		return true
+	case DEDUP_HARDLINK, DEDUP_SYMLINK:
+		linkStorage, ok := dlStorage.(LinkStorage)
+		if !ok {
+			warnDedupCopy("the storage backend does not support links")
+			return false
+		}
+		if dedupAction == DEDUP_SYMLINK {
+			symlinkDuplicate(linkStorage, original, filePath)
+			return false
+		}
+
+		err := linkStorage.Link(original, filePath)
+		if err == nil {
+			return false
+		}
+		if isCrossDeviceErr(err) {
+			// hard links cannot span across devices unlike symbolic links
+			symlinkDuplicate(linkStorage, original, filePath)
+			return false
+		}
+		utils.LogError(
+			fmt.Errorf(
+				"error %d: failed to hard link %s to %s, keeping it as a copy, more info => %v",
+				utils.OS_ERROR,
+				filePath,
+				original,
+				err,
+			),
+			"",
+			false,
+			utils.ERROR,
+		)
+	}
+	return false
+}
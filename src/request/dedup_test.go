@@ -0,0 +1,184 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Enables the dedup action with an index file in a temporary app directory for the test
+func useDedup(t *testing.T, action string) {
+	t.Helper()
+	appPath := utils.APP_PATH
+	utils.APP_PATH = t.TempDir()
+	t.Cleanup(func() {
+		utils.APP_PATH = appPath
+		SetDedup("")
+	})
+	if err := SetDedup(action); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDedupFile(t *testing.T) {
+	tests := []struct {
+		action      string
+		wantRemoved bool
+		wantMode    os.FileMode // type of the duplicate file after the dedup
+		wantSame    bool        // whether the duplicate is the same file as the original
+	}{
+		{action: DEDUP_HARDLINK, wantSame: true},
+		{action: DEDUP_SYMLINK, wantMode: os.ModeSymlink, wantSame: true},
+		{action: DEDUP_COPY},
+		{action: DEDUP_SKIP, wantRemoved: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.action, func(t *testing.T) {
+			useDedup(t, test.action)
+
+			dirPath := t.TempDir()
+			original := filepath.Join(dirPath, "post 1", "image.jpg")
+			duplicate := filepath.Join(dirPath, "post 2", "image.jpg")
+			unique := filepath.Join(dirPath, "post 2", "other.jpg")
+			for filePath, content := range map[string]string{original: "image", duplicate: "image", unique: "other"} {
+				if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filePath, []byte(content), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, filePath := range []string{original, unique} {
+				if dedupFile(filePath) {
+					t.Errorf("dedupFile(%q) removed the first file with its content", filePath)
+				}
+			}
+			if removed := dedupFile(duplicate); removed != test.wantRemoved {
+				t.Errorf("dedupFile() removed = %v, want %v", removed, test.wantRemoved)
+			}
+
+			if test.wantRemoved {
+				if _, err := os.Lstat(duplicate); !os.IsNotExist(err) {
+					t.Errorf("the duplicate %s was not removed", duplicate)
+				}
+				return
+			}
+			fileInfo, err := os.Lstat(duplicate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := fileInfo.Mode().Type(); mode != test.wantMode {
+				t.Errorf("duplicate file type = %v, want %v", mode, test.wantMode)
+			}
+			if content, err := os.ReadFile(duplicate); err != nil || string(content) != "image" {
+				t.Errorf("duplicate content = %q, %v, want %q", content, err, "image")
+			}
+
+			originalInfo, err := os.Stat(original)
+			if err != nil {
+				t.Fatal(err)
+			}
+			duplicateInfo, err := os.Stat(duplicate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if same := os.SameFile(originalInfo, duplicateInfo); same != test.wantSame {
+				t.Errorf("the duplicate is the same file as the original = %v, want %v", same, test.wantSame)
+			}
+			if _, err := os.Lstat(duplicate + ".link"); !os.IsNotExist(err) {
+				t.Errorf("the temporary link of the duplicate was left behind")
+			}
+		})
+	}
+}
+
+func TestDedupSkipIsKeptAcrossRuns(t *testing.T) {
+	useFakeClock(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("file content"))
+	}))
+	defer srv.Close()
+
+	dlDir := t.TempDir()
+	original := filepath.Join(dlDir, "post 1", "file.bin")
+	duplicate := filepath.Join(dlDir, "post 2", "file.bin")
+	download := func(reqUrl, filePath string) error {
+		return downloadUrl(filePath, "", make(chan struct{}, 1), &RequestArgs{
+			Url:            reqUrl,
+			Method:         "GET",
+			Timeout:        10,
+			Http2:          true,
+			RequestHandler: CallRequest,
+		}, false)
+	}
+
+	useDedup(t, DEDUP_SKIP)
+	if err := download(srv.URL+"/1/file.bin", original); err != nil {
+		t.Fatalf("downloading the original error = %v", err)
+	}
+	if err := download(srv.URL+"/2/file.bin", duplicate); err != errDlDuplicate {
+		t.Fatalf("downloading the duplicate error = %v, want %v", err, errDlDuplicate)
+	}
+	if _, err := os.Stat(duplicate); !os.IsNotExist(err) {
+		t.Fatalf("the duplicate %s was not removed", duplicate)
+	}
+	if err := SaveDedupIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next run loads the index and does not download the removed duplicate again
+	if err := SetDedup(DEDUP_SKIP); err != nil {
+		t.Fatal(err)
+	}
+	if err := download(srv.URL+"/2/file.bin", duplicate); err != errDlSkipped {
+		t.Errorf("downloading the removed duplicate error = %v, want %v", err, errDlSkipped)
+	}
+	if _, err := os.Stat(duplicate); !os.IsNotExist(err) {
+		t.Errorf("the removed duplicate %s was downloaded again", duplicate)
+	}
+	if _, err := os.Stat(original); err != nil {
+		t.Errorf("the original was not kept, more info => %v", err)
+	}
+}
+
+func TestDedupKeepsCopyWithoutLinkStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	SetStorageBackend(storage)
+	t.Cleanup(func() { SetStorageBackend(&localStorage{}) })
+	useDedup(t, DEDUP_HARDLINK)
+
+	for _, filePath := range []string{"a.jpg", "b.jpg"} {
+		if err := WriteFile(filePath, []byte("image")); err != nil {
+			t.Fatal(err)
+		}
+		if dedupFile(filePath) {
+			t.Errorf("dedupFile(%q) removed the file", filePath)
+		}
+	}
+	if content, err := storage.ReadFile("b.jpg"); err != nil || string(content) != "image" {
+		t.Errorf("duplicate content = %q, %v, want %q", content, err, "image")
+	}
+}
+
+func TestSetDedupRejectsUnknownAction(t *testing.T) {
+	if err := SetDedup("reflink"); err == nil {
+		t.Error("SetDedup(\"reflink\") error = nil, want an error")
+	}
+}
+
+func TestIsCrossDeviceErr(t *testing.T) {
+	if !isCrossDeviceErr(&os.LinkError{Op: "link", Err: syscall.EXDEV}) {
+		t.Error("isCrossDeviceErr() = false for EXDEV, want true")
+	}
+	if isCrossDeviceErr(&os.LinkError{Op: "link", Err: syscall.EPERM}) {
+		t.Error("isCrossDeviceErr() = true for EPERM, want false")
+	}
+}
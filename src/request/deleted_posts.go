@@ -0,0 +1,87 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const (
+	// Log and skip the files of the posts that were deleted between listing and downloading them
+	DELETED_POST_SKIP = "skip"
+
+	// Treat the files of the deleted posts as failed downloads
+	DELETED_POST_FAIL = "fail"
+)
+
+var ACCEPTED_DELETED_POST = []string{
+	DELETED_POST_SKIP,
+	DELETED_POST_FAIL,
+}
+
+// errPostDeleted is returned internally when the file's post was deleted or is no longer available
+var errPostDeleted = errors.New("post deleted or unavailable")
+
+// deletedPostPolicy is how the files of the posts that were deleted mid-run are handled
+var deletedPostPolicy = DELETED_POST_SKIP
+
+// SetDeletedPostPolicy sets how the files of the posts that were deleted between listing and downloading are handled
+func SetDeletedPostPolicy(policy string) error {
+	policy = strings.ToLower(policy)
+	if !utils.SliceContains(ACCEPTED_DELETED_POST, policy) {
+		return fmt.Errorf(
+			"error %d: invalid deleted post policy %q, expected one of %s",
+			utils.INPUT_ERROR,
+			policy,
+			strings.Join(ACCEPTED_DELETED_POST, ", "),
+		)
+	}
+	deletedPostPolicy = policy
+	return nil
+}
+
+// SetPostDeletedCheck sets the check of whether the post of the files has been deleted which is only called once
+// per post after one of its files could not be found, e.g. by querying the platform's API for the post.
+//
+// The check should rely on the platform's specific not-found response of the post and not just any 404 response.
+func SetPostDeletedCheck(postFiles []*ToDownload, post string, isDeleted func() bool) {
+	var once sync.Once
+	var deleted bool
+	check := func() bool {
+		once.Do(func() {
+			deleted = isDeleted()
+			if deleted {
+				utils.LogError(
+					nil,
+					fmt.Sprintf("%s was deleted or is unavailable, skipping its remaining files", post),
+					false,
+					utils.INFO,
+				)
+			}
+		})
+		return deleted
+	}
+	for _, postFile := range postFiles {
+		postFile.IsPostDeleted = check
+	}
+}
+
+// Checks if the file could not be downloaded due to its post being deleted after it was listed
+func isPostDeletedErr(urlInfo *ToDownload, err error) bool {
+	if deletedPostPolicy != DELETED_POST_SKIP || urlInfo.IsPostDeleted == nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	if statusErr.StatusCode != http.StatusNotFound && statusErr.StatusCode != http.StatusGone {
+		return false
+	}
+	return urlInfo.IsPostDeleted()
+}
@@ -0,0 +1,71 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Windows system error codes for when the disk is full
+const (
+	winErrorHandleDiskFull = 39
+	winErrorDiskFull       = 112
+)
+
+// DiskFullError is returned when a download could not be
+// written as there is no space left on the device.
+//
+// Unlike other download errors, this error will abort the entire run
+// as all subsequent downloads would fail to be written as well.
+type DiskFullError struct {
+	FilePath string
+	Err      error
+}
+
+func (e *DiskFullError) Error() string {
+	return fmt.Sprintf(
+		"download error %d: no space left on the device while writing to %s, "+
+			"please free up some space before trying again, more info => %v",
+		utils.OS_ERROR,
+		e.FilePath,
+		e.Err,
+	)
+}
+
+func (e *DiskFullError) Unwrap() error {
+	return e.Err
+}
+
+// diskFullErr is set when a download runs out of disk space so that no new downloads will be started.
+var diskFullErr atomic.Pointer[DiskFullError]
+
+// Checks if the error was due to the disk being full (ENOSPC)
+func isDiskFullErr(err error) bool {
+	if errors.Is(err, syscall.ENOSPC) {
+		return true
+	}
+
+	var errno syscall.Errno
+	if runtime.GOOS == "windows" && errors.As(err, &errno) {
+		return errno == winErrorDiskFull || errno == winErrorHandleDiskFull
+	}
+	return false
+}
+
+// Returns a *DiskFullError if the error was due to the disk being full, otherwise the error is returned as is.
+func wrapDiskFullErr(err error, filePath string) error {
+	if err == nil || !isDiskFullErr(err) {
+		return err
+	}
+
+	dfErr := &DiskFullError{
+		FilePath: filePath,
+		Err:      err,
+	}
+	diskFullErr.CompareAndSwap(nil, dfErr)
+	return dfErr
+}
@@ -2,6 +2,8 @@ package request
 
 import (
 	"context"
+	"crypto/sha1"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,40 +11,118 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 )
 
+// maxStartJitter is the max delay between launching each download goroutine of
+// the first wave to avoid bursting the CDN with requests all at once.
+var maxStartJitter = time.Duration(utils.DEFAULT_START_JITTER) * time.Millisecond
+
+// SetMaxStartJitter sets the max delay in milliseconds between launching each
+// download goroutine of the first wave. Set it to 0 to disable the staggered start.
+func SetMaxStartJitter(ms int) error {
+	if ms < 0 {
+		return fmt.Errorf(
+			"error %d: start jitter cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			ms,
+		)
+	}
+	maxStartJitter = time.Duration(ms) * time.Millisecond
+	return nil
+}
+
+// maxWorkers overrides the max concurrent downloads of the platforms if set
+var maxWorkers int
+
+// SetMaxWorkers sets the max number of concurrent HTTP downloads for all platforms
+// which overrides each platform's default. Google Drive downloads are not affected.
+func SetMaxWorkers(workers int) error {
+	if workers < 1 {
+		return fmt.Errorf(
+			"error %d: number of workers must be at least 1, got %d",
+			utils.INPUT_ERROR,
+			workers,
+		)
+	}
+	maxWorkers = workers
+	return nil
+}
+
+// staggerStart sleeps for a random duration if the
+// goroutine at idx is part of the first download wave.
+func staggerStart(idx, maxConcurrency int) {
+	if idx == 0 || idx >= maxConcurrency || maxStartJitter <= 0 {
+		return
+	}
+	clock.Sleep(utils.GetRandomTime(0, maxStartJitter.Seconds()))
+}
+
+// rawFilenames is whether to only unescape the filenames derived from the URL once like before
+var rawFilenames bool
+
+// SetRawFilenames sets whether to skip the decoding of double-encoded
+// and HTML-escaped filenames and the use of the Content-Disposition header's filename.
+func SetRawFilenames(raw bool) {
+	rawFilenames = raw
+}
+
+// Returns the filename of the downloaded file based on the response's URL.
+//
+// The Content-Disposition header's filename will be used instead
+// if the filename from the URL does not have a file extension.
+func getFilenameFromRes(res *http.Response) (string, error) {
+	if rawFilenames {
+		filename, err := url.PathUnescape(res.Request.URL.String())
+		if err != nil {
+			// should never happen but just in case
+			return "", fmt.Errorf(
+				"error %d: failed to unescape URL, more info => %v\nurl: %s",
+				utils.UNEXPECTED_ERROR,
+				err,
+				res.Request.URL.String(),
+			)
+		}
+		return utils.GetLastPartOfUrl(filename), nil
+	}
+
+	filename := utils.DecodeFilename(
+		utils.GetLastPartOfUrl(res.Request.URL.EscapedPath()),
+	)
+	if filepath.Ext(filename) == "" {
+		if dispositionFilename := utils.GetContentDispositionFilename(res); dispositionFilename != "" {
+			return dispositionFilename, nil
+		}
+	}
+	return filename, nil
+}
+
 func getFullFilePath(res *http.Response, filePath string) (string, error) {
 	// check if filepath already have a filename attached
 	if filepath.Ext(filePath) != "" {
 		filePathDir := filepath.Dir(filePath)
-		os.MkdirAll(filePathDir, 0755)
-		filePathWithoutExt := utils.RemoveExtFromFilename(filePath)
-		return filePathWithoutExt + strings.ToLower(filepath.Ext(filePath)), nil
+		if err := dlStorage.MkdirAll(filePathDir); err != nil {
+			return "", err
+		}
+		return utils.NormaliseFileExt(filePath), nil
 	}
 
-	os.MkdirAll(filePath, 0755)
-	filename, err := url.PathUnescape(res.Request.URL.String())
+	if err := dlStorage.MkdirAll(filePath); err != nil {
+		return "", err
+	}
+	filename, err := getFilenameFromRes(res)
 	if err != nil {
-		// should never happen but just in case
-		return "", fmt.Errorf(
-			"error %d: failed to unescape URL, more info => %v\nurl: %s",
-			utils.UNEXPECTED_ERROR,
-			err,
-			res.Request.URL.String(),
-		)
+		return "", err
 	}
-	filename = utils.GetLastPartOfUrl(filename)
-	filenameWithoutExt := utils.RemoveExtFromFilename(filename)
 	filePath = filepath.Join(
 		filePath,
-		filenameWithoutExt + strings.ToLower(filepath.Ext(filename)),
+		utils.NormaliseFileExt(filename),
 	)
 	return filePath, nil
 }
@@ -50,7 +130,11 @@ func getFullFilePath(res *http.Response, filePath string) (string, error) {
 // check if the file size matches the content length
 // if not, then the file does not exist or is corrupted and should be re-downloaded
 func checkIfCanSkipDl(contentLength int64, filePath string, forceOverwrite bool) bool {
-	fileSize, err := utils.GetFileSize(filePath)
+	if isRemovedDuplicate(filePath) {
+		return true
+	}
+
+	fileSize, err := dlStorage.Size(filePath)
 	if err != nil {
 		if err != os.ErrNotExist {
 			// if the error wasn't because the file does not exist,
@@ -59,6 +143,10 @@ func checkIfCanSkipDl(contentLength int64, filePath string, forceOverwrite bool)
 		}
 		return false
 	}
+	if isOlderThanMaxAge(filePath) {
+		// the file is considered outdated and should be re-downloaded
+		return false
+	}
 
 	if fileSize == contentLength {
 		// If the file already exists and the file size
@@ -75,9 +163,67 @@ func checkIfCanSkipDl(contentLength int64, filePath string, forceOverwrite bool)
 	return false
 }
 
+// tempDir is the directory where the in-progress downloads (.part files) will be written to.
+//
+// If empty, the .part files will be written next to the final file path.
+var tempDir string
+
+// SetTempDir sets the directory where the in-progress downloads will be written to
+// before being moved to the download directory on completion.
+func SetTempDir(dirPath string) error {
+	if dirPath == "" {
+		tempDir = ""
+		return nil
+	}
+
+	if err := utils.MkdirAll(dirPath); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create temporary directory at %s, more info => %v",
+			utils.OS_ERROR,
+			dirPath,
+			err,
+		)
+	}
+	tempDir = dirPath
+	return nil
+}
+
+// GetPartFilePath returns the path of the in-progress download file for the given file path.
+//
+// The file path is hashed when using a temporary directory to
+// avoid name collisions between files from different posts.
+func GetPartFilePath(filePath string) string {
+	if tempDir == "" {
+		return filePath + ".part"
+	}
+
+	pathHash := sha1.Sum([]byte(filePath))
+	return filepath.Join(
+		tempDir,
+		fmt.Sprintf("%s.%x.part", filepath.Base(filePath), pathHash[:8]),
+	)
+}
+
 func DlToFile(res *http.Response, url, filePath string) error {
-	file, err := os.Create(filePath) // create the file
+	return dlToFile(res, url, filePath, 0)
+}
+
+// Same as DlToFile but appends the response body to the .part file of the file path if the resume offset is given
+func dlToFile(res *http.Response, url, filePath string, resumeOffset int64) error {
+	var file FileWriter
+	var err error
+	if resumeOffset > 0 {
+		file, err = appendToPartFile(filePath)
+	} else {
+		file, err = dlStorage.Create(filePath, res.ContentLength) // create the file
+		if err == nil {
+			savePartValidator(filePath, res)
+		}
+	}
 	if err != nil {
+		if isDiskFullErr(err) {
+			return wrapDiskFullErr(err, filePath)
+		}
 		return fmt.Errorf(
 			"error %d: failed to create file, more info => %v\nfile path: %s",
 			utils.OS_ERROR,
@@ -88,13 +234,16 @@ func DlToFile(res *http.Response, url, filePath string) error {
 
 	// write the body to file
 	// https://stackoverflow.com/a/11693049/16377492
-	_, err = io.Copy(file, res.Body)
+	written, err := io.Copy(file, res.Body)
+	addDownloadedBytes(written)
 	if err != nil {
-		file.Close()
-		if fileErr := os.Remove(filePath); fileErr != nil {
+		if keepPartFile(filePath) {
+			// keep the .part file to resume the download from where it stopped
+			closePartFile(file)
+		} else if fileErr := file.Abort(); fileErr != nil {
 			utils.LogError(
 				fmt.Errorf(
-					"download error %d: failed to remove file at %s, more info => %v",
+					"download error %d: failed to remove incomplete file of %s, more info => %v",
 					utils.OS_ERROR,
 					filePath,
 					fileErr,
@@ -105,21 +254,91 @@ func DlToFile(res *http.Response, url, filePath string) error {
 			)
 		}
 
-		if err != context.Canceled {
-			errorMsg := fmt.Sprintf("failed to download %s due to %v", url, err)
-			utils.LogError(err, errorMsg, false, utils.ERROR)
-			err = nil
+		if isDiskFullErr(err) {
+			return wrapDiskFullErr(err, filePath)
 		}
-		return err
+		if err == ErrDownloadStalled || err == context.Canceled {
+			return err
+		}
+		return fmt.Errorf(
+			"error %d: %w, more info => %v\nurl: %s",
+			utils.DOWNLOAD_ERROR,
+			errDlInterrupted,
+			err,
+			url,
+		)
 	}
-	file.Close()
-	return nil
+	if res.ContentLength >= 0 && written != res.ContentLength {
+		// only commit the file once the whole body has been received
+		if !keepPartFile(filePath) {
+			file.Abort()
+		} else {
+			closePartFile(file)
+		}
+		return fmt.Errorf(
+			"error %d: %w, received %d of %d bytes\nurl: %s",
+			utils.DOWNLOAD_ERROR,
+			errDlInterrupted,
+			written,
+			res.ContentLength,
+			url,
+		)
+	}
+	err = file.Commit()
+	if err == nil {
+		removePartValidator(filePath)
+	}
+	return wrapDiskFullErr(
+		err,
+		filePath,
+	)
 }
 
+// errDlSkipped is returned internally when the file already exists and the download has been skipped
+var errDlSkipped = errors.New("download skipped as the file already exists")
+
+// Checks if the committed file has the expected size before it is recorded as downloaded
+// and removes it otherwise so that it will be downloaded again. A negative expected size is unknown.
+func checkCommittedSize(reqUrl, filePath string, expectedSize int64) error {
+	if expectedSize < 0 {
+		return nil
+	}
+	fileSize, err := dlStorage.Size(filePath)
+	if err == nil && fileSize == expectedSize {
+		return nil
+	}
+	dlStorage.Remove(filePath)
+	return fmt.Errorf(
+		"error %d: %w, the downloaded file has %d of %d bytes\nurl: %s\nfile path: %s",
+		utils.DOWNLOAD_ERROR,
+		errDlInterrupted,
+		fileSize,
+		expectedSize,
+		reqUrl,
+		filePath,
+	)
+}
+
+// errDlInterrupted is returned when the connection was lost before the whole file was received
+// where the next attempt will resume the download from its .part file if possible
+var errDlInterrupted = errors.New("the download was interrupted before the whole file was received")
+
 // DownloadUrl is used to download a file from a URL
 //
 // Note: If the file already exists, the download process will be skipped
 func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, overwriteExistingFile bool) error {
+	err := downloadUrl(filePath, "", queue, reqArgs, overwriteExistingFile)
+	if err == errDlSkipped || err == errDlDuplicate {
+		return nil
+	}
+	return err
+}
+
+// Same as DownloadUrl but prefixes the downloaded filename with the given filename prefix
+//
+// errDlSkipped is returned if the file already exists and the download was skipped
+// while errDlDuplicate is returned if the file was removed as a duplicate, see dedupFile.
+func downloadUrl(filePath, filenamePrefix string, queue chan struct{}, reqArgs *RequestArgs, overwriteExistingFile bool) (err error) {
 	// Create a context that can be cancelled when SIGINT/SIGTERM signal is received
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -134,6 +353,35 @@ func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 	defer signal.Stop(sigs)
 
 	queue <- struct{}{}
+	if reqArgs.tail != nil {
+		reqArgs.tail.waiting.Add(-1)
+	}
+	activeWorkers.Add(1)
+	defer activeWorkers.Add(-1)
+	done, ok := trackInFlight()
+	if !ok {
+		return context.Canceled
+	}
+	defer done()
+	if dfErr := diskFullErr.Load(); dfErr != nil {
+		return dfErr
+	}
+	if maxTotalSizeReached() {
+		return ErrMaxTotalSizeReached
+	}
+	if IsCreatorSkipped(reqArgs.creator) {
+		return errCreatorSkipped
+	}
+	if err := dlPauser.wait(ctx); err != nil {
+		return err
+	}
+	if err := waitForFreeSpace(ctx, filePath); err != nil {
+		return err
+	}
+	if err := dlThrottler.wait(ctx, reqArgs.Url); err != nil {
+		return err
+	}
+
 	// Send a HEAD request first to get the expected file size from the Content-Length header.
 	// A GET request might work but most of the time
 	// as the Content-Length header may not present due to chunked encoding.
@@ -149,18 +397,144 @@ func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 			Http3:       reqArgs.Http3,
 			Http2:       reqArgs.Http2,
 			Context:     ctx,
+			OnRetry:     reqArgs.OnRetry,
 		},
 	)
 	if err != nil {
+		if err != context.Canceled {
+			dlThrottler.recordFailure(reqArgs.Url)
+		}
 		return err
 	}
 	fileReqContentLength := headRes.ContentLength
 	headRes.Body.Close()
 
-	reqArgs.Context = ctx
-	res, err := reqArgs.RequestHandler(reqArgs)
+	// the file path is resolved from the HEAD response to find its sync manifest entry
+	// and the .part file of an incomplete download
+	var resolvedPath string
+	if canResumePart() || manifest != nil {
+		if fullPath, err := getFullFilePath(headRes, filePath); err == nil {
+			resolvedPath = addFilenamePrefix(fullPath, filenamePrefix)
+			markSyncPlanned(reqArgs.Url, resolvedPath)
+		}
+	}
+	if unchanged, changed := checkSyncEntry(resolvedPath, headRes); unchanged {
+		recordDlStat(reqArgs.Url, dlSkipped)
+		return errDlSkipped
+	} else if changed {
+		overwriteExistingFile = true
+	}
+
+	var resumePath string
+	if canResumePart() {
+		resumePath = resolvedPath
+	}
+	defer func() {
+		// the .part file is only kept for a later run if resuming is enabled
+		// while a stalled download may still be requeued and resumed by this run
+		if err != nil && err != ErrDownloadStalled && resumePath != "" && !canResume() {
+			discardPartFile(resumePath)
+		}
+	}()
+
+	if aria2cPath != "" {
+		err = downloadWithAria2c(ctx, headRes, reqArgs, filePath, filenamePrefix, fileReqContentLength, overwriteExistingFile)
+		if err != errAria2cFailed {
+			return err
+		}
+	}
+
+	reqArgs.Timeout = getDownloadTimeout(fileReqContentLength)
+	for i := 1; i <= utils.RETRY_COUNTER; i++ {
+		// each attempt has its own context so that the stall detector
+		// can cancel the stuck request without affecting the retries
+		attemptCtx, cancelAttempt := context.WithCancel(withHarDownload(ctx))
+		reqArgs.Context = attemptCtx
+		err = downloadBody(reqArgs, filePath, filenamePrefix, resumePath, fileReqContentLength, overwriteExistingFile, cancelAttempt)
+		cancelAttempt()
+		if errors.Is(err, errCorruptImage) {
+			recordRetry()
+			if reqArgs.OnRetry != nil {
+				reqArgs.OnRetry()
+			}
+			utils.LogError(
+				nil,
+				fmt.Sprintf("downloaded image from %s is corrupted, re-downloading it (attempt %d/%d)", reqArgs.Url, i, utils.RETRY_COUNTER),
+				false,
+				utils.INFO,
+			)
+			continue
+		}
+		if errors.Is(err, errDlInterrupted) {
+			recordRetry()
+			if reqArgs.OnRetry != nil {
+				reqArgs.OnRetry()
+			}
+			utils.LogError(
+				nil,
+				fmt.Sprintf("download of %s was interrupted, retrying it (attempt %d/%d)", reqArgs.Url, i, utils.RETRY_COUNTER),
+				false,
+				utils.INFO,
+			)
+			clock.Sleep(utils.GetRandomDelay())
+			continue
+		}
+		if err != ErrDownloadStalled {
+			return err
+		}
+		recordRetry()
+		if reqArgs.OnRetry != nil {
+			reqArgs.OnRetry()
+		}
+		if postRetries > 0 {
+			// free up the worker's slot and let the caller requeue the file
+			return err
+		}
+
+		utils.LogError(
+			nil,
+			fmt.Sprintf("download of %s stalled (attempt %d/%d)", reqArgs.Url, i, utils.RETRY_COUNTER),
+			false,
+			utils.INFO,
+		)
+	}
+	return fmt.Errorf(
+		"error %d: failed to download file after %d retries, more info => %w\nurl: %s",
+		utils.DOWNLOAD_ERROR,
+		utils.RETRY_COUNTER,
+		err,
+		reqArgs.Url,
+	)
+}
+
+// Sends the GET request and writes the response body to the file.
+//
+// If the resume path has an incomplete download, the request will only ask for the rest of the file
+// as long as the file has not changed since and the rest will be appended to the .part file.
+func downloadBody(reqArgs *RequestArgs, filePath, filenamePrefix, resumePath string, fileReqContentLength int64, overwriteExistingFile bool, cancel context.CancelFunc) error {
+	injectChaosLatency()
+	resumeOffset, validator := getResumeInfo(resumePath)
+	if resumeOffset > 0 && fileReqContentLength >= 0 && resumeOffset >= fileReqContentLength {
+		// the .part file cannot be the start of the file if it is not smaller than the file
+		discardPartFile(resumePath)
+		resumeOffset = 0
+	}
+	dlReqArgs := reqArgs
+	if resumeOffset > 0 {
+		resumeReqArgs := *reqArgs
+		resumeReqArgs.Headers = addResumeHeaders(reqArgs.Headers, resumeOffset, validator)
+		dlReqArgs = &resumeReqArgs
+	}
+
+	res, err := reqArgs.RequestHandler(dlReqArgs)
 	if err != nil {
+		if resumeOffset > 0 && isRangeNotSatisfiable(err) {
+			// the .part file does not match the file, e.g. it is larger than the file, so restart the download
+			discardPartFile(resumePath)
+			return downloadBody(reqArgs, filePath, filenamePrefix, resumePath, fileReqContentLength, overwriteExistingFile, cancel)
+		}
 		if err != context.Canceled {
+			dlThrottler.recordFailure(reqArgs.Url)
 			err = fmt.Errorf(
 				"error %d: failed to download file, more info => %v\nurl: %s",
 				utils.DOWNLOAD_ERROR,
@@ -170,15 +544,76 @@ func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 		}
 		return err
 	}
+	dlThrottler.recordSuccess(reqArgs.Url)
+	wrapChaosBody(res)
+	res.Body = &pausableReader{ctx: reqArgs.Context, body: wrapStallBody(res.Body, cancel)}
 	defer res.Body.Close()
 
-	filePath, err = getFullFilePath(res, filePath)
-	if err != nil {
-		return err
+	if resumeOffset > 0 && res.StatusCode != http.StatusPartialContent {
+		// the server sends the whole file instead if it has changed since the .part file was started
+		utils.LogError(
+			nil,
+			fmt.Sprintf("%s has changed since its download was interrupted, restarting the download", reqArgs.Url),
+			false,
+			utils.INFO,
+		)
+		resumeOffset = 0
+	}
+
+	if resumeOffset > 0 {
+		if err := checkContentRange(res, resumeOffset); err != nil {
+			discardPartFile(resumePath)
+			return err
+		}
+		filePath = resumePath
+	} else {
+		filePath, err = getFullFilePath(res, filePath)
+		if err != nil {
+			return err
+		}
+		filePath = addFilenamePrefix(filePath, filenamePrefix)
+
+		if checkIfCanSkipDl(fileReqContentLength, filePath, overwriteExistingFile) {
+			recordSynced(reqArgs.Url, filePath, res)
+			recordDlStat(reqArgs.Url, dlSkipped)
+			return errDlSkipped
+		}
+		if err := checkUnexpectedHtml(res, reqArgs.Url, filePath, true); err != nil {
+			return err
+		}
 	}
 
-	if !checkIfCanSkipDl(fileReqContentLength, filePath, overwriteExistingFile) {
-		err = DlToFile(res, reqArgs.Url, filePath)
+	// the idle workers at the end of the batch can help to download the large file in chunks
+	extraChunks, releaseChunks := 0, func() {}
+	if resumeOffset == 0 {
+		extraChunks, releaseChunks = getTailChunks(reqArgs, res)
+	}
+	if extraChunks > 0 {
+		err = dlToFileInChunks(res, reqArgs, filePath, extraChunks)
+	} else {
+		err = dlToFile(res, reqArgs.Url, filePath, resumeOffset)
+	}
+	releaseChunks()
+	if err == nil {
+		expectedSize := res.ContentLength
+		if resumeOffset > 0 && expectedSize >= 0 {
+			expectedSize += resumeOffset
+		}
+		if err = checkCommittedSize(reqArgs.Url, filePath, expectedSize); err != nil {
+			return err
+		}
+		if err = verifyImage(filePath); err != nil {
+			return err
+		}
+		applyServerMtime(filePath, res)
+		if dedupFile(filePath) {
+			markSyncPlanned(reqArgs.Url, filePath)
+			recordDlStat(reqArgs.Url, dlSkipped)
+			return errDlDuplicate
+		}
+		recordSynced(reqArgs.Url, filePath, res)
+		recordDlStat(reqArgs.Url, dlDownloaded)
+		utils.RecordDownloadedFile(filePath)
 	}
 	return err
 }
@@ -187,73 +622,232 @@ func DownloadUrl(filePath string, queue chan struct{}, reqArgs *RequestArgs, ove
 //
 // Note: If the file already exists, the download process will be skipped
 func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler) {
+	urlInfoSlice = orderByPost(urlInfoSlice)
+	if addToPlan(urlInfoSlice, dlOptions, config, reqHandler) {
+		return
+	}
+	addToSyncPlan(urlInfoSlice)
+	urlInfoSlice = filterCompleted(urlInfoSlice)
+	downloadWithPostRetries(urlInfoSlice, dlOptions, config, reqHandler)
+}
+
+// Downloads all the files at once limited by the max concurrency and returns the files that failed to download.
+//
+// The failed files will only be recorded in the download stats if it is the last attempt.
+func downloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler, lastAttempt bool) []*ToDownload {
 	urlsLen := len(urlInfoSlice)
 	if urlsLen == 0 {
-		return
+		return nil
+	}
+	if maxWorkers > 0 {
+		dlOptions.MaxConcurrency = maxWorkers
 	}
 	if urlsLen < dlOptions.MaxConcurrency {
 		dlOptions.MaxConcurrency = urlsLen
 	}
 
+	batches := make(chan []*ToDownload, 1)
+	batches <- urlInfoSlice
+	close(batches)
+	return downloadUrlBatches(batches, dlOptions, config, reqHandler, lastAttempt)
+}
+
+// Downloads the batches of files from the channel as they are received, limited by the max concurrency,
+// and returns the files that failed to download. The total number of files on the progress spinner grows
+// with each batch and is reconciled once the channel is closed.
+//
+// The failed files will only be recorded in the download stats if it is the last attempt.
+func downloadUrlBatches(batches <-chan []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler, lastAttempt bool) []*ToDownload {
+	if maxWorkers > 0 {
+		dlOptions.MaxConcurrency = maxWorkers
+	}
+	if maxPostWorkers > 0 {
+		dlOptions.MaxPostConcurrency = maxPostWorkers
+	}
+
 	var wg sync.WaitGroup
+	var failedMu sync.Mutex
+	var failed []*ToDownload
+	var errsMu sync.Mutex
+	var errs []error
 	queue := make(chan struct{}, dlOptions.MaxConcurrency)
-	errChan := make(chan error, urlsLen)
+	postQueues := newPostLimiter(dlOptions.MaxPostConcurrency)
+	tail := newDlTail(queue)
+	addErr := func(err error) {
+		errsMu.Lock()
+		errs = append(errs, err)
+		errsMu.Unlock()
+	}
 
-	baseMsg := "Downloading files [%d/" + fmt.Sprintf("%d]...", urlsLen)
 	progress := spinner.New(
 		spinner.DL_SPINNER,
 		"fgHiYellow",
-		fmt.Sprintf(
-			baseMsg,
-			0,
-		),
-		fmt.Sprintf(
-			"Finished downloading %d files",
-			urlsLen,
-		),
-		fmt.Sprintf(
-			"Something went wrong while downloading %d files.\nPlease refer to the logs for more details.",
-			urlsLen,
-		),
-		urlsLen,
+		"Downloading files [0/0]...",
+		"",
+		"",
+		0,
 	)
 	progress.Start()
-	for _, urlInfo := range urlInfoSlice {
-		wg.Add(1)
-		go func(fileUrl, filePath string) {
-			defer func() {
-				wg.Done()
-				<-queue
-			}()
-			err := DownloadUrl(
-				filePath,
-				queue,
-				&RequestArgs{
-					Url:            fileUrl,
-					Method:         "GET",
-					Timeout:        utils.DOWNLOAD_TIMEOUT,
-					Cookies:        dlOptions.Cookies,
-					Headers:        dlOptions.Headers,
-					Http2:          !dlOptions.UseHttp3,
-					Http3:          dlOptions.UseHttp3,
-					UserAgent:      config.UserAgent,
-					RequestHandler: reqHandler,
-				},
-				config.OverwriteFiles,
-			)
-			if err != nil {
-				errChan <- err
+	dlCounts := newDlProgress(progress, 0)
+	startKeyboardListener()
+	dlPauser.setProgress(progress)
+	defer dlPauser.setProgress(nil)
+
+	idx := 0
+	stoppedEarly := false
+enumerate:
+	for urlInfoSlice := range batches {
+		dlCounts.addTotal(len(urlInfoSlice))
+		for _, urlInfo := range urlInfoSlice {
+			if diskFullErr.Load() != nil || IsShuttingDown() {
+				stoppedEarly = true
+				break enumerate
+			}
+			if maxTotalSizeReached() {
+				printSizeCapMsg()
+				stoppedEarly = true
+				break enumerate
 			}
 
-			if err != context.Canceled {
-				progress.MsgIncrement(baseMsg)
+			filePath := urlInfo.FilePath
+			if config.MirrorPath {
+				mirrorPath, err := utils.GetMirrorFilePath(utils.DOWNLOAD_PATH, urlInfo.Url)
+				if err != nil {
+					addErr(utils.WithLogFields(err, utils.LogFields{Url: urlInfo.Url}))
+					dlCounts.record(dlFailed)
+					continue
+				}
+				filePath = mirrorPath
 			}
-		}(urlInfo.Url, urlInfo.FilePath)
+			staggerStart(idx, dlOptions.MaxConcurrency)
+			idx++
+
+			wg.Add(1)
+			tail.waiting.Add(1)
+			go func(urlInfo *ToDownload, filePath string) {
+				defer func() {
+					wg.Done()
+					<-queue
+				}()
+				// wait for a free slot of the post before taking a worker
+				// so that the files of the other posts can be downloaded in the meantime
+				releasePost := postQueues.acquire(urlInfo.Post)
+				defer releasePost()
+
+				onRetry, retryDone := dlCounts.fileRetrying()
+				var err error
+				for requeues := 0; ; requeues++ {
+					err = downloadUrl(
+						filePath,
+						urlInfo.FilenamePrefix,
+						queue,
+						&RequestArgs{
+							Url:            urlInfo.Url,
+							Method:         "GET",
+							Timeout:        utils.DOWNLOAD_TIMEOUT,
+							Cookies:        urlInfo.mergeCookies(dlOptions.Cookies),
+							Headers:        urlInfo.mergeHeaders(dlOptions.Headers),
+							Http2:          !dlOptions.UseHttp3,
+							Http3:          dlOptions.UseHttp3,
+							UserAgent:      config.UserAgent,
+							RequestHandler: reqHandler,
+							OnRetry:        onRetry,
+							creator:        urlInfo.Creator,
+							tail:           tail,
+						},
+						config.OverwriteFiles,
+					)
+					if err != ErrDownloadStalled {
+						break
+					}
+					if requeues >= postRetries {
+						err = fmt.Errorf(
+							"error %d: failed to download file after requeuing it %d times, more info => %v\nurl: %s",
+							utils.DOWNLOAD_ERROR,
+							requeues,
+							ErrDownloadStalled,
+							urlInfo.Url,
+						)
+						break
+					}
+
+					// free up the worker's slot and requeue the stalled file behind the files waiting for a worker
+					tail.waiting.Add(1)
+					<-queue
+					utils.LogError(
+						nil,
+						fmt.Sprintf("download of %s stalled, requeuing it (%d/%d)", urlInfo.Url, requeues+1, postRetries),
+						false,
+						utils.INFO,
+					)
+				}
+				retryDone()
+				if isPostDeletedErr(urlInfo, err) {
+					err = errPostDeleted
+				}
+
+				// disk full errors are reported once after all in-flight downloads have stopped
+				var dfErr *DiskFullError
+				if err == ErrMaxTotalSizeReached {
+					printSizeCapMsg()
+				} else if err != nil && err != errDlSkipped && err != errDlDuplicate && err != errCreatorSkipped && err != errPostDeleted && !errors.As(err, &dfErr) {
+					addErr(utils.WithLogFields(err, utils.LogFields{Url: urlInfo.Url}))
+				}
+
+				switch err {
+				case nil:
+					// nil is only returned once the whole file has been committed, see checkCommittedSize
+					markCompleted(urlInfo)
+					dlCounts.record(dlDownloaded)
+				case errDlSkipped, errDlDuplicate:
+					markCompleted(urlInfo)
+					dlCounts.record(dlSkipped)
+				case ErrMaxTotalSizeReached:
+					dlCounts.record(dlSkipped)
+				case errCreatorSkipped:
+					recordDlStat(urlInfo.Url, dlFailed)
+					dlCounts.record(dlFailed)
+				case errPostDeleted:
+					recordDlStat(urlInfo.Url, dlDeleted)
+					dlCounts.record(dlSkipped)
+				case context.Canceled:
+				default:
+					RecordCreatorFailure(urlInfo.Creator, err)
+					if lastAttempt {
+						recordDlStat(urlInfo.Url, dlFailed)
+					}
+					dlCounts.record(dlFailed)
+					failedMu.Lock()
+					failed = append(failed, urlInfo)
+					failedMu.Unlock()
+				}
+			}(urlInfo, filePath)
+		}
+	}
+	tail.enumerated.Store(true)
+	if stoppedEarly {
+		// do not block the producer of the remaining batches
+		go func() {
+			for range batches {
+			}
+		}()
 	}
 	wg.Wait()
 	close(queue)
+	dlCounts.finish(stoppedEarly)
+
+	errChan := make(chan error, len(errs))
+	for _, err := range errs {
+		errChan <- err
+	}
 	close(errChan)
 
+	if dfErr := diskFullErr.Load(); dfErr != nil {
+		progress.Stop(true)
+		utils.LogErrors(false, errChan, utils.ERROR)
+		utils.LogError(dfErr, "", true, utils.ERROR)
+	}
+
 	hasErr := false
 	if len(errChan) > 0 {
 		hasErr = true
@@ -264,6 +858,7 @@ func DownloadUrlsWithHandler(urlInfoSlice []*ToDownload, dlOptions *DlOptions, c
 		}
 	}
 	progress.Stop(hasErr)
+	return failed
 }
 
 // Same as DownloadUrlsWithHandler but uses the default request handler (CallRequest)
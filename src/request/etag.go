@@ -0,0 +1,181 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// URL prefixes of the platforms' metadata APIs that are eligible for ETag caching
+var metadataApiUrls = []string{
+	utils.FANTIA_URL + "/api/",
+	utils.PIXIV_API_URL,
+	utils.PIXIV_MOBILE_URL,
+	utils.PIXIV_FANBOX_API_URL,
+	utils.KEMONO_API_URL,
+}
+
+// Returns the path of the ETag cache file in the app's config directory
+func getEtagCacheFilePath() string {
+	return filepath.Join(utils.APP_PATH, "etag_cache.json")
+}
+
+type etagEntry struct {
+	ETag string `json:"etag"`
+	Body []byte `json:"body"`
+}
+
+type etagCache struct {
+	mu      sync.Mutex
+	entries map[string]*etagEntry
+	changed bool
+}
+
+// metadataCache is nil if the ETag caching is not enabled
+var metadataCache *etagCache
+
+// EnableEtagCache loads the ETag cache file from the app's config directory
+// and enables the ETag caching of the platforms' metadata API responses.
+//
+// On subsequent runs, the "If-None-Match" header will be sent and
+// the cached response body will be used if the API responds with a 304 Not Modified.
+func EnableEtagCache() error {
+	cache := &etagCache{
+		entries: make(map[string]*etagEntry),
+	}
+
+	etagCacheFilePath := getEtagCacheFilePath()
+	data, err := os.ReadFile(etagCacheFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf(
+			"error %d: failed to read the ETag cache file at %s, more info => %v",
+			utils.OS_ERROR,
+			etagCacheFilePath,
+			err,
+		)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &cache.entries); err != nil {
+			// corrupted cache file, start with an empty cache
+			utils.LogError(
+				err,
+				fmt.Sprintf("the ETag cache file at %s is corrupted and will be reset", etagCacheFilePath),
+				false,
+				utils.ERROR,
+			)
+			cache.entries = make(map[string]*etagEntry)
+		}
+	}
+
+	metadataCache = cache
+	return nil
+}
+
+// SaveEtagCache writes the ETag cache to the app's config directory if it has changed
+func SaveEtagCache() error {
+	if metadataCache == nil {
+		return nil
+	}
+
+	metadataCache.mu.Lock()
+	defer metadataCache.mu.Unlock()
+	if !metadataCache.changed {
+		return nil
+	}
+
+	data, err := json.Marshal(metadataCache.entries)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal the ETag cache, more info => %v",
+			utils.JSON_ERROR,
+			err,
+		)
+	}
+
+	os.MkdirAll(utils.APP_PATH, 0700)
+	etagCacheFilePath := getEtagCacheFilePath()
+	if err := os.WriteFile(etagCacheFilePath, data, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the ETag cache file to %s, more info => %v",
+			utils.OS_ERROR,
+			etagCacheFilePath,
+			err,
+		)
+	}
+	metadataCache.changed = false
+	return nil
+}
+
+// Returns the cache key of the request or an empty string if the request is not eligible for caching
+func getEtagCacheKey(req *http.Request) string {
+	if metadataCache == nil || req.Method != "GET" {
+		return ""
+	}
+
+	reqUrl := req.URL.String()
+	for _, apiUrl := range metadataApiUrls {
+		if strings.HasPrefix(reqUrl, apiUrl) {
+			return reqUrl
+		}
+	}
+	return ""
+}
+
+// Adds the "If-None-Match" header to the request if there is a cached ETag for it
+func addIfNoneMatch(cacheKey string, req *http.Request) {
+	metadataCache.mu.Lock()
+	defer metadataCache.mu.Unlock()
+
+	if entry, ok := metadataCache.entries[cacheKey]; ok {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+}
+
+// Handles the response for ETag caching.
+//
+// If the API responded with a 304 Not Modified, the cached body will be returned as a 200 OK response.
+// Otherwise, the response body will be cached if the response has an ETag header.
+func handleEtagResponse(cacheKey string, res *http.Response) (*http.Response, error) {
+	metadataCache.mu.Lock()
+	defer metadataCache.mu.Unlock()
+
+	if res.StatusCode == http.StatusNotModified {
+		entry, ok := metadataCache.entries[cacheKey]
+		if !ok {
+			return res, nil
+		}
+
+		res.Body.Close()
+		res.StatusCode = http.StatusOK
+		res.Status = "200 OK"
+		res.ContentLength = int64(len(entry.Body))
+		res.Body = io.NopCloser(bytes.NewReader(entry.Body))
+		return res, nil
+	}
+
+	etag := res.Header.Get("ETag")
+	if res.StatusCode != http.StatusOK || etag == "" {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(utils.LimitResBody(res))
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	metadataCache.entries[cacheKey] = &etagEntry{
+		ETag: etag,
+		Body: body,
+	}
+	metadataCache.changed = true
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
@@ -0,0 +1,89 @@
+package request
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+type fileIndexRange struct {
+	min int
+	max int
+}
+
+// fileIndices are the 1-based positions of the files in a post to download, nil to download all files
+var fileIndices []fileIndexRange
+
+// SetFileIndices sets the positions of the files in each post to download
+// based on a comma-separated selector of numbers and ranges, e.g. "1,3,5-8".
+func SetFileIndices(selector string) error {
+	fileIndices = nil
+	if selector == "" {
+		return nil
+	}
+
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if !utils.PAGE_NUM_REGEX.MatchString(part) {
+			return fmt.Errorf(
+				"error %d: invalid file index %q in %q, expected a positive number or a range like \"5-8\"",
+				utils.INPUT_ERROR,
+				part,
+				selector,
+			)
+		}
+
+		min, max, _, err := utils.GetMinMaxFromStr(part)
+		if err != nil {
+			return err
+		}
+		fileIndices = append(fileIndices, fileIndexRange{min: min, max: max})
+	}
+	return nil
+}
+
+// Checks if the 1-based position of the file is selected
+func isFileIndexSelected(position int) bool {
+	for _, indexRange := range fileIndices {
+		if position >= indexRange.min && position <= indexRange.max {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectFileIndices returns only the files of a post at the positions set by SetFileIndices.
+//
+// The positions are based on the order of the files in the slice which is the same as the
+// one used by AddIndexPrefixes. Selected positions that exceed the number of files will be logged.
+func SelectFileIndices(postFiles []*ToDownload, postFolderPath string) []*ToDownload {
+	if fileIndices == nil {
+		return postFiles
+	}
+
+	for _, indexRange := range fileIndices {
+		if indexRange.max > len(postFiles) {
+			utils.LogError(
+				nil,
+				fmt.Sprintf(
+					"File index %d-%d is out of range for the %d file(s) in %s",
+					indexRange.min,
+					indexRange.max,
+					len(postFiles),
+					postFolderPath,
+				),
+				false,
+				utils.INFO,
+			)
+		}
+	}
+
+	selected := make([]*ToDownload, 0, len(postFiles))
+	for idx, postFile := range postFiles {
+		if isFileIndexSelected(idx + 1) {
+			selected = append(selected, postFile)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,118 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// How often to check the free space again while the downloads are paused due to low free space
+const freeSpaceCheckInterval = 10 * time.Second
+
+var (
+	minFreeSpace     int64 // in bytes, 0 to disable
+	minFreeSpaceStr  string
+	freeSpaceTimeout time.Duration // 0 to wait indefinitely
+	lowSpaceWarned   atomic.Bool
+)
+
+// SetMinFreeSpace sets the minimum free space, e.g. "5GB", that must be available on
+// the download volume before starting a new download. Leave blank to disable the check.
+//
+// If the free space stays below the minimum for longer than the
+// timeout in seconds, the run will be aborted. Use 0 to wait indefinitely.
+func SetMinFreeSpace(sizeStr string, timeout int) error {
+	if sizeStr == "" {
+		return nil
+	}
+
+	size, err := utils.ParseByteSize(sizeStr)
+	if err != nil {
+		return err
+	}
+	if timeout < 0 {
+		return fmt.Errorf(
+			"error %d: free space timeout cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			timeout,
+		)
+	}
+
+	minFreeSpace = size
+	minFreeSpaceStr = sizeStr
+	freeSpaceTimeout = time.Duration(timeout) * time.Second
+	return nil
+}
+
+// Returns the closest existing parent directory of the file path
+// as the file and its directory may not have been created yet.
+func getExistingDir(filePath string) string {
+	dirPath := filepath.Dir(filePath)
+	for !utils.PathExists(dirPath) {
+		parentDir := filepath.Dir(dirPath)
+		if parentDir == dirPath {
+			return "."
+		}
+		dirPath = parentDir
+	}
+	return dirPath
+}
+
+// waitForFreeSpace blocks new downloads while the free space on the volume
+// of the file path is below the minimum free space and resumes once space has been freed.
+//
+// The run will be aborted the same way as when the disk is full if the free space timeout is exceeded.
+func waitForFreeSpace(ctx context.Context, filePath string) error {
+	if minFreeSpace == 0 {
+		return nil
+	}
+	freeSpaceStorage, ok := dlStorage.(FreeSpaceStorage)
+	if !ok {
+		return nil
+	}
+
+	startTime := clock.Now()
+	for {
+		freeSpace, err := freeSpaceStorage.FreeSpace(filePath)
+		if err != nil {
+			// don't block the downloads if the free space cannot be determined
+			return nil
+		}
+		if freeSpace >= minFreeSpace {
+			if lowSpaceWarned.CompareAndSwap(true, false) {
+				color.Green("\nFree space is above %s again, resuming the downloads...", minFreeSpaceStr)
+			}
+			return nil
+		}
+
+		if lowSpaceWarned.CompareAndSwap(false, true) {
+			color.Yellow(
+				"\nFree space on the download volume is below %s, new downloads are paused until some space has been freed...",
+				minFreeSpaceStr,
+			)
+		}
+		if freeSpaceTimeout > 0 && clock.Now().Sub(startTime) >= freeSpaceTimeout {
+			dfErr := &DiskFullError{
+				FilePath: filePath,
+				Err: fmt.Errorf(
+					"free space has been below %s for more than %s",
+					minFreeSpaceStr,
+					freeSpaceTimeout,
+				),
+			}
+			diskFullErr.CompareAndSwap(nil, dfErr)
+			return dfErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clock.After(freeSpaceCheckInterval):
+		}
+	}
+}
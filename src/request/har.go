@@ -0,0 +1,520 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const (
+	// Max size of a request or response body that will be recorded in the HAR file
+	maxHarBodySize = 1024 * 1024
+
+	// Max number of entries kept in the HAR file where the oldest entries are dropped first
+	maxHarEntries = 10000
+
+	harRedactedValue = "[REDACTED]"
+)
+
+// Headers, cookies, query parameters, and body fields with any of these keywords in their name will have their values redacted
+var harSecretKeywords = []string{"cookie", "authorization", "session", "token", "csrf", "pass", "secret", "key", "signature", "policy"}
+
+// Headers whose values are URLs that may contain the signed tokens of the platforms' download URLs
+var harUrlHeaders = []string{"Location", "Content-Location", "Referer", "Origin"}
+
+// harFile is the root of the HAR file
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+// The HAR 1.2 format, see http://www.softwareishard.com/blog/har-12-spec/
+type harLog struct {
+	Version string      `json:"version"`
+	Creator harCreator  `json:"creator"`
+	Entries []*harEntry `json:"entries"`
+	Comment string      `json:"comment,omitempty"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"` // custom field for the requests that did not get a response
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectUrl string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harRecorder records all the HTTP requests and responses of the run
+var harRecorder = struct {
+	mu         sync.Mutex
+	filePath   string
+	withBodies bool
+	entries    []*harEntry
+	dropped    int // number of the oldest entries dropped due to maxHarEntries
+}{}
+
+// SetDumpHar sets the path of the HAR 1.2 file that all the HTTP requests and responses of the run will be recorded to
+// with their secrets like the cookies and tokens redacted. Set it to empty to disable it.
+//
+// If withBodies is true, the textual request and response bodies, e.g. the API JSON responses, will be recorded too
+// while the downloaded files will not be.
+func SetDumpHar(filePath string, withBodies bool) error {
+	if filePath == "" {
+		if withBodies {
+			return fmt.Errorf(
+				"error %d: the HAR file path must be specified to record the bodies",
+				utils.INPUT_ERROR,
+			)
+		}
+		return nil
+	}
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext != ".har" && ext != ".json" {
+		return fmt.Errorf(
+			"error %d: invalid HAR file path %q, expected a .har or .json file",
+			utils.INPUT_ERROR,
+			filePath,
+		)
+	}
+
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	harRecorder.filePath = filePath
+	harRecorder.withBodies = withBodies
+	return nil
+}
+
+func isHarSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, keyword := range harSecretKeywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the value or the redacted placeholder if the name is a secret
+func redactHarValue(name, value string) string {
+	if value != "" && isHarSecret(name) {
+		return harRedactedValue
+	}
+	return value
+}
+
+// Returns the header value with its secrets redacted including the signed tokens of the URL headers
+func redactHarHeader(name, value string) string {
+	if utils.SliceContains(harUrlHeaders, http.CanonicalHeaderKey(name)) {
+		return redactHarUrlString(value)
+	}
+	return redactHarValue(name, value)
+}
+
+// Returns the headers sorted by their name for a deterministic output
+func getHarHeaders(headers http.Header) []harNameValue {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	harHeaders := []harNameValue{}
+	for _, name := range names {
+		for _, value := range headers[name] {
+			harHeaders = append(harHeaders, harNameValue{Name: name, Value: redactHarHeader(name, value)})
+		}
+	}
+	return harHeaders
+}
+
+// Returns the cookies with all of their values redacted as they are usually the session cookies
+func getHarCookies(cookies []*http.Cookie) []harNameValue {
+	harCookies := []harNameValue{}
+	for _, cookie := range cookies {
+		harCookies = append(harCookies, harNameValue{Name: cookie.Name, Value: harRedactedValue})
+	}
+	return harCookies
+}
+
+// Returns the URL with the values of its secret query parameters redacted and its query parameters sorted by their name
+func redactHarUrl(reqUrl *url.URL) (string, []harNameValue) {
+	query := reqUrl.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	queryString := []harNameValue{}
+	for _, name := range names {
+		values := query[name]
+		for i, value := range values {
+			values[i] = redactHarValue(name, value)
+			queryString = append(queryString, harNameValue{Name: name, Value: values[i]})
+		}
+	}
+
+	redactedUrl := *reqUrl
+	redactedUrl.User = nil
+	if len(query) > 0 {
+		redactedUrl.RawQuery = query.Encode()
+	}
+	return redactedUrl.String(), queryString
+}
+
+// Same as redactHarUrl but for a URL string, e.g. from the "Location" header, which is fully redacted if it is invalid
+func redactHarUrlString(rawUrl string) string {
+	if rawUrl == "" {
+		return ""
+	}
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return harRedactedValue
+	}
+	redactedUrl, _ := redactHarUrl(parsedUrl)
+	return redactedUrl
+}
+
+// Redacts the values of the secret fields of a JSON value
+func redactHarJson(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range value {
+			if isHarSecret(key) {
+				value[key] = harRedactedValue
+			} else {
+				value[key] = redactHarJson(fieldValue)
+			}
+		}
+	case []interface{}:
+		for i, elem := range value {
+			value[i] = redactHarJson(elem)
+		}
+	}
+	return value
+}
+
+// Returns the body as text with the values of its secret JSON or form fields redacted
+func redactHarBody(body []byte, mimeType string) string {
+	switch {
+	case strings.Contains(mimeType, "json"):
+		var jsonBody interface{}
+		if err := json.Unmarshal(body, &jsonBody); err == nil {
+			if redactedBody, err := json.Marshal(redactHarJson(jsonBody)); err == nil {
+				return string(redactedBody)
+			}
+		}
+	case strings.Contains(mimeType, "x-www-form-urlencoded"):
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for name, values := range form {
+				for i, value := range values {
+					values[i] = redactHarValue(name, value)
+				}
+			}
+			return form.Encode()
+		}
+	}
+	return string(body)
+}
+
+// Checks if the body of the content type is textual and not a downloaded file
+func isHarTextBody(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	return strings.HasPrefix(mimeType, "text/") ||
+		strings.Contains(mimeType, "json") ||
+		strings.Contains(mimeType, "xml") ||
+		strings.Contains(mimeType, "x-www-form-urlencoded") ||
+		strings.Contains(mimeType, "javascript")
+}
+
+// harDownloadKey marks the context of the download requests whose bodies are never recorded
+type harDownloadKey struct{}
+
+// Marks the requests with the context as downloads of the files so that their bodies
+// will not be recorded in the HAR file even if they are textual, e.g. .txt or .json attachments.
+func withHarDownload(ctx context.Context) context.Context {
+	return context.WithValue(ctx, harDownloadKey{}, true)
+}
+
+func isHarDownload(req *http.Request) bool {
+	isDownload, _ := req.Context().Value(harDownloadKey{}).(bool)
+	return isDownload
+}
+
+func harMillis(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
+
+// harTransport records the requests sent through it and their responses to the HAR file
+type harTransport struct {
+	base http.RoundTripper
+}
+
+// Wraps the transport to record its requests and responses if a HAR file has been set
+func wrapHarTransport(base http.RoundTripper) http.RoundTripper {
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	if harRecorder.filePath == "" {
+		return base
+	}
+	return &harTransport{base: base}
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	harRecorder.mu.Lock()
+	withBodies := harRecorder.withBodies
+	harRecorder.mu.Unlock()
+	isDownload := isHarDownload(req)
+
+	reqUrl, queryString := redactHarUrl(req.URL)
+	entry := &harEntry{
+		StartedDateTime: time.Now(),
+		Request: harRequest{
+			Method:      req.Method,
+			Url:         reqUrl,
+			HttpVersion: req.Proto,
+			Cookies:     getHarCookies(req.Cookies()),
+			Headers:     getHarHeaders(req.Header),
+			QueryString: queryString,
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+		Timings: harTimings{Send: 0, Wait: -1, Receive: -1},
+	}
+	if req.Body != nil && req.GetBody != nil && withBodies {
+		mimeType := req.Header.Get("Content-Type")
+		if body, err := req.GetBody(); err == nil && isHarTextBody(mimeType) {
+			data, _ := io.ReadAll(io.LimitReader(body, maxHarBodySize))
+			body.Close()
+			entry.Request.PostData = &harPostData{
+				MimeType: mimeType,
+				Text:     redactHarBody(data, mimeType),
+			}
+		}
+	}
+
+	res, err := t.base.RoundTrip(req)
+	elapsed := time.Since(entry.StartedDateTime)
+	entry.Time = harMillis(elapsed)
+	entry.Timings.Wait = harMillis(elapsed)
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Response = harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		addHarEntry(entry)
+		return nil, err
+	}
+
+	mimeType := res.Header.Get("Content-Type")
+	entry.Response = harResponse{
+		Status:      res.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, fmt.Sprint(res.StatusCode))),
+		HttpVersion: res.Proto,
+		Cookies:     getHarCookies(res.Cookies()),
+		Headers:     getHarHeaders(res.Header),
+		Content: harContent{
+			Size:     res.ContentLength,
+			MimeType: mimeType,
+		},
+		RedirectUrl: redactHarUrlString(res.Header.Get("Location")),
+		HeadersSize: -1,
+		BodySize:    res.ContentLength,
+	}
+	// the body of a redirect is skipped as it usually repeats the signed URL of the "Location" header
+	isRedirect := res.StatusCode >= 300 && res.StatusCode < 400
+	recordBody := withBodies && !isDownload && !isRedirect && isHarTextBody(mimeType)
+	if withBodies && isDownload {
+		entry.Response.Content.Comment = "body not recorded as it is a downloaded file"
+	} else if withBodies && isRedirect {
+		entry.Response.Content.Comment = "body not recorded as it is a redirect"
+	} else if withBodies && !recordBody {
+		entry.Response.Content.Comment = "body not recorded as it is not textual"
+	}
+	addHarEntry(entry)
+
+	res.Body = &harBody{
+		ReadCloser: res.Body,
+		entry:      entry,
+		record:     recordBody,
+	}
+	return res, nil
+}
+
+// harBody records the size, receive time, and if textual, the content of the response body once it has been closed
+type harBody struct {
+	io.ReadCloser
+	entry  *harEntry
+	record bool
+	buf    bytes.Buffer
+	size   int64
+	once   sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if b.record && b.buf.Len() < maxHarBodySize {
+		remaining := maxHarBodySize - b.buf.Len()
+		if n < remaining {
+			remaining = n
+		}
+		b.buf.Write(p[:remaining])
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		harRecorder.mu.Lock()
+		defer harRecorder.mu.Unlock()
+
+		entry := b.entry
+		receive := time.Since(entry.StartedDateTime) - time.Duration(entry.Timings.Wait*float64(time.Millisecond))
+		entry.Timings.Receive = harMillis(receive)
+		entry.Time = entry.Timings.Wait + entry.Timings.Receive
+		entry.Response.Content.Size = b.size
+		entry.Response.BodySize = b.size
+		if b.record && b.buf.Len() > 0 {
+			entry.Response.Content.Text = redactHarBody(b.buf.Bytes(), entry.Response.Content.MimeType)
+			if b.size > maxHarBodySize {
+				entry.Response.Content.Comment = fmt.Sprintf("body truncated to %d bytes", maxHarBodySize)
+			}
+		}
+	})
+	return err
+}
+
+// Adds the entry to the HAR file, dropping the oldest entry if there are too many
+// so that the memory usage of a long run stays bounded while keeping the latest requests.
+func addHarEntry(entry *harEntry) {
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	if len(harRecorder.entries) >= maxHarEntries {
+		harRecorder.entries[0] = nil
+		harRecorder.entries = harRecorder.entries[1:]
+		harRecorder.dropped++
+	}
+	harRecorder.entries = append(harRecorder.entries, entry)
+}
+
+// SaveHarFile writes the recorded HTTP requests and responses to the HAR file if it has been set
+func SaveHarFile() error {
+	harRecorder.mu.Lock()
+	defer harRecorder.mu.Unlock()
+	if harRecorder.filePath == "" {
+		return nil
+	}
+
+	entries := harRecorder.entries
+	if entries == nil {
+		entries = []*harEntry{}
+	}
+	log := harLog{
+		Version: "1.2",
+		Creator: harCreator{
+			Name:    "Cultured Downloader CLI",
+			Version: utils.VERSION,
+		},
+		Entries: entries,
+	}
+	if harRecorder.dropped > 0 {
+		log.Comment = fmt.Sprintf("the oldest %d entries were dropped to keep the last %d entries", harRecorder.dropped, maxHarEntries)
+	}
+	data, err := json.MarshalIndent(harFile{Log: log}, "", "\t")
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal the HAR file, more info => %v",
+			utils.JSON_ERROR,
+			err,
+		)
+	}
+
+	if dir := filepath.Dir(harRecorder.filePath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf(
+				"error %d: failed to create the directory of the HAR file, more info => %v\ndirectory: %s",
+				utils.OS_ERROR,
+				err,
+				dir,
+			)
+		}
+	}
+	if err := os.WriteFile(harRecorder.filePath, data, 0600); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the HAR file, more info => %v\nfile path: %s",
+			utils.OS_ERROR,
+			err,
+			harRecorder.filePath,
+		)
+	}
+	return nil
+}
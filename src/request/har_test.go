@@ -0,0 +1,83 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHarFileRedactsSecrets(t *testing.T) {
+	harPath := filepath.Join(t.TempDir(), "session.har")
+	if err := SetDumpHar(harPath, true); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		harRecorder.filePath = ""
+		harRecorder.withBodies = false
+		harRecorder.entries = nil
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/post":
+			http.Redirect(w, r, "/api?Signature=signed&page=1", http.StatusFound)
+		case "/api":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"title":"post","access_token":"secret-token"}`))
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("attachment content"))
+		}
+	}))
+	defer srv.Close()
+
+	send := func(ctx context.Context, path string) {
+		req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Referer", srv.URL+"/?token=referer-token")
+		req.AddCookie(&http.Cookie{Name: "FANBOXSESSID", Value: "session-cookie"})
+		res, err := (&http.Client{Transport: wrapHarTransport(http.DefaultTransport)}).Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	send(context.Background(), "/post")
+	send(withHarDownload(context.Background()), "/attachment.txt")
+	if err := SaveHarFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(harPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"signed", "secret-token", "referer-token", "session-cookie", "attachment content"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("HAR file contains %q", secret)
+		}
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatal(err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 3 {
+		t.Fatalf("HAR log has version %q and %d entries, want 1.2 and 3", har.Log.Version, len(har.Log.Entries))
+	}
+	if redirectUrl := har.Log.Entries[0].Response.RedirectUrl; !strings.Contains(redirectUrl, "page=1") {
+		t.Errorf("redirectURL = %q, want the non-secret query parameters to be kept", redirectUrl)
+	}
+	if text := har.Log.Entries[1].Response.Content.Text; !strings.Contains(text, `"title":"post"`) {
+		t.Errorf("API response body = %q, want it to be recorded", text)
+	}
+}
@@ -0,0 +1,50 @@
+package request
+
+import (
+	"net/http"
+)
+
+// The headers sent by a browser when navigating to a page that will be added
+// to the requests if they are not already set when enabled via SetBrowserHeaders.
+//
+// Note: "Accept-Encoding" is left to the transport so that the response will be decompressed transparently.
+var browserHeaders = map[string]string{
+	"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+	"Accept-Language": "en-US,en;q=0.9,ja;q=0.8",
+	"Sec-Fetch-Dest":  "empty",
+	"Sec-Fetch-Mode":  "cors",
+	"Sec-Fetch-Site":  "same-site",
+}
+
+var useBrowserHeaders bool
+
+// SetBrowserHeaders sets whether browser-like headers like Accept, Accept-Language,
+// and Sec-Fetch-* should be added to the requests that did not set them.
+func SetBrowserHeaders(enabled bool) {
+	useBrowserHeaders = enabled
+}
+
+// AddHeaders sets the headers on the request where the default user agent
+// will be used if the "User-Agent" header is not set.
+//
+// The headers are set on req.Header directly so that each header only has the given value.
+// Note that the order in which the headers are sent is decided by the transport as req.Header is a map,
+// e.g. HTTP/1.1 writes them in alphabetical order.
+func AddHeaders(headers map[string]string, defaultUserAgent string, req *http.Request) {
+	for key, value := range headers {
+		req.Header[http.CanonicalHeaderKey(key)] = []string{value}
+	}
+	if _, ok := req.Header["Accept-Language"]; !ok && acceptLanguage != "" {
+		req.Header["Accept-Language"] = []string{acceptLanguage}
+	}
+	if useBrowserHeaders {
+		for key, value := range browserHeaders {
+			if _, ok := req.Header[key]; !ok {
+				req.Header[key] = []string{value}
+			}
+		}
+	}
+	if userAgent := req.Header.Get("User-Agent"); userAgent == "" && defaultUserAgent != "" {
+		req.Header["User-Agent"] = []string{defaultUserAgent}
+	}
+}
@@ -0,0 +1,99 @@
+package request
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Starts a server that records the header lines of the first request as they were written on the wire
+func newRawHeaderServer(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var headerLines []string
+		reader := bufio.NewReader(conn)
+		reader.ReadString('\n') // request line
+		for {
+			line, err := reader.ReadString('\n')
+			line = strings.TrimRight(line, "\r\n")
+			if err != nil || line == "" {
+				break
+			}
+			headerLines = append(headerLines, line)
+		}
+		lines <- headerLines
+		conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+	}()
+	return "http://" + ln.Addr().String(), lines
+}
+
+func TestAddHeadersOnTheWire(t *testing.T) {
+	SetBrowserHeaders(true)
+	t.Cleanup(func() { SetBrowserHeaders(false) })
+
+	srvUrl, lines := newRawHeaderServer(t)
+	req, err := http.NewRequest("GET", srvUrl+"/file.bin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Referer", "https://stale.example/")
+	AddHeaders(map[string]string{
+		"referer":        "https://www.fanbox.cc/",
+		"Sec-Fetch-Mode": "navigate",
+	}, "test-agent", req)
+
+	res, err := (&http.Client{Transport: &http.Transport{}}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	headerLines := <-lines
+	want := map[string]string{
+		"Referer":         "https://www.fanbox.cc/",
+		"Sec-Fetch-Mode":  "navigate",
+		"Sec-Fetch-Dest":  browserHeaders["Sec-Fetch-Dest"],
+		"Accept-Language": browserHeaders["Accept-Language"],
+		"User-Agent":      "test-agent",
+	}
+	var keys []string
+	for _, line := range headerLines {
+		key, value, _ := strings.Cut(line, ": ")
+		keys = append(keys, key)
+		if wantValue, ok := want[key]; ok {
+			if value != wantValue {
+				t.Errorf("%s header = %q, want %q", key, value, wantValue)
+			}
+			delete(want, key)
+		}
+	}
+	for key := range want {
+		t.Errorf("%s header was not written, got %q", key, headerLines)
+	}
+
+	// the order is decided by the transport and not by AddHeaders,
+	// HTTP/1.1 writes the Host and User-Agent first followed by the sorted headers
+	// and lastly, its own Accept-Encoding header.
+	if len(keys) < 3 || keys[0] != "Host" || keys[1] != "User-Agent" || keys[len(keys)-1] != "Accept-Encoding" {
+		t.Fatalf("header order = %q, want Host and User-Agent first and Accept-Encoding last", keys)
+	}
+	if !sort.StringsAreSorted(keys[2 : len(keys)-1]) {
+		t.Errorf("header order = %q, want the remaining headers in alphabetical order", keys)
+	}
+}
@@ -0,0 +1,129 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Sliding window in which the failed request attempts to a host are counted
+const HOST_ERROR_WINDOW = time.Minute
+
+// hostBreaker is the state of a host's circuit breaker
+type hostBreaker struct {
+	errors    []time.Time // the failed request attempts within the window
+	openUntil time.Time
+}
+
+// hostBreakers pauses all the requests to a host for a cooldown after too many failed request attempts
+// to it within a short window, e.g. to avoid getting IP banned, unlike the run-wide circuit breaker.
+var hostBreakers = struct {
+	mu        sync.Mutex
+	maxErrors int
+	cooldown  time.Duration
+	hosts     map[string]*hostBreaker
+}{
+	cooldown: 5 * time.Minute,
+	hosts:    make(map[string]*hostBreaker),
+}
+
+// SetMaxErrorsPerHost sets the number of failed request attempts to a host within a minute
+// after which all the requests to it will be paused for the cooldown. Set it to 0 to disable it.
+func SetMaxErrorsPerHost(maxErrors int) error {
+	if maxErrors < 0 {
+		return fmt.Errorf(
+			"error %d: max errors per host cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			maxErrors,
+		)
+	}
+
+	hostBreakers.mu.Lock()
+	defer hostBreakers.mu.Unlock()
+	hostBreakers.maxErrors = maxErrors
+	return nil
+}
+
+// SetHostCooldown sets how long the requests to a host are paused for after its breaker has tripped, e.g. "5m"
+func SetHostCooldown(cooldown string) error {
+	duration, err := time.ParseDuration(strings.TrimSpace(cooldown))
+	if err != nil || duration <= 0 {
+		return fmt.Errorf(
+			"error %d: invalid host cooldown %q, expected a positive duration like \"30s\" or \"5m\"",
+			utils.INPUT_ERROR,
+			cooldown,
+		)
+	}
+
+	hostBreakers.mu.Lock()
+	defer hostBreakers.mu.Unlock()
+	hostBreakers.cooldown = duration
+	return nil
+}
+
+// Records a failed request attempt to the host and trips its breaker if there were too many within the window
+func recordHostError(host string) {
+	hostBreakers.mu.Lock()
+	defer hostBreakers.mu.Unlock()
+	if hostBreakers.maxErrors == 0 {
+		return
+	}
+
+	breaker, ok := hostBreakers.hosts[host]
+	if !ok {
+		breaker = &hostBreaker{}
+		hostBreakers.hosts[host] = breaker
+	}
+
+	now := clock.Now()
+	windowStart := now.Add(-HOST_ERROR_WINDOW)
+	recentErrors := breaker.errors[:0]
+	for _, errTime := range breaker.errors {
+		if errTime.After(windowStart) {
+			recentErrors = append(recentErrors, errTime)
+		}
+	}
+	breaker.errors = append(recentErrors, now)
+	if len(breaker.errors) < hostBreakers.maxErrors || now.Before(breaker.openUntil) {
+		return
+	}
+
+	breaker.errors = nil
+	breaker.openUntil = now.Add(hostBreakers.cooldown)
+	utils.LogError(
+		nil,
+		fmt.Sprintf(
+			"%d requests to %s have failed within %s, pausing the requests to it for %s",
+			hostBreakers.maxErrors,
+			host,
+			HOST_ERROR_WINDOW,
+			hostBreakers.cooldown,
+		),
+		false,
+		utils.INFO,
+	)
+}
+
+// Waits until the breaker of the host is closed again if it has tripped or until the context is cancelled
+func waitForHostCooldown(ctx context.Context, host string) error {
+	hostBreakers.mu.Lock()
+	var wait time.Duration
+	if breaker, ok := hostBreakers.hosts[host]; ok {
+		wait = breaker.openUntil.Sub(clock.Now())
+	}
+	hostBreakers.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-clock.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,60 @@
+package request
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Number of bytes of the response body to sniff for an HTML page
+const htmlSniffLen = 512
+
+// errUnexpectedHtml is returned when an HTML page was received instead of the file to download
+var errUnexpectedHtml = errors.New("received an HTML page instead of the file")
+
+// File extensions of the downloads that are expected to be HTML pages
+var htmlFileExts = map[string]bool{
+	".html":  true,
+	".htm":   true,
+	".xhtml": true,
+}
+
+// Checks if an HTML page was received for a file that is not expected to be one, e.g. a
+// "post not found" or "login required" page that was served with a 200 OK status code.
+//
+// The response body will be sniffed if sniff is true where the peeked bytes will still be readable from the body.
+func checkUnexpectedHtml(res *http.Response, reqUrl, filePath string, sniff bool) error {
+	if htmlFileExts[strings.ToLower(filepath.Ext(filePath))] {
+		return nil
+	}
+
+	var head []byte
+	if sniff {
+		bufferedBody := bufio.NewReaderSize(res.Body, htmlSniffLen)
+		head, _ = bufferedBody.Peek(htmlSniffLen)
+		res.Body = &multiReadCloser{
+			Reader: bufferedBody,
+			Closer: res.Body,
+		}
+	}
+	if !utils.IsHtmlPage(res.Header.Get("Content-Type"), head) {
+		return nil
+	}
+
+	pageInfo := ""
+	if title := utils.GetHtmlPageTitle(head); title != "" {
+		pageInfo = fmt.Sprintf(" titled %q", title)
+	}
+	return fmt.Errorf(
+		"error %d: %w%s, the post may not exist or requires you to be logged in\nurl: %s",
+		utils.DOWNLOAD_ERROR,
+		errUnexpectedHtml,
+		pageInfo,
+		reqUrl,
+	)
+}
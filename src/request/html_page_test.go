@@ -0,0 +1,62 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const loginPageHtml = `<!DOCTYPE html><html><head><title>Login required</title></head><body>Please log in</body></html>`
+
+func TestDownloadRejectsHtmlPage(t *testing.T) {
+	useFakeClock(t)
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"html content type", "text/html; charset=utf-8"},
+		{"html body with an image content type", "image/jpeg"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", test.contentType)
+				w.WriteHeader(http.StatusOK)
+				if r.Method == http.MethodGet {
+					w.Write([]byte(loginPageHtml))
+				}
+			}))
+			defer srv.Close()
+
+			dirPath := t.TempDir()
+			filePath := filepath.Join(dirPath, "image.jpg")
+			err := DownloadUrl(filePath, make(chan struct{}, 1), &RequestArgs{
+				Url:            srv.URL + "/image.jpg",
+				Method:         "GET",
+				Timeout:        10,
+				Http2:          true,
+				RequestHandler: CallRequest,
+			}, false)
+			if err == nil || !strings.Contains(err.Error(), errUnexpectedHtml.Error()) {
+				t.Fatalf("DownloadUrl() error = %v, want %v", err, errUnexpectedHtml)
+			}
+			if !strings.Contains(err.Error(), "Login required") {
+				t.Errorf("DownloadUrl() error = %v, want the page title in the error", err)
+			}
+
+			if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+				t.Errorf("the HTML page was saved as %s", filePath)
+			}
+			if _, err := os.Stat(GetPartFilePath(filePath)); !os.IsNotExist(err) {
+				t.Errorf("the incomplete file %s was left behind", GetPartFilePath(filePath))
+			}
+			if entries, err := os.ReadDir(dirPath); err != nil || len(entries) != 0 {
+				t.Errorf("the download left %d file(s) in the directory", len(entries))
+			}
+		})
+	}
+}
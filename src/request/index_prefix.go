@@ -0,0 +1,47 @@
+package request
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// indexPrefix is whether to prefix the downloaded filenames with their order in the post
+var indexPrefix bool
+
+// SetIndexPrefix sets whether to prefix the downloaded filenames
+// with a zero-padded index of their order in the post, e.g. "001_".
+func SetIndexPrefix(enabled bool) {
+	indexPrefix = enabled
+}
+
+// IndexPrefixEnabled returns true if the downloaded filenames will be prefixed with their order in the post
+func IndexPrefixEnabled() bool {
+	return indexPrefix
+}
+
+// AddIndexPrefixes sets the filename prefix of each file of a post based on their order in the slice.
+//
+// The padding width of the index is based on the number of files, e.g. "01_" for 10 to 99 files.
+// Does nothing if the index prefix is not enabled.
+func AddIndexPrefixes(postFiles []*ToDownload) {
+	if !indexPrefix || len(postFiles) == 0 {
+		return
+	}
+
+	padWidth := len(strconv.Itoa(len(postFiles)))
+	for idx, postFile := range postFiles {
+		postFile.FilenamePrefix = fmt.Sprintf("%0*d_", padWidth, idx+1)
+	}
+}
+
+// Adds the prefix to the filename of the given file path
+func addFilenamePrefix(filePath, prefix string) string {
+	if prefix == "" {
+		return filePath
+	}
+	return filepath.Join(
+		filepath.Dir(filePath),
+		prefix+filepath.Base(filePath),
+	)
+}
@@ -0,0 +1,82 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Max size of a JSON response body that will be buffered and validated before being returned
+const maxJsonValidationSize = 32 * 1024 * 1024
+
+// errInvalidJson is returned when the JSON response was truncated or invalid and the request should be retried
+var errInvalidJson = errors.New("received an invalid or truncated JSON response")
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Returns true if the response is a JSON response, e.g. "application/json" or "application/vnd.api+json"
+func isJsonResponse(res *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Buffers the JSON response body and checks that it is valid JSON so that a response that
+// was truncated due to a connection drop can be retried instead of failing when it is unmarshalled.
+//
+// The body is replaced with the buffered content so that the caller can still read it as usual.
+// Non-JSON responses and responses larger than maxJsonValidationSize will be left as is.
+func bufferJsonBody(req *http.Request, res *http.Response) error {
+	if req.Method == http.MethodHead || res.StatusCode < 200 || res.StatusCode > 299 || !isJsonResponse(res) {
+		return nil
+	}
+	if res.ContentLength > maxJsonValidationSize {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxJsonValidationSize+1))
+	if err != nil {
+		res.Body.Close()
+		return fmt.Errorf(
+			"error %d: %w from %s, more info => %v",
+			utils.RESPONSE_ERROR,
+			errInvalidJson,
+			req.URL.String(),
+			err,
+		)
+	}
+	if len(body) > maxJsonValidationSize {
+		// too large to validate, let the caller read the rest of the body
+		res.Body = &multiReadCloser{
+			Reader: io.MultiReader(bytes.NewReader(body), res.Body),
+			Closer: res.Body,
+		}
+		return nil
+	}
+
+	res.Body.Close()
+	if !json.Valid(body) {
+		return fmt.Errorf(
+			"error %d: %w from %s (%d bytes)",
+			utils.RESPONSE_ERROR,
+			errInvalidJson,
+			req.URL.String(),
+			len(body),
+		)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	return nil
+}
@@ -0,0 +1,86 @@
+package request
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const validJsonBody = `{"body": {"items": [{"id": "1"}, {"id": "2"}]}}`
+
+func TestTruncatedJsonIsRetried(t *testing.T) {
+	useFakeClock(t)
+	tests := []struct {
+		name         string
+		truncations  int64 // number of attempts that receive a truncated body
+		dropConn     bool  // whether the connection drops instead of the body being cut short
+		wantErr      bool
+		wantAttempts int64
+	}{
+		{name: "truncated body succeeds on retry", truncations: 1, wantAttempts: 2},
+		{name: "dropped connection succeeds on retry", truncations: 1, dropConn: true, wantAttempts: 2},
+		{name: "always truncated body fails", truncations: utils.RETRY_COUNTER, wantErr: true, wantAttempts: utils.RETRY_COUNTER},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if attempts.Add(1) > test.truncations {
+					w.Write([]byte(validJsonBody))
+					return
+				}
+
+				truncated := validJsonBody[:len(validJsonBody)/2]
+				if !test.dropConn {
+					w.Write([]byte(truncated))
+					return
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(validJsonBody)))
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(truncated))
+				w.(http.Flusher).Flush()
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+			}))
+			defer srv.Close()
+
+			res, err := CallRequest(&RequestArgs{
+				Url:         srv.URL,
+				Method:      "GET",
+				Timeout:     10,
+				Http2:       true,
+				CheckStatus: true,
+			})
+			if got := attempts.Load(); got != test.wantAttempts {
+				t.Errorf("sent %d attempts, want %d", got, test.wantAttempts)
+			}
+			if test.wantErr {
+				// the error of the last attempt is only included in the message of the returned error
+				if err == nil || !strings.Contains(err.Error(), errInvalidJson.Error()) {
+					t.Errorf("CallRequest() error = %v, want %v", err, errInvalidJson)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CallRequest() error = %v", err)
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != validJsonBody {
+				t.Errorf("body = %q, want %q", body, validJsonBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package request
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Matches a language range of the Accept-Language header with an optional quality value, e.g. "ja-JP" or "en;q=0.8"
+var langRangeRegex = regexp.MustCompile(`^(\*|[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*)(;q=(0(\.\d{0,3})?|1(\.0{0,3})?))?$`)
+
+// acceptLanguage is the value of the Accept-Language header to send, empty to leave it to the platforms' defaults
+var acceptLanguage string
+
+// SetAcceptLanguage sets the Accept-Language header of the requests, e.g. "ja" or "en-US,en;q=0.9",
+// so that the platforms that support it will return the post titles and descriptions in that language.
+//
+// The header will not override the Accept-Language header set explicitly for a request.
+func SetAcceptLanguage(lang string) error {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		acceptLanguage = ""
+		return nil
+	}
+
+	for _, langRange := range strings.Split(lang, ",") {
+		if !langRangeRegex.MatchString(strings.TrimSpace(langRange)) {
+			return fmt.Errorf(
+				"error %d: invalid language %q, expected a language tag like \"ja\" or \"en-US,en;q=0.9\"",
+				utils.INPUT_ERROR,
+				lang,
+			)
+		}
+	}
+	acceptLanguage = lang
+	return nil
+}
+
+// GetAcceptLanguage returns the Accept-Language header value set via SetAcceptLanguage, if any
+func GetAcceptLanguage() string {
+	return acceptLanguage
+}
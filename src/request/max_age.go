@@ -0,0 +1,57 @@
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// maxAge is the max age of an existing file before it will be re-downloaded, 0 to disable
+var maxAge time.Duration
+
+// SetMaxAge sets the max age of the existing files, e.g. "7d" or "12h", where the files
+// that were last modified before then will be re-downloaded instead of being skipped.
+//
+// Leave blank to disable.
+func SetMaxAge(age string) error {
+	age = strings.TrimSpace(age)
+	if age == "" {
+		maxAge = 0
+		return nil
+	}
+
+	var duration time.Duration
+	var err error
+	if days, hasDaySuffix := strings.CutSuffix(age, "d"); hasDaySuffix {
+		var numOfDays float64
+		numOfDays, err = strconv.ParseFloat(days, 64)
+		duration = time.Duration(numOfDays * float64(24*time.Hour))
+	} else {
+		duration, err = time.ParseDuration(age)
+	}
+	if err != nil || duration <= 0 {
+		return fmt.Errorf(
+			"error %d: invalid max age %q, expected a positive duration like \"7d\", \"12h\", or \"30m\"",
+			utils.INPUT_ERROR,
+			age,
+		)
+	}
+	maxAge = duration
+	return nil
+}
+
+// Returns true if the existing file at the file path was last modified before the max age
+func isOlderThanMaxAge(filePath string) bool {
+	if maxAge <= 0 {
+		return false
+	}
+
+	modTime, err := dlStorage.ModTime(filePath)
+	if err != nil {
+		return false
+	}
+	return time.Since(modTime) > maxAge
+}
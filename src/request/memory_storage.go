@@ -0,0 +1,253 @@
+package request
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemoryStorage keeps the downloaded files in memory instead of writing them to
+// the local filesystem, e.g. to test the download logic via SetStorageBackend.
+//
+// The incomplete files are kept in memory as well so that the downloads can be resumed.
+type MemoryStorage struct {
+	mu         sync.Mutex
+	files      map[string]*memoryFile
+	parts      map[string][]byte
+	validators map[string]string
+}
+
+type memoryFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// NewMemoryStorage returns a new empty in-memory storage backend
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		files:      make(map[string]*memoryFile),
+		parts:      make(map[string][]byte),
+		validators: make(map[string]string),
+	}
+}
+
+// ReadFile returns a copy of the content of the file at the given path
+func (m *MemoryStorage) ReadFile(filePath string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, ok := m.files[filepath.Clean(filePath)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return append([]byte(nil), file.data...), nil
+}
+
+// HasPart checks if the incomplete file of the file path exists
+func (m *MemoryStorage) HasPart(filePath string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.parts[filepath.Clean(filePath)]
+	return ok
+}
+
+func (m *MemoryStorage) Exists(filePath string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.files[filepath.Clean(filePath)]
+	return ok
+}
+
+func (m *MemoryStorage) Size(filePath string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, ok := m.files[filepath.Clean(filePath)]
+	if !ok {
+		return -1, os.ErrNotExist
+	}
+	return int64(len(file.data)), nil
+}
+
+func (m *MemoryStorage) ModTime(filePath string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, ok := m.files[filepath.Clean(filePath)]
+	if !ok {
+		return time.Time{}, os.ErrNotExist
+	}
+	return file.modTime, nil
+}
+
+func (m *MemoryStorage) Open(filePath string) (io.ReadCloser, error) {
+	data, err := m.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MemoryStorage) Create(filePath string, size int64) (FileWriter, error) {
+	return m.CreateRandomAccess(filePath, size)
+}
+
+func (m *MemoryStorage) CreateRandomAccess(filePath string, size int64) (RandomAccessWriter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	filePath = filepath.Clean(filePath)
+	m.parts[filePath] = []byte{}
+	return &memoryFileWriter{storage: m, filePath: filePath}, nil
+}
+
+func (m *MemoryStorage) Rename(src, dest string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	src, dest = filepath.Clean(src), filepath.Clean(dest)
+	file, ok := m.files[src]
+	if !ok {
+		return os.ErrNotExist
+	}
+	m.files[dest] = file
+	delete(m.files, src)
+	return nil
+}
+
+func (m *MemoryStorage) Remove(filePath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	filePath = filepath.Clean(filePath)
+	if _, ok := m.files[filePath]; !ok {
+		return os.ErrNotExist
+	}
+	delete(m.files, filePath)
+	return nil
+}
+
+// MkdirAll is a no-op as the files are kept in a flat map
+func (m *MemoryStorage) MkdirAll(dirPath string) error {
+	return nil
+}
+
+func (m *MemoryStorage) Chtimes(filePath string, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, ok := m.files[filepath.Clean(filePath)]
+	if !ok {
+		return os.ErrNotExist
+	}
+	file.modTime = mtime
+	return nil
+}
+
+func (m *MemoryStorage) PartSize(filePath string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	part, ok := m.parts[filepath.Clean(filePath)]
+	if !ok {
+		return -1, os.ErrNotExist
+	}
+	return int64(len(part)), nil
+}
+
+func (m *MemoryStorage) OpenAppend(filePath string) (FileWriter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	filePath = filepath.Clean(filePath)
+	part, ok := m.parts[filePath]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return &memoryFileWriter{storage: m, filePath: filePath, offset: int64(len(part))}, nil
+}
+
+func (m *MemoryStorage) PartValidator(filePath string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.validators[filepath.Clean(filePath)]
+}
+
+func (m *MemoryStorage) SetPartValidator(filePath, validator string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	filePath = filepath.Clean(filePath)
+	if validator == "" {
+		delete(m.validators, filePath)
+	} else {
+		m.validators[filePath] = validator
+	}
+	return nil
+}
+
+func (m *MemoryStorage) RemovePart(filePath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	filePath = filepath.Clean(filePath)
+	delete(m.parts, filePath)
+	delete(m.validators, filePath)
+	return nil
+}
+
+// memoryFileWriter writes to the incomplete file of the file path in the MemoryStorage
+type memoryFileWriter struct {
+	storage  *MemoryStorage
+	filePath string
+
+	// offset of the next sequential Write which is kept separately from the size of the
+	// incomplete file as the chunks written via WriteAt may have grown the file past it
+	offset int64
+}
+
+func (w *memoryFileWriter) Write(p []byte) (int, error) {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+	n, err := w.writeAt(p, w.offset)
+	w.offset += int64(n)
+	return n, err
+}
+
+func (w *memoryFileWriter) WriteAt(p []byte, off int64) (int, error) {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+	return w.writeAt(p, off)
+}
+
+// Writes to the incomplete file at the offset, the storage's lock must be held
+func (w *memoryFileWriter) writeAt(p []byte, off int64) (int, error) {
+	part, ok := w.storage.parts[w.filePath]
+	if !ok {
+		return 0, os.ErrClosed
+	}
+	if end := off + int64(len(p)); end > int64(len(part)) {
+		part = append(part, make([]byte, end-int64(len(part)))...)
+	}
+	copy(part[off:], p)
+	w.storage.parts[w.filePath] = part
+	return len(p), nil
+}
+
+// Commit moves the incomplete file to its final path
+func (w *memoryFileWriter) Commit() error {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+	part, ok := w.storage.parts[w.filePath]
+	if !ok {
+		return os.ErrClosed
+	}
+	w.storage.files[w.filePath] = &memoryFile{data: part, modTime: clock.Now()}
+	delete(w.storage.parts, w.filePath)
+	return nil
+}
+
+// Abort removes the incomplete file
+func (w *memoryFileWriter) Abort() error {
+	w.storage.mu.Lock()
+	defer w.storage.mu.Unlock()
+	delete(w.storage.parts, w.filePath)
+	return nil
+}
+
+// Close keeps the incomplete file so that its download can be resumed
+func (w *memoryFileWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,118 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+var (
+	// The number of requests and downloads that were retried in this run
+	retryCount atomic.Int64
+
+	// The number of downloads that are currently in progress
+	activeWorkers atomic.Int64
+
+	metricsServer *http.Server
+)
+
+func recordRetry() {
+	retryCount.Add(1)
+}
+
+// Writes the metrics in the Prometheus text exposition format
+func writeMetrics(w http.ResponseWriter, r *http.Request) {
+	var sb strings.Builder
+	writeMetric := func(name, metricType, help string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	}
+
+	writeMetric("cultured_downloader_files_total", "counter", "Number of files processed by platform and outcome.")
+	stats := GetDownloadStats()
+	platforms := make([]string, 0, len(stats))
+	for platform := range stats {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	for _, platform := range platforms {
+		platformStats := stats[platform]
+		for _, outcome := range []struct {
+			name  string
+			count int
+		}{
+			{"downloaded", platformStats.Downloaded},
+			{"skipped", platformStats.Skipped},
+			{"failed", platformStats.Failed},
+			{"deleted", platformStats.Deleted},
+		} {
+			fmt.Fprintf(
+				&sb,
+				"cultured_downloader_files_total{platform=%q,outcome=%q} %d\n",
+				platform,
+				outcome.name,
+				outcome.count,
+			)
+		}
+	}
+
+	writeMetric("cultured_downloader_downloaded_bytes_total", "counter", "Number of bytes downloaded.")
+	fmt.Fprintf(&sb, "cultured_downloader_downloaded_bytes_total %d\n", GetTotalDownloadedBytes())
+
+	writeMetric("cultured_downloader_failures_total", "counter", "Number of errors logged.")
+	fmt.Fprintf(&sb, "cultured_downloader_failures_total %d\n", len(utils.GetLoggedErrors()))
+
+	writeMetric("cultured_downloader_retries_total", "counter", "Number of retried requests and downloads.")
+	fmt.Fprintf(&sb, "cultured_downloader_retries_total %d\n", retryCount.Load())
+
+	writeMetric("cultured_downloader_active_workers", "gauge", "Number of downloads in progress.")
+	fmt.Fprintf(&sb, "cultured_downloader_active_workers %d\n", activeWorkers.Load())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(sb.String()))
+}
+
+// StartMetricsServer starts an HTTP server on the given address, e.g. ":9090",
+// that exposes the download metrics in the Prometheus format on the "/metrics" path.
+func StartMetricsServer(addr string) error {
+	if addr == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to start the metrics server on %q, more info => %v",
+			utils.INPUT_ERROR,
+			addr,
+			err,
+		)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", writeMetrics)
+	metricsServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go metricsServer.Serve(listener)
+	return nil
+}
+
+// StopMetricsServer gracefully shuts down the metrics server if it was started
+func StopMetricsServer() {
+	if metricsServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	metricsServer.Shutdown(ctx)
+	metricsServer = nil
+}
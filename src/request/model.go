@@ -1,16 +1,76 @@
 package request
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type ToDownload struct {
 	Url      string
 	FilePath string
+
+	// Headers is an optional map of headers for this file only.
+	// They are merged with DlOptions.Headers and take precedence on conflicts.
+	Headers map[string]string
+
+	// Cookies is an optional list of cookies for this file only, e.g. the session that has access to the file's post.
+	// They are added on top of DlOptions.Cookies and take precedence on conflicts.
+	Cookies []*http.Cookie
+
+	// FilenamePrefix is an optional prefix for the downloaded filename, e.g. "001_"
+	FilenamePrefix string
+
+	// Creator is an optional creator of the file, e.g. "fanbox/creatorId", used to
+	// skip the rest of the creator's files after too many failures. Set via SetCreator.
+	Creator string
+
+	// Post is an optional post of the file, e.g. the post's folder path, used to
+	// limit the concurrent downloads of the files within a post. Set via SetPost.
+	Post string
+
+	// PostDate is an optional published date of the post of the file, used to
+	// download the posts in the order of the "--order" flag. Set via SetPostDate.
+	PostDate time.Time
+
+	// IsPostDeleted is an optional check of whether the post of the file was deleted after it was
+	// listed, used when the file could not be found. Set via SetPostDeletedCheck.
+	IsPostDeleted func() bool
+}
+
+// mergeHeaders returns the headers from dlOptions with the item's headers on top.
+func (item *ToDownload) mergeHeaders(defaultHeaders map[string]string) map[string]string {
+	if len(item.Headers) == 0 {
+		return defaultHeaders
+	}
+
+	headers := make(map[string]string, len(defaultHeaders)+len(item.Headers))
+	for key, value := range defaultHeaders {
+		headers[key] = value
+	}
+	for key, value := range item.Headers {
+		headers[key] = value
+	}
+	return headers
+}
+
+// mergeCookies returns the cookies from dlOptions appended with the item's cookies.
+func (item *ToDownload) mergeCookies(defaultCookies []*http.Cookie) []*http.Cookie {
+	if len(item.Cookies) == 0 {
+		return defaultCookies
+	}
+
+	cookies := make([]*http.Cookie, 0, len(defaultCookies)+len(item.Cookies))
+	cookies = append(cookies, defaultCookies...)
+	return append(cookies, item.Cookies...)
 }
 
 type DlOptions struct {
 	// MaxConcurrency is the maximum number of concurrent downloads
 	MaxConcurrency int
 
+	// MaxPostConcurrency is the maximum number of concurrent downloads of the files within a post, 0 for no limit
+	MaxPostConcurrency int
+
 	// Cookies is a list of cookies to be used in the download process
 	Cookies []*http.Cookie
 
@@ -0,0 +1,121 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+)
+
+func TestMergeHeaders(t *testing.T) {
+	defaultHeaders := map[string]string{"Referer": "https://www.fanbox.cc", "Origin": "https://www.fanbox.cc"}
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    map[string]string
+	}{
+		{
+			name: "no headers of the file",
+			want: defaultHeaders,
+		},
+		{
+			name:    "headers of the file are added",
+			headers: map[string]string{"Authorization": "Bearer token"},
+			want: map[string]string{
+				"Referer":       "https://www.fanbox.cc",
+				"Origin":        "https://www.fanbox.cc",
+				"Authorization": "Bearer token",
+			},
+		},
+		{
+			name:    "headers of the file take precedence",
+			headers: map[string]string{"Referer": "https://www.pixiv.net"},
+			want: map[string]string{
+				"Referer": "https://www.pixiv.net",
+				"Origin":  "https://www.fanbox.cc",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item := &ToDownload{Headers: test.headers}
+			if got := item.mergeHeaders(defaultHeaders); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("mergeHeaders() = %v, want %v", got, test.want)
+			}
+			if len(defaultHeaders) != 2 || defaultHeaders["Referer"] != "https://www.fanbox.cc" {
+				t.Errorf("mergeHeaders() modified the default headers: %v", defaultHeaders)
+			}
+		})
+	}
+}
+
+func TestMergeCookies(t *testing.T) {
+	defaultCookie := &http.Cookie{Name: "session", Value: "default"}
+	itemCookie := &http.Cookie{Name: "session", Value: "rotated"}
+	tests := []struct {
+		name    string
+		cookies []*http.Cookie
+		want    []*http.Cookie
+	}{
+		{"no cookies of the file", nil, []*http.Cookie{defaultCookie}},
+		{"cookies of the file come last", []*http.Cookie{itemCookie}, []*http.Cookie{defaultCookie, itemCookie}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			item := &ToDownload{Cookies: test.cookies}
+			if got := item.mergeCookies([]*http.Cookie{defaultCookie}); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("mergeCookies() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDownloadUsesCookiesOfTheFile(t *testing.T) {
+	useFakeClock(t)
+	var mu sync.Mutex
+	sessions := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			cookie, err := r.Cookie("session")
+			mu.Lock()
+			if err == nil {
+				sessions[r.URL.Path] = cookie.Value
+			}
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("file content"))
+	}))
+	defer srv.Close()
+
+	dlDir := t.TempDir()
+	DownloadUrlsWithHandler(
+		[]*ToDownload{
+			{Url: srv.URL + "/default.bin", FilePath: filepath.Join(dlDir, "default.bin")},
+			{
+				Url:      srv.URL + "/rotated.bin",
+				FilePath: filepath.Join(dlDir, "rotated.bin"),
+				Cookies:  []*http.Cookie{{Name: "session", Value: "rotated"}},
+			},
+		},
+		&DlOptions{
+			MaxConcurrency: 2,
+			Cookies:        []*http.Cookie{{Name: "session", Value: "default"}},
+		},
+		&configs.Config{},
+		CallRequest,
+	)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := map[string]string{"/default.bin": "default", "/rotated.bin": "rotated"}
+	if !reflect.DeepEqual(sessions, want) {
+		t.Errorf("sent the sessions %v, want %v", sessions, want)
+	}
+}
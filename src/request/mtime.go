@@ -0,0 +1,50 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+var preserveMtime bool
+
+// SetPreserveMtime sets whether the downloaded files should have their modification time
+// set to the server's Last-Modified time instead of the time of the download.
+//
+// Note: Only applies to the storage backends that can set the modification time, e.g. the local filesystem.
+func SetPreserveMtime(enabled bool) {
+	preserveMtime = enabled
+}
+
+// Sets the modification time of the downloaded file to the Last-Modified header of the response
+// if enabled, where a missing or invalid header will leave the modification time as it is.
+func applyServerMtime(filePath string, res *http.Response) {
+	if !preserveMtime {
+		return
+	}
+	mtimeStorage, ok := dlStorage.(MtimeStorage)
+	if !ok {
+		return
+	}
+
+	lastModified, err := http.ParseTime(res.Header.Get("Last-Modified"))
+	if err != nil || lastModified.After(time.Now()) {
+		return
+	}
+
+	if err := mtimeStorage.Chtimes(filePath, lastModified); err != nil {
+		utils.LogError(
+			fmt.Errorf(
+				"error %d: failed to set the modification time of %s, more info => %v",
+				utils.OS_ERROR,
+				filePath,
+				err,
+			),
+			"",
+			false,
+			utils.ERROR,
+		)
+	}
+}
@@ -0,0 +1,171 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Returns the path of the file storing the validator, i.e. the ETag or Last-Modified header, of the .part file
+func getPartValidatorPath(partFilePath string) string {
+	return partFilePath + ".validator"
+}
+
+// Returns the validator of the response to send in the "If-Range" header when resuming,
+// or an empty string if there is none as weak ETags cannot be used for a Range request.
+func getIfRangeValidator(res *http.Response) string {
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return res.Header.Get("Last-Modified")
+}
+
+// Returns true if resuming is enabled and the incomplete downloads can be resumed by a later run.
+//
+// The .part files are kept when a download fails or is interrupted so that it can be continued
+// with a Range request instead of being downloaded again from the start.
+func canResume() bool {
+	return runState != nil && canResumePart()
+}
+
+// Returns the storage backend if it keeps the incomplete downloads
+// where an interrupted download is resumed by its next attempt within the same run.
+func getPartialStorage() (PartialStorage, bool) {
+	partialStorage, ok := dlStorage.(PartialStorage)
+	return partialStorage, ok
+}
+
+// Returns true if the incomplete downloads can be resumed for the current storage backend
+func canResumePart() bool {
+	_, ok := getPartialStorage()
+	return ok
+}
+
+// Returns the size of the .part file of the file path and its validator
+// or 0 if there is no incomplete download that can be resumed.
+func getResumeInfo(filePath string) (int64, string) {
+	partialStorage, ok := getPartialStorage()
+	if filePath == "" || !ok {
+		return 0, ""
+	}
+
+	validator := partialStorage.PartValidator(filePath)
+	if validator == "" {
+		return 0, ""
+	}
+	partSize, err := partialStorage.PartSize(filePath)
+	if err != nil || partSize <= 0 {
+		return 0, ""
+	}
+	return partSize, validator
+}
+
+// Adds the "Range" and "If-Range" headers to a copy of the headers to resume the download from the offset
+// so that the server will send the whole file instead with a 200 OK status code if it has changed since.
+func addResumeHeaders(headers map[string]string, offset int64, validator string) map[string]string {
+	resumeHeaders := make(map[string]string, len(headers)+2)
+	for key, value := range headers {
+		resumeHeaders[key] = value
+	}
+	resumeHeaders["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	resumeHeaders["If-Range"] = validator
+	return resumeHeaders
+}
+
+// Checks if the 206 Partial Content response continues the .part file from the offset
+func checkContentRange(res *http.Response, offset int64) error {
+	contentRange := strings.TrimPrefix(res.Header.Get("Content-Range"), "bytes ")
+	start, _, _ := strings.Cut(contentRange, "-")
+	if parsedStart, err := strconv.ParseInt(start, 10, 64); err == nil && parsedStart == offset {
+		return nil
+	}
+	return fmt.Errorf(
+		"error %d: unexpected Content-Range, %q, when resuming from byte %d\nurl: %s",
+		utils.DOWNLOAD_ERROR,
+		res.Header.Get("Content-Range"),
+		offset,
+		res.Request.URL.String(),
+	)
+}
+
+// Checks if the status code is the 206 Partial Content response of a Range request
+func isPartialContentStatus(req *http.Request, statusCode int) bool {
+	return statusCode == http.StatusPartialContent && req.Header.Get("Range") != ""
+}
+
+// Checks if the server rejected the Range request, e.g. as the .part file is larger than the file
+func isRangeNotSatisfiable(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusRequestedRangeNotSatisfiable
+}
+
+// Saves the validator of the response for the new .part file so that the download can be resumed safely
+func savePartValidator(filePath string, res *http.Response) {
+	partialStorage, ok := getPartialStorage()
+	if !ok {
+		return
+	}
+
+	// without a validator, the download cannot be resumed safely
+	// as it is unknown if the file has changed, hence any old validator is removed
+	if err := partialStorage.SetPartValidator(filePath, getIfRangeValidator(res)); err != nil {
+		utils.LogError(
+			fmt.Errorf(
+				"error %d: failed to save the resume validator of %s, more info => %v",
+				utils.OS_ERROR,
+				filePath,
+				err,
+			),
+			"",
+			false,
+			utils.ERROR,
+		)
+	}
+}
+
+// Removes the .part file of the file path and its validator so that the download restarts from the start
+func discardPartFile(filePath string) {
+	if partialStorage, ok := getPartialStorage(); ok {
+		partialStorage.RemovePart(filePath)
+	}
+}
+
+// Returns true if the .part file of the file path should be kept to be resumed later
+func keepPartFile(filePath string) bool {
+	partialStorage, ok := getPartialStorage()
+	return ok && partialStorage.PartValidator(filePath) != ""
+}
+
+// Opens the .part file of the file path to append the rest of the download to
+func appendToPartFile(filePath string) (FileWriter, error) {
+	partialStorage, ok := getPartialStorage()
+	if !ok {
+		return nil, fmt.Errorf(
+			"error %d: the storage backend cannot resume the download of %s",
+			utils.DEV_ERROR,
+			filePath,
+		)
+	}
+	return partialStorage.OpenAppend(filePath)
+}
+
+// Closes the .part file without removing it so that its download can be resumed
+func closePartFile(file FileWriter) {
+	if closer, ok := file.(io.Closer); ok {
+		closer.Close()
+	} else {
+		file.Abort()
+	}
+}
+
+// Removes the validator of the .part file of the file path after its download has been completed or aborted
+func removePartValidator(filePath string) {
+	if partialStorage, ok := getPartialStorage(); ok {
+		partialStorage.SetPartValidator(filePath, "")
+	}
+}
@@ -0,0 +1,196 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const partialResumeContent = "hello world, this is the whole file"
+
+// Serves the content with the given ETag where http.ServeContent handles the Range and If-Range headers
+func newRangeServer(t *testing.T, etag string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			mu.Lock()
+			ranges = append(ranges, r.Header.Get("Range"))
+			mu.Unlock()
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader([]byte(partialResumeContent)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func TestDownloadResumesPartFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		partContent   string
+		partValidator string
+		wantRange     string
+	}{
+		{
+			name:          "206 appends to the part file",
+			partContent:   partialResumeContent[:11],
+			partValidator: `"v1"`,
+			wantRange:     "bytes=11-",
+		},
+		{
+			name:          "200 restarts the changed file",
+			partContent:   "stale bytes",
+			partValidator: `"v0"`,
+			wantRange:     "bytes=11-",
+		},
+		{
+			name:      "no part file downloads the whole file",
+			wantRange: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, getRanges := newRangeServer(t, `"v1"`)
+			filePath := filepath.Join(t.TempDir(), "file.bin")
+			partFilePath := GetPartFilePath(filePath)
+			if test.partContent != "" {
+				if err := os.WriteFile(partFilePath, []byte(test.partContent), 0666); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(getPartValidatorPath(partFilePath), []byte(test.partValidator), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := DownloadUrl(filePath, make(chan struct{}, 1), &RequestArgs{
+				Url:            srv.URL + "/file.bin",
+				Method:         "GET",
+				Timeout:        10,
+				Http2:          true,
+				RequestHandler: CallRequest,
+			}, false)
+			if err != nil {
+				t.Fatalf("DownloadUrl() error = %v", err)
+			}
+
+			data, err := os.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != partialResumeContent {
+				t.Errorf("file content = %q, want %q", data, partialResumeContent)
+			}
+			if ranges := getRanges(); len(ranges) != 1 || ranges[0] != test.wantRange {
+				t.Errorf("GET Range headers = %q, want [%q]", ranges, test.wantRange)
+			}
+			for _, leftover := range []string{partFilePath, getPartValidatorPath(partFilePath)} {
+				if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+					t.Errorf("%s was not removed after the download", leftover)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadResumesInterruptedAttempt(t *testing.T) {
+	useFakeClock(t)
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if r.Method == http.MethodGet {
+			mu.Lock()
+			ranges = append(ranges, r.Header.Get("Range"))
+			firstAttempt := len(ranges) == 1
+			mu.Unlock()
+			if firstAttempt {
+				// drop the connection after sending the first half of the file
+				w.Header().Set("Content-Length", strconv.Itoa(len(partialResumeContent)))
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(partialResumeContent[:11]))
+				w.(http.Flusher).Flush()
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader([]byte(partialResumeContent)))
+	}))
+	defer srv.Close()
+
+	filePath := filepath.Join(t.TempDir(), "file.bin")
+	err := DownloadUrl(filePath, make(chan struct{}, 1), &RequestArgs{
+		Url:            srv.URL + "/file.bin",
+		Method:         "GET",
+		Timeout:        10,
+		Http2:          true,
+		RequestHandler: CallRequest,
+	}, false)
+	if err != nil {
+		t.Fatalf("DownloadUrl() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != partialResumeContent {
+		t.Errorf("file content = %q, want %q", data, partialResumeContent)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ranges) != 2 || ranges[1] != "bytes=11-" {
+		t.Errorf("GET Range headers = %q, want the retry to resume from byte 11", ranges)
+	}
+}
+
+func TestDownloadFailsWhenInterrupted(t *testing.T) {
+	useFakeClock(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(partialResumeContent)))
+		if r.Method == http.MethodHead {
+			return
+		}
+		// without a validator, the download cannot be resumed and always gets cut off
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(partialResumeContent[:11]))
+		w.(http.Flusher).Flush()
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	filePath := filepath.Join(t.TempDir(), "file.bin")
+	err := DownloadUrl(filePath, make(chan struct{}, 1), &RequestArgs{
+		Url:            srv.URL + "/file.bin",
+		Method:         "GET",
+		Timeout:        10,
+		Http2:          true,
+		RequestHandler: CallRequest,
+	}, false)
+	if !errors.Is(err, errDlInterrupted) {
+		t.Fatalf("DownloadUrl() error = %v, want %v", err, errDlInterrupted)
+	}
+	for _, path := range []string{filePath, GetPartFilePath(filePath)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should not exist after the interrupted download", path)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package request
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/fatih/color"
+)
+
+// pauseController is used to pause and resume the downloads
+// via keyboard controls which are read from stdin.
+type pauseController struct {
+	mu       sync.Mutex
+	paused   bool
+	resumeCh chan struct{} // closed when the downloads are resumed
+	progress *spinner.Spinner
+}
+
+var (
+	dlPauser         = &pauseController{}
+	keyboardControls bool
+	keyboardOnce     sync.Once
+)
+
+// EnableKeyboardControls enables the user to type "p" and press ENTER to pause
+// the downloads and "r" followed by ENTER to resume the downloads.
+//
+// Note: stdin will be consumed while downloading, hence this is not enabled by default
+// as some platforms like Fantia may require the user to press ENTER after solving a reCAPTCHA.
+func EnableKeyboardControls() {
+	keyboardControls = true
+}
+
+func (p *pauseController) pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused {
+		return
+	}
+
+	p.paused = true
+	p.resumeCh = make(chan struct{})
+	if p.progress != nil {
+		p.progress.SetPaused(true)
+	}
+}
+
+func (p *pauseController) resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused {
+		return
+	}
+
+	p.paused = false
+	close(p.resumeCh)
+	if p.progress != nil {
+		p.progress.SetPaused(false)
+	}
+}
+
+func (p *pauseController) isPaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// setProgress sets the spinner to show the paused state on
+func (p *pauseController) setProgress(progress *spinner.Spinner) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress = progress
+	if progress != nil && p.paused {
+		progress.SetPaused(true)
+	}
+}
+
+// wait blocks until the downloads are resumed or the context is cancelled
+func (p *pauseController) wait(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.paused {
+		p.mu.Unlock()
+		return nil
+	}
+	resumeCh := p.resumeCh
+	p.mu.Unlock()
+
+	select {
+	case <-resumeCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startKeyboardListener starts reading the keyboard controls from stdin
+// if enabled and if stdin is an interactive terminal.
+func startKeyboardListener() {
+	if !keyboardControls {
+		return
+	}
+
+	keyboardOnce.Do(func() {
+		stat, err := os.Stdin.Stat()
+		if err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+			return
+		}
+
+		color.Yellow("Keyboard controls: type \"p\" and press ENTER to pause, \"r\" and press ENTER to resume.")
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+				case "p":
+					dlPauser.pause()
+				case "r":
+					dlPauser.resume()
+				}
+			}
+		}()
+	})
+}
+
+// pausableReader blocks reads from the response body while the downloads are paused
+type pausableReader struct {
+	ctx  context.Context
+	body io.ReadCloser
+}
+
+func (r *pausableReader) Read(p []byte) (int, error) {
+	if err := dlPauser.wait(r.ctx); err != nil {
+		return 0, err
+	}
+	return r.body.Read(p)
+}
+
+func (r *pausableReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,232 @@
+package request
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// plannedDl is a batch of downloads that was deferred until all the posts have been enumerated
+type plannedDl struct {
+	urlInfoSlice []*ToDownload
+	dlOptions    *DlOptions
+	config       *configs.Config
+	reqHandler   RequestHandler
+}
+
+// dlPlan holds the downloads and the follow-up tasks, e.g. the Ugoira conversions,
+// of a run that enumerates all the posts first before downloading any of them.
+var dlPlan = struct {
+	mu        sync.Mutex
+	enabled   bool
+	downloads []*plannedDl
+	deferred  []func()
+}{}
+
+// PlanSummary is the summary of the downloads planned by the run before they are started
+type PlanSummary struct {
+	Files int
+	Posts int
+
+	// KnownSize is the total size in bytes of the files that reported a size
+	KnownSize int64
+
+	// UnknownSizes is the number of files whose size could not be determined
+	UnknownSizes int
+
+	// FreeSpace is the free space in bytes of the download directory, -1 if it could not be determined
+	FreeSpace int64
+}
+
+// SetPlanFirst sets whether all the posts and their files should be enumerated before any of the downloads are started
+// where the downloads are deferred until RunDownloadPlan is called, e.g. after the user has confirmed the plan.
+func SetPlanFirst(enabled bool) {
+	dlPlan.mu.Lock()
+	defer dlPlan.mu.Unlock()
+	dlPlan.enabled = enabled
+}
+
+// Returns true if all the posts and their files are enumerated before any of the downloads are started
+func isPlanFirst() bool {
+	dlPlan.mu.Lock()
+	defer dlPlan.mu.Unlock()
+	return dlPlan.enabled
+}
+
+// Adds the downloads to the plan instead of downloading them if enumerating first.
+//
+// Returns false if the downloads should be started right away.
+func addToPlan(urlInfoSlice []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler) bool {
+	dlPlan.mu.Lock()
+	defer dlPlan.mu.Unlock()
+	if !dlPlan.enabled {
+		return false
+	}
+
+	if len(urlInfoSlice) > 0 {
+		dlPlan.downloads = append(dlPlan.downloads, &plannedDl{
+			urlInfoSlice: urlInfoSlice,
+			dlOptions:    dlOptions,
+			config:       config,
+			reqHandler:   reqHandler,
+		})
+	}
+	return true
+}
+
+// DeferUntilPlanned runs the task after the planned downloads if enumerating first, or right away otherwise,
+// for the tasks that depend on the downloaded files, e.g. converting the Ugoira after their zip files are downloaded.
+func DeferUntilPlanned(task func()) {
+	dlPlan.mu.Lock()
+	if dlPlan.enabled {
+		dlPlan.deferred = append(dlPlan.deferred, task)
+		dlPlan.mu.Unlock()
+		return
+	}
+	dlPlan.mu.Unlock()
+	task()
+}
+
+// HasDownloadPlan returns true if there are any downloads or tasks deferred until the plan is confirmed
+func HasDownloadPlan() bool {
+	dlPlan.mu.Lock()
+	defer dlPlan.mu.Unlock()
+	return len(dlPlan.downloads) > 0 || len(dlPlan.deferred) > 0
+}
+
+// EstimateDownloadPlan sends a HEAD request for each of the planned files to sum up their sizes.
+//
+// The total size is an upper bound as the files that already exist may still be skipped when downloading.
+func EstimateDownloadPlan() *PlanSummary {
+	dlPlan.mu.Lock()
+	downloads := dlPlan.downloads
+	dlPlan.mu.Unlock()
+
+	summary := &PlanSummary{FreeSpace: -1}
+	posts := make(map[string]struct{})
+	for _, planned := range downloads {
+		summary.Files += len(planned.urlInfoSlice)
+		for _, urlInfo := range planned.urlInfoSlice {
+			if urlInfo.Post != "" {
+				posts[urlInfo.Post] = struct{}{}
+			}
+		}
+	}
+	summary.Posts = len(posts)
+	if freeSpaceStorage, ok := dlStorage.(FreeSpaceStorage); ok {
+		if freeSpace, err := freeSpaceStorage.FreeSpace(filepath.Join(utils.DOWNLOAD_PATH, "plan")); err == nil {
+			summary.FreeSpace = freeSpace
+		}
+	}
+	if summary.Files == 0 {
+		return summary
+	}
+
+	concurrency := utils.MAX_CONCURRENT_DOWNLOADS
+	if maxWorkers > 0 {
+		concurrency = maxWorkers
+	}
+	baseMsg := "Getting the size of the planned files [%d/" + fmt.Sprintf("%d]...", summary.Files)
+	progress := spinner.New(
+		spinner.REQ_SPINNER,
+		"fgHiYellow",
+		fmt.Sprintf(
+			baseMsg,
+			0,
+		),
+		fmt.Sprintf(
+			"Finished getting the size of %d planned files!",
+			summary.Files,
+		),
+		"",
+		summary.Files,
+	)
+	progress.Start()
+
+	var wg sync.WaitGroup
+	var knownSize, unknownSizes atomic.Int64
+	queue := make(chan struct{}, concurrency)
+	for _, planned := range downloads {
+		for _, urlInfo := range planned.urlInfoSlice {
+			wg.Add(1)
+			go func(planned *plannedDl, urlInfo *ToDownload) {
+				defer func() {
+					<-queue
+					wg.Done()
+				}()
+
+				queue <- struct{}{}
+				res, err := planned.reqHandler(
+					&RequestArgs{
+						Url:         urlInfo.Url,
+						Method:      "HEAD",
+						Timeout:     10,
+						Cookies:     urlInfo.mergeCookies(planned.dlOptions.Cookies),
+						Headers:     urlInfo.mergeHeaders(planned.dlOptions.Headers),
+						UserAgent:   planned.config.UserAgent,
+						CheckStatus: true,
+						Http2:       !planned.dlOptions.UseHttp3,
+						Http3:       planned.dlOptions.UseHttp3,
+					},
+				)
+				if err == nil {
+					res.Body.Close()
+				}
+				if err != nil || res.ContentLength < 0 {
+					unknownSizes.Add(1)
+				} else {
+					knownSize.Add(res.ContentLength)
+				}
+				progress.MsgIncrement(baseMsg)
+			}(planned, urlInfo)
+		}
+	}
+	wg.Wait()
+	progress.Stop(false)
+
+	summary.KnownSize = knownSize.Load()
+	summary.UnknownSizes = int(unknownSizes.Load())
+	return summary
+}
+
+// RunDownloadPlan starts the planned downloads followed by the deferred tasks
+func RunDownloadPlan() {
+	dlPlan.mu.Lock()
+	downloads, deferred := dlPlan.downloads, dlPlan.deferred
+	dlPlan.enabled = false
+	dlPlan.downloads, dlPlan.deferred = nil, nil
+	dlPlan.mu.Unlock()
+
+	for _, planned := range downloads {
+		if IsShuttingDown() {
+			return
+		}
+		DownloadUrlsWithHandler(planned.urlInfoSlice, planned.dlOptions, planned.config, planned.reqHandler)
+	}
+	for _, task := range deferred {
+		if IsShuttingDown() {
+			return
+		}
+		task()
+	}
+}
+
+// DiscardDownloadPlan discards the planned downloads and the deferred tasks,
+// e.g. when the user did not confirm the plan, and returns the number of planned files.
+func DiscardDownloadPlan() int {
+	dlPlan.mu.Lock()
+	defer dlPlan.mu.Unlock()
+
+	discarded := 0
+	for _, planned := range dlPlan.downloads {
+		discarded += len(planned.urlInfoSlice)
+	}
+	dlPlan.enabled = false
+	dlPlan.downloads, dlPlan.deferred = nil, nil
+	return discarded
+}
@@ -0,0 +1,90 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+var (
+	// Connection pool settings of the shared http.Transport, see SetConnectionPool
+	maxIdleConns        = utils.DEFAULT_MAX_IDLE_CONNS
+	maxIdleConnsPerHost = utils.DEFAULT_MAX_IDLE_CONNS_PER_HOST
+	maxConnsPerHost     = 0
+	idleConnTimeout     = utils.DEFAULT_IDLE_CONN_TIMEOUT * time.Second
+
+	// sharedTransports caches the http.Transport per proxy and compression setting
+	// so that the idle connections are reused across the requests instead of a new pool per request.
+	sharedTransports   = map[transportKey]*http.Transport{}
+	sharedTransportsMu sync.Mutex
+)
+
+type transportKey struct {
+	proxy              string
+	disableCompression bool
+}
+
+// SetConnectionPool configures the connection pool of the shared http.Transport.
+//
+//   - maxIdle is the max number of idle connections across all hosts, 0 for no limit
+//   - maxIdlePerHost is the max number of idle connections kept per host.
+//     Should be at least the number of workers so that the connections are reused between files.
+//   - maxPerHost is the max number of connections per host including the active ones, 0 for no limit.
+//     Lower it to avoid rate limits at the cost of the workers waiting for a free connection.
+//   - idleTimeoutSeconds is how long an idle connection is kept open before it is closed, 0 for no limit
+//
+// Note: The settings do not apply to HTTP/3 which multiplexes the requests over a single connection per host.
+func SetConnectionPool(maxIdle, maxIdlePerHost, maxPerHost, idleTimeoutSeconds int) error {
+	settings := map[string]int{
+		"--max_idle_conns":          maxIdle,
+		"--max_idle_conns_per_host": maxIdlePerHost,
+		"--max_conns_per_host":      maxPerHost,
+		"--idle_conn_timeout":       idleTimeoutSeconds,
+	}
+	for flagName, value := range settings {
+		if value < 0 {
+			return fmt.Errorf(
+				"error %d: %s cannot be negative, got %d",
+				utils.INPUT_ERROR,
+				flagName,
+				value,
+			)
+		}
+	}
+
+	sharedTransportsMu.Lock()
+	defer sharedTransportsMu.Unlock()
+	maxIdleConns = maxIdle
+	maxIdleConnsPerHost = maxIdlePerHost
+	maxConnsPerHost = maxPerHost
+	idleConnTimeout = time.Duration(idleTimeoutSeconds) * time.Second
+	for key, transport := range sharedTransports {
+		transport.CloseIdleConnections()
+		delete(sharedTransports, key)
+	}
+	return nil
+}
+
+// Returns the shared http.Transport for the request's proxy and compression
+// setting, creating it based on the user's network configurations if needed.
+func getSharedHttp2Transport(reqArgs *RequestArgs) *http.Transport {
+	key := transportKey{
+		disableCompression: reqArgs.DisableCompression,
+	}
+	if proxyUrl := getProxy(reqArgs.Url); proxyUrl != nil {
+		key.proxy = proxyUrl.String()
+	}
+
+	sharedTransportsMu.Lock()
+	defer sharedTransportsMu.Unlock()
+	if transport, ok := sharedTransports[key]; ok {
+		return transport
+	}
+
+	transport := getHttp2Transport(reqArgs)
+	sharedTransports[key] = transport
+	return transport
+}
@@ -0,0 +1,111 @@
+package request
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const (
+	// Download the oldest posts first
+	POST_ORDER_ASC = "asc"
+
+	// Download the newest posts first
+	POST_ORDER_DESC = "desc"
+)
+
+var ACCEPTED_POST_ORDER = []string{
+	POST_ORDER_ASC,
+	POST_ORDER_DESC,
+}
+
+// postOrder is the order the posts are downloaded in, empty for the platform's natural order
+var postOrder string
+
+// SetPostOrder sets the order the posts are downloaded in by their published date.
+// Set it to empty to keep the platform's natural order, i.e. the order the posts were listed in.
+func SetPostOrder(order string) error {
+	order = strings.ToLower(order)
+	if order != "" && !utils.SliceContains(ACCEPTED_POST_ORDER, order) {
+		return fmt.Errorf(
+			"error %d: invalid post order %q, expected one of %s",
+			utils.INPUT_ERROR,
+			order,
+			strings.Join(ACCEPTED_POST_ORDER, ", "),
+		)
+	}
+	postOrder = order
+	return nil
+}
+
+// SetPostDate sets the published date of the post of the files, in any of the platforms' date formats,
+// so that the posts can be downloaded in the order of the "--order" flag.
+func SetPostDate(postFiles []*ToDownload, publishedDate string) {
+	postDate, ok := utils.ParsePostDate(publishedDate)
+	if !ok {
+		return
+	}
+	for _, postFile := range postFiles {
+		postFile.PostDate = postDate
+	}
+}
+
+// postGroup is the files of a post in their natural order
+type postGroup struct {
+	files []*ToDownload
+	date  time.Time
+}
+
+// Reorders the files by the published date of their posts where the files of a post are kept together
+// in their natural order so that their index prefixes are not affected.
+//
+// The posts with the same date keep their natural order for a deterministic order. If any of the posts
+// does not have a published date, the natural order, which is the newest first for all the platforms,
+// is reversed for the ascending order instead.
+func orderByPost(urlInfoSlice []*ToDownload) []*ToDownload {
+	if postOrder == "" || len(urlInfoSlice) < 2 {
+		return urlInfoSlice
+	}
+
+	var groups []*postGroup
+	groupIdx := make(map[string]int)
+	allDated := true
+	for _, urlInfo := range urlInfoSlice {
+		if urlInfo.Post != "" {
+			if idx, ok := groupIdx[urlInfo.Post]; ok {
+				groups[idx].files = append(groups[idx].files, urlInfo)
+				continue
+			}
+			groupIdx[urlInfo.Post] = len(groups)
+		}
+		if urlInfo.PostDate.IsZero() {
+			allDated = false
+		}
+		groups = append(groups, &postGroup{
+			files: []*ToDownload{urlInfo},
+			date:  urlInfo.PostDate,
+		})
+	}
+
+	if allDated {
+		sort.SliceStable(groups, func(i, j int) bool {
+			if postOrder == POST_ORDER_ASC {
+				return groups[i].date.Before(groups[j].date)
+			}
+			return groups[i].date.After(groups[j].date)
+		})
+	} else if postOrder == POST_ORDER_ASC {
+		for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+			groups[i], groups[j] = groups[j], groups[i]
+		}
+	}
+
+	ordered := make([]*ToDownload, 0, len(urlInfoSlice))
+	for _, group := range groups {
+		ordered = append(ordered, group.files...)
+	}
+	return ordered
+}
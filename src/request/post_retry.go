@@ -0,0 +1,58 @@
+package request
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+// postRetries is the number of times the files that failed to download will be retried
+// after the rest of the files have been downloaded, on top of the per-request retries in CallRequest.
+var postRetries int
+
+// SetPostRetries sets the number of times to retry the files that are still failing after all the other
+// files have been downloaded, with a longer pause that increases with each retry in between.
+//
+// Only the failed files will be retried, the files that were downloaded successfully will not be re-checked.
+// Stalled downloads will also be requeued up to the same number of times within the batch instead of
+// being retried by the same worker so that a stuck file does not tie up a worker.
+func SetPostRetries(retries int) error {
+	if retries < 0 {
+		return fmt.Errorf(
+			"error %d: post retries cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			retries,
+		)
+	}
+	postRetries = retries
+	return nil
+}
+
+// Downloads the files and retries the ones that failed up to postRetries times
+func downloadWithPostRetries(urlInfoSlice []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler) {
+	failed := downloadInWaves(urlInfoSlice, dlOptions, config, reqHandler, postRetries == 0)
+	retryFailedDownloads(failed, dlOptions, config, reqHandler)
+}
+
+// Retries the files that failed to download on the first attempt up to postRetries times
+func retryFailedDownloads(failed []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler) {
+	for attempt := 1; attempt <= postRetries && len(failed) > 0; attempt++ {
+		if diskFullErr.Load() != nil || maxTotalSizeReached() || IsShuttingDown() {
+			return
+		}
+
+		delay := time.Duration(attempt*utils.POST_RETRY_DELAY) * time.Second
+		color.Yellow(
+			"\nRetrying %d failed file(s) in %s [%d/%d]...",
+			len(failed),
+			delay,
+			attempt,
+			postRetries,
+		)
+		clock.Sleep(delay)
+		failed = downloadInWaves(failed, dlOptions, config, reqHandler, attempt == postRetries)
+	}
+}
@@ -0,0 +1,69 @@
+package request
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// maxPostWorkers overrides the max concurrent downloads per post of the platforms if set
+var maxPostWorkers int
+
+// SetMaxPostWorkers sets the max number of concurrent downloads of the files within a single post,
+// independent of the max concurrent downloads across the posts. Set it to 0 for no limit per post.
+func SetMaxPostWorkers(workers int) error {
+	if workers < 0 {
+		return fmt.Errorf(
+			"error %d: number of workers per post cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			workers,
+		)
+	}
+	maxPostWorkers = workers
+	return nil
+}
+
+// SetPost sets the post of the files to download so that their
+// concurrent downloads can be limited per post, e.g. the post's folder path.
+func SetPost(postFiles []*ToDownload, post string) {
+	for _, postFile := range postFiles {
+		postFile.Post = post
+	}
+}
+
+// postLimiter limits the number of concurrent downloads of the files of the same post
+type postLimiter struct {
+	mu     sync.Mutex
+	max    int
+	queues map[string]chan struct{}
+}
+
+func newPostLimiter(max int) *postLimiter {
+	return &postLimiter{
+		max:    max,
+		queues: make(map[string]chan struct{}),
+	}
+}
+
+// Waits for a free slot of the post and returns a function to release it.
+//
+// Files without a post or a limiter without a max will not wait.
+func (l *postLimiter) acquire(post string) func() {
+	if l.max == 0 || post == "" {
+		return func() {}
+	}
+
+	l.mu.Lock()
+	queue, ok := l.queues[post]
+	if !ok {
+		queue = make(chan struct{}, l.max)
+		l.queues[post] = queue
+	}
+	l.mu.Unlock()
+
+	queue <- struct{}{}
+	return func() {
+		<-queue
+	}
+}
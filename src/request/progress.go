@@ -0,0 +1,130 @@
+package request
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
+)
+
+// dlProgress keeps the live counts of the downloaded, skipped, failed,
+// and currently retrying files of a batch of downloads to show on the spinner.
+type dlProgress struct {
+	mu         sync.Mutex
+	spinner    *spinner.Spinner
+	total      int
+	downloaded int
+	skipped    int
+	failed     int
+	retrying   int
+}
+
+func newDlProgress(progress *spinner.Spinner, total int) *dlProgress {
+	return &dlProgress{
+		spinner: progress,
+		total:   total,
+	}
+}
+
+func (p *dlProgress) msg() string {
+	var retryingMsg string
+	if p.retrying > 0 {
+		retryingMsg = fmt.Sprintf(" (%d retrying)", p.retrying)
+	}
+	return fmt.Sprintf(
+		"Downloading files [%d/%d] (%d downloaded, %d skipped, %d failed)%s...",
+		p.downloaded+p.skipped+p.failed,
+		p.total,
+		p.downloaded,
+		p.skipped,
+		p.failed,
+		retryingMsg,
+	)
+}
+
+// record increments the count of the given outcome and updates the spinner message.
+//
+// Safe to be called from multiple goroutines.
+func (p *dlProgress) record(outcome int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch outcome {
+	case dlDownloaded:
+		p.downloaded++
+	case dlSkipped:
+		p.skipped++
+	case dlFailed:
+		p.failed++
+	}
+	p.spinner.Add(1)
+	p.spinner.UpdateMsg(p.msg())
+}
+
+// fileRetrying returns a callback for RequestArgs.OnRetry that marks the file as
+// being retried on its first retry and a function to unmark it once the file is done.
+//
+// Safe to be called from multiple goroutines.
+func (p *dlProgress) fileRetrying() (onRetry func(), done func()) {
+	var once sync.Once
+	var retrying bool
+	onRetry = func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			retrying = true
+			p.retrying++
+			p.spinner.UpdateMsg(p.msg())
+		})
+	}
+	done = func() {
+		// ensures that the retry callback can no longer mark the file
+		once.Do(func() {})
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if retrying {
+			p.retrying--
+			p.spinner.UpdateMsg(p.msg())
+		}
+	}
+	return onRetry, done
+}
+
+// addTotal grows the total number of files by n as more files are discovered
+// while the earlier files are being downloaded, e.g. when the posts are still being listed.
+//
+// Safe to be called from multiple goroutines.
+func (p *dlProgress) addTotal(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.total += n
+	p.spinner.SetMax(p.total)
+	p.spinner.UpdateMsg(p.msg())
+}
+
+// finish reconciles the total number of files with the files that were actually processed
+// once no more files will be discovered, e.g. when the downloads were stopped early,
+// and updates the outcome messages of the spinner with the final total.
+func (p *dlProgress) finish(stoppedEarly bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if processed := p.downloaded + p.skipped + p.failed; stoppedEarly && processed < p.total {
+		p.total = processed
+		p.spinner.SetMax(p.total)
+	}
+	p.spinner.UpdateMsg(p.msg())
+	p.spinner.SetOutcomeMsgs(
+		fmt.Sprintf(
+			"Finished downloading %d files",
+			p.total,
+		),
+		fmt.Sprintf(
+			"Something went wrong while downloading %d files.\nPlease refer to the logs for more details.",
+			p.total,
+		),
+	)
+}
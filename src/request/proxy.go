@@ -0,0 +1,108 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+var (
+	// globalProxy is the proxy set via the --proxy flag
+	// and will be used for all requests unless a platform proxy is set.
+	globalProxy *url.URL
+
+	// platformProxies maps a platform (e.g. utils.FANTIA) to its proxy
+	// which are configured in the program's config file.
+	platformProxies = map[string]*url.URL{}
+)
+
+// parseProxyUrl validates the given proxy URL string.
+//
+// Supported schemes are http, https, and socks5.
+func parseProxyUrl(rawUrl string) (*url.URL, error) {
+	proxyUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: invalid proxy URL, %q, more info => %v",
+			utils.INPUT_ERROR,
+			rawUrl,
+			err,
+		)
+	}
+
+	switch proxyUrl.Scheme {
+	case "http", "https", "socks5":
+		if proxyUrl.Host == "" {
+			break
+		}
+		return proxyUrl, nil
+	}
+	return nil, fmt.Errorf(
+		"error %d: invalid proxy URL, %q, expected format: \"http://host:port\", \"https://host:port\", or \"socks5://host:port\"",
+		utils.INPUT_ERROR,
+		rawUrl,
+	)
+}
+
+// SetGlobalProxy sets the proxy to be used for all requests
+func SetGlobalProxy(rawUrl string) error {
+	proxyUrl, err := parseProxyUrl(rawUrl)
+	if err != nil {
+		return err
+	}
+	globalProxy = proxyUrl
+	return nil
+}
+
+// SetPlatformProxy sets the proxy to be used for requests to the given platform.
+//
+// It takes precedence over the global proxy.
+func SetPlatformProxy(site, rawUrl string) error {
+	if !utils.IsKnownPlatform(site) {
+		return fmt.Errorf(
+			"error %d: unknown platform, %q, for proxy configuration",
+			utils.INPUT_ERROR,
+			site,
+		)
+	}
+
+	proxyUrl, err := parseProxyUrl(rawUrl)
+	if err != nil {
+		return err
+	}
+	platformProxies[site] = proxyUrl
+	return nil
+}
+
+// getPlatformFromUrl returns the platform of the given URL or an empty string if unknown
+func getPlatformFromUrl(reqUrl string) string {
+	parsedUrl, err := url.Parse(reqUrl)
+	if err != nil {
+		return ""
+	}
+	return utils.GetPlatformFromHost(parsedUrl.Hostname())
+}
+
+// getProxy returns the proxy to use for the given request URL, if any
+func getProxy(reqUrl string) *url.URL {
+	if len(platformProxies) > 0 {
+		if proxyUrl, ok := platformProxies[getPlatformFromUrl(reqUrl)]; ok {
+			return proxyUrl
+		}
+	}
+	return globalProxy
+}
+
+// getProxyFunc returns the proxy function for the http.Transport.
+//
+// The proxy is selected based on the initial request URL so that
+// redirects (e.g. to a CDN) will go through the same proxy.
+func getProxyFunc(reqUrl string) func(*http.Request) (*url.URL, error) {
+	proxyUrl := getProxy(reqUrl)
+	if proxyUrl == nil {
+		return nil
+	}
+	return http.ProxyURL(proxyUrl)
+}
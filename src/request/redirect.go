@@ -0,0 +1,73 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// The redirect status codes that will be treated as successful
+// responses when the redirects are not followed
+var redirectStatusCodes = map[int]bool{
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusSeeOther:          true,
+	http.StatusTemporaryRedirect: true,
+	http.StatusPermanentRedirect: true,
+}
+
+var followRedirects = true
+
+// SetFollowRedirects sets whether the redirects should be followed automatically.
+//
+// If disabled, the raw 3xx response will be returned instead which is useful for debugging.
+func SetFollowRedirects(follow bool) {
+	followRedirects = follow
+}
+
+// maxRedirects is the max number of redirects to follow per request
+var maxRedirects = utils.DEFAULT_MAX_REDIRECTS
+
+// SetMaxRedirects sets the max number of redirects to follow per request
+// before the request fails, e.g. due to a redirect loop. Set it to 0 to fail on any redirect.
+func SetMaxRedirects(max int) error {
+	if max < 0 {
+		return fmt.Errorf(
+			"error %d: max redirects cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			max,
+		)
+	}
+	maxRedirects = max
+	return nil
+}
+
+// Stops following the redirects once the max number of redirects has been exceeded
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) <= maxRedirects {
+		return nil
+	}
+	return fmt.Errorf(
+		"error %d: stopped after exceeding the max of %d redirect(s) which may be a redirect loop, "+
+			"started from %s and was last redirected to %s. Increase the \"--max_redirects\" limit if this is expected",
+		utils.CONNECTION_ERROR,
+		maxRedirects,
+		via[0].URL.String(),
+		req.URL.String(),
+	)
+}
+
+// Returns the raw 3xx response instead of following the redirect
+func noRedirect(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// Checks if the status code of the response is a successful one
+// where the redirect status codes are included if the redirects are not followed
+func isSuccessStatus(statusCode int) bool {
+	if statusCode == http.StatusOK {
+		return true
+	}
+	return !followRedirects && redirectStatusCodes[statusCode]
+}
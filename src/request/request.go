@@ -1,10 +1,13 @@
 package request
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"os"
 	"strings"
@@ -14,51 +17,84 @@ import (
 	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
 	"github.com/KJHJason/Cultured-Downloader-CLI/spinner"
 	"github.com/fatih/color"
+	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/http3"
 )
 
 // Get a new HTTP/2 or HTTP/3 client based on the request arguments
+//
+// Note: HTTP/3 does not support proxies, custom DNS servers, TLS pinning, and forcing an IP version,
+// hence HTTP/2 will be used if either of them are configured.
 func GetHttpClient(reqArgs *RequestArgs) *http.Client {
-	if reqArgs.Http2 {
+	if reqArgs.Http2 || requiresHttp2Transport(reqArgs) {
 		return &http.Client{
-			Transport: &http.Transport{
-				DisableCompression: reqArgs.DisableCompression,
-			},
+			Transport: wrapHarTransport(getSharedHttp2Transport(reqArgs)),
 		}
 	}
 	return &http.Client{
-		Transport: &http3.RoundTripper{
+		Transport: wrapHarTransport(&http3.RoundTripper{
 			DisableCompression: reqArgs.DisableCompression,
-		},
+			QuicConfig:         &quic.Config{
+				HandshakeIdleTimeout: utils.TLS_HANDSHAKE_TIMEOUT * time.Second,
+			},
+		}),
 	}
 }
 
-// add headers to the request
-func AddHeaders(headers map[string]string, defaultUserAgent string, req *http.Request) {
-	if len(headers) == 0 {
-		return
-	}
-
-	if userAgent, ok := headers["User-Agent"]; !ok || userAgent == ""{
-		headers["User-Agent"] = defaultUserAgent
-	}
+// Returns the cookies with the cookies of a platform, e.g. a ".pixiv.net" cookie, copied to all of the
+// platform's associated domains, e.g. ".pximg.net", so that the platform's CDNs also receive them.
+func expandPlatformCookies(cookies []*http.Cookie) []*http.Cookie {
+	expanded := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		site := utils.GetPlatformFromHost(cookie.Domain)
+		if cookie.Domain == "" || site == "" {
+			expanded = append(expanded, cookie)
+			continue
+		}
 
-	for key, value := range headers {
-		req.Header.Add(key, value)
+		for _, domain := range utils.GetPlatformDomains(site) {
+			domainCookie := *cookie
+			domainCookie.Domain = "." + domain
+			expanded = append(expanded, &domainCookie)
+		}
 	}
+	return expanded
 }
 
-// add cookies to the request
-func AddCookies(reqUrl string, cookies []*http.Cookie, req *http.Request) {
+// Returns a new cookie jar seeded with the given cookies
+// so that the cookies' domain and path will be matched according to
+// RFC 6265 for the request and any subsequent redirects.
+func NewCookieJar(reqUrl string, cookies []*http.Cookie) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
 	if len(cookies) == 0 {
-		return
+		return jar, nil
 	}
 
-	for _, cookie := range cookies {
-		if strings.Contains(reqUrl, cookie.Domain) {
-			req.AddCookie(cookie)
+	parsedReqUrl, err := url.Parse(reqUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cookie := range expandPlatformCookies(cookies) {
+		// the cookie has to be set on a URL that 
+		// domain-matches its domain for the cookie jar to accept it.
+		cookieUrl := &url.URL{
+			Scheme: "https",
+			Host:   strings.TrimPrefix(cookie.Domain, "."),
+			Path:   cookie.Path,
+		}
+		if cookie.Domain == "" {
+			cookieUrl.Host = parsedReqUrl.Host
 		}
+		if cookieUrl.Path == "" {
+			cookieUrl.Path = "/"
+		}
+		jar.SetCookies(cookieUrl, []*http.Cookie{cookie})
 	}
+	return jar, nil
 }
 
 // add params to the request
@@ -74,52 +110,152 @@ func AddParams(params map[string]string, req *http.Request) {
 	req.URL.RawQuery = query.Encode()
 }
 
+// StatusError is returned when the request failed due to the status code of the response
+type StatusError struct {
+	StatusCode int
+	Url        string
+	msg        string
+}
+
+func (e *StatusError) Error() string {
+	return e.msg
+}
+
+func (e *StatusError) LogFields() utils.LogFields {
+	return utils.LogFields{Url: e.Url, Status: e.StatusCode}
+}
+
 // send the request to the target URL and retries if the request was not successful
 func sendRequest(req *http.Request, reqArgs *RequestArgs) (*http.Response, error) {
-	AddCookies(reqArgs.Url, reqArgs.Cookies, req)
 	AddHeaders(reqArgs.Headers, reqArgs.UserAgent, req)
 	AddParams(reqArgs.Params, req)
 
-	var err error
-	var res *http.Response
+	cacheKey := getEtagCacheKey(req)
+	if cacheKey != "" {
+		addIfNoneMatch(cacheKey, req)
+	}
 
+	jar, err := NewCookieJar(reqArgs.Url, reqArgs.Cookies)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: failed to create cookie jar for %s, more info => %v",
+			utils.UNEXPECTED_ERROR,
+			reqArgs.Url,
+			err,
+		)
+	}
+
+	var res *http.Response
+	var nonRetryable bool
 	client := GetHttpClient(reqArgs)
+	client.Jar = jar
+	client.CheckRedirect = checkRedirect
+	if gatewayAuth != nil {
+		addBasicAuth(req)
+		client.CheckRedirect = checkAuthRedirect
+	}
+	if !followRedirects {
+		client.CheckRedirect = noRedirect
+	}
 	client.Timeout = time.Duration(reqArgs.Timeout) * time.Second
+	// the reason of the last failed attempt which is recorded once the retries have run out
+	var failReason string
 	for i := 1; i <= utils.RETRY_COUNTER; i++ {
-		res, err = client.Do(req)
+		if i > 1 && req.GetBody != nil {
+			// the body has been consumed by the previous attempt
+			if req.Body, err = req.GetBody(); err != nil {
+				break
+			}
+		}
+		if err = waitForHostCooldown(req.Context(), req.URL.Host); err != nil {
+			return nil, err
+		}
+		if err = waitForCrawlDelay(req); err != nil {
+			return nil, err
+		}
+		res, err = doWithHeaderTimeout(client, req)
+		if err == nil && cacheKey != "" {
+			res, err = handleEtagResponse(cacheKey, res)
+		}
 		if err == nil {
-			if !reqArgs.CheckStatus {
+			// truncated JSON responses are retried like the other failed requests
+			err = bufferJsonBody(req, res)
+		}
+		if err == nil {
+			retryable := isRetryStatus(res.StatusCode, reqArgs.CheckStatus)
+			if reqArgs.CheckStatus && (isSuccessStatus(res.StatusCode) || isPartialContentStatus(req, res.StatusCode)) {
+				reqBreaker.recordSuccess()
 				return res, nil
-			} else if res.StatusCode == 200 {
+			} else if !reqArgs.CheckStatus && (!retryable || i == utils.RETRY_COUNTER) {
+				// the unchecked status code is left for the caller to handle
+				// but only a 2xx response means that the session and connection are fine
+				if res.StatusCode >= 200 && res.StatusCode < 300 {
+					reqBreaker.recordSuccess()
+				} else if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+					reqBreaker.recordFailure(reqArgs.Url, res.Status+" response")
+				}
 				return res, nil
 			}
 			res.Body.Close()
+			failReason = res.Status + " response"
+			if isMissingStatus(res.StatusCode) {
+				// a missing file is not a sign of a broken session or connection
+				failReason = ""
+			}
+			recordHostError(req.URL.Host)
+			if !retryable {
+				nonRetryable = true
+				break
+			}
 		} else if errors.Is(err, context.Canceled) {
 			return nil, context.Canceled
+		} else if err == errResponseHeaderTimeout || errors.Is(err, errInvalidJson) {
+			failReason = err.Error()
+			recordHostError(req.URL.Host)
 		} else {
+			failReason = err.Error()
+			recordHostError(req.URL.Host)
 			break
 		}
 
 		if i < utils.RETRY_COUNTER {
-			time.Sleep(utils.GetRandomDelay())
+			recordRetry()
+			if reqArgs.OnRetry != nil {
+				reqArgs.OnRetry()
+			}
+			clock.Sleep(utils.GetRandomDelay())
 		}
 	}
 
+	if failReason != "" {
+		reqBreaker.recordFailure(reqArgs.Url, failReason)
+	}
+
 	errMsg := fmt.Sprintf(
 		"the request to %s failed after %d retries",
 		reqArgs.Url,
 		utils.RETRY_COUNTER,
 	)
+	if nonRetryable {
+		errMsg = fmt.Sprintf(
+			"the request to %s failed without retrying as the status code is not one of the \"--retry_on\" status codes",
+			reqArgs.Url,
+		)
+	}
 	if err != nil {
 		err = fmt.Errorf("%s, more info => %v",
 			errMsg,
 			err,
 		)
 	} else if res != nil {
-		err = fmt.Errorf("%s, status code => %s",
-			errMsg,
-			res.Status,
-		)
+		err = &StatusError{
+			StatusCode: res.StatusCode,
+			Url:        reqArgs.Url,
+			msg: fmt.Sprintf("%s, status code => %s",
+				errMsg,
+				res.Status,
+			),
+		}
 	} else {
 		err = errors.New(errMsg)
 	}
@@ -331,3 +467,44 @@ func CallRequestWithData(reqArgs *RequestArgs, data map[string]string) (*http.Re
 
 	return sendRequest(req, reqArgs)
 }
+
+// Sends a request with the given body and sets the Content-Type header to the content type if not empty.
+//
+// The body is read into memory so that it can be sent again when the request is retried.
+func CallRequestWithBody(reqArgs *RequestArgs, body io.Reader, contentType string) (*http.Response, error) {
+	reqArgs.ValidateArgs()
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		if bodyBytes, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf(
+				"error %d: failed to read the request body for %s, more info => %v",
+				utils.UNEXPECTED_ERROR,
+				reqArgs.Url,
+				err,
+			)
+		}
+	}
+	if contentType != "" {
+		reqArgs.Headers["Content-Type"] = contentType
+	}
+
+	req, err := http.NewRequestWithContext(
+		reqArgs.Context,
+		reqArgs.Method,
+		reqArgs.Url,
+		bytes.NewReader(bodyBytes),
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error %d: unable to create a new request, more info => %v",
+			utils.DEV_ERROR,
+			err,
+		)
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(bodyBytes)), nil
+	}
+
+	return sendRequest(req, reqArgs)
+}
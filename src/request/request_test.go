@@ -0,0 +1,129 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+func TestNewCookieJarDomainMatching(t *testing.T) {
+	tests := []struct {
+		name         string
+		cookieDomain string
+		host         string
+		want         bool
+	}{
+		{name: "exact host", cookieDomain: "example.com", host: "example.com", want: true},
+		{name: "subdomain of a domain cookie", cookieDomain: "example.com", host: "www.example.com", want: true},
+		{name: "leading dot matches the domain", cookieDomain: ".fanbox.cc", host: "fanbox.cc", want: true},
+		{name: "leading dot matches a subdomain", cookieDomain: ".fanbox.cc", host: "downloads.fanbox.cc", want: true},
+		{name: "look-alike suffix", cookieDomain: ".fanbox.cc", host: "evilfanbox.cc", want: false},
+		{name: "look-alike suffix without a dot", cookieDomain: "example.com", host: "evilexample.com", want: false},
+		{name: "domain as a subdomain of another host", cookieDomain: "example.com", host: "example.com.attacker.net", want: false},
+		{name: "parent of the cookie domain", cookieDomain: "www.example.com", host: "example.com", want: false},
+		{name: "platform cookie sent to its CDN", cookieDomain: ".pixiv.net", host: "i.pximg.net", want: true},
+		{name: "host-only cookie on the request host", cookieDomain: "", host: "api.example.com", want: true},
+		{name: "host-only cookie on a subdomain", cookieDomain: "", host: "www.api.example.com", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cookie := &http.Cookie{Name: "session", Value: "secret", Domain: test.cookieDomain}
+			jar, err := NewCookieJar("https://api.example.com/posts", []*http.Cookie{cookie})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := len(jar.Cookies(&url.URL{Scheme: "https", Host: test.host, Path: "/"})) > 0
+			if got != test.want {
+				t.Errorf("cookie with domain %q sent to %q = %v, want %v", test.cookieDomain, test.host, got, test.want)
+			}
+		})
+	}
+}
+
+// Routes all the requests through a test server acting as an HTTP proxy
+// so that the requests can be sent to made up hostnames like "www.fanbox.test".
+func useTestProxy(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	if err := SetGlobalProxy(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		globalProxy = nil
+		sharedTransportsMu.Lock()
+		defer sharedTransportsMu.Unlock()
+		for key, transport := range sharedTransports {
+			transport.CloseIdleConnections()
+			delete(sharedTransports, key)
+		}
+	})
+}
+
+func TestCookiesAcrossRedirects(t *testing.T) {
+	var mu sync.Mutex
+	receivedCookies := map[string]string{}
+	useTestProxy(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if cookie, err := r.Cookie("session"); err == nil {
+			receivedCookies[r.URL.Host] = cookie.Value
+		} else {
+			receivedCookies[r.URL.Host] = ""
+		}
+		mu.Unlock()
+
+		switch r.URL.Host + r.URL.Path {
+		case "fanbox.test/subdomain":
+			http.Redirect(w, r, "http://downloads.fanbox.test/file", http.StatusFound)
+		case "fanbox.test/foreign":
+			http.Redirect(w, r, "http://attacker.test/file", http.StatusFound)
+		default:
+			w.Write([]byte("file content"))
+		}
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		targetHost string
+		wantCookie string
+	}{
+		{name: "redirect to a subdomain", path: "/subdomain", targetHost: "downloads.fanbox.test", wantCookie: "secret"},
+		{name: "redirect to a foreign host", path: "/foreign", targetHost: "attacker.test", wantCookie: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := CallRequest(&RequestArgs{
+				Url:            "http://fanbox.test" + test.path,
+				Method:         "GET",
+				Timeout:        10,
+				Http2:          true,
+				CheckStatus:    true,
+				Cookies:        []*http.Cookie{{Name: "session", Value: "secret", Domain: ".fanbox.test"}},
+				RequestHandler: CallRequest,
+			})
+			if err != nil {
+				t.Fatalf("CallRequest() error = %v", err)
+			}
+			res.Body.Close()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if got := receivedCookies["fanbox.test"]; got != "secret" {
+				t.Errorf("cookie sent to the origin = %q, want %q", got, "secret")
+			}
+			got, ok := receivedCookies[test.targetHost]
+			if !ok {
+				t.Fatalf("the redirect to %s was not followed", test.targetHost)
+			}
+			if got != test.wantCookie {
+				t.Errorf("cookie sent to %s = %q, want %q", test.targetHost, got, test.wantCookie)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Number of completed downloads after which the resume state will be written to the state file
+// so that a run that is killed or crashes mid-post can still be resumed from the last checkpoint.
+const resumeCheckpointInterval = 10
+
+// Returns the path of the resume state file in the app's config directory
+func getResumeStateFilePath() string {
+	return filepath.Join(utils.APP_PATH, "resume_state.json")
+}
+
+// resumeState keeps track of the completed downloads of a run
+// so that an interrupted run with the same arguments can be resumed.
+//
+// The downloads are keyed by their file path instead of the URL as the download URLs
+// of some platforms like Fantia and Pixiv Fanbox are signed and change on every run, see getResumeKey.
+type resumeState struct {
+	mu        sync.Mutex
+	RunKey    string          `json:"run_key"`
+	Completed map[string]bool `json:"completed"`
+	changed   bool
+	unsaved   int // number of completed downloads since the last save
+}
+
+var (
+	// runState is nil if resuming is not enabled
+	runState     *resumeState
+	resumedCount atomic.Int64
+)
+
+// EnableResume loads the resume state file from the app's config directory
+// so that the downloads completed by a previous run with the same run key will be skipped.
+//
+// The run key should identify the arguments of the run, e.g. the command and its flags,
+// where a state file for a different run key will be replaced.
+func EnableResume(runKey string) error {
+	state := &resumeState{
+		RunKey:    runKey,
+		Completed: make(map[string]bool),
+	}
+
+	stateFilePath := getResumeStateFilePath()
+	data, err := os.ReadFile(stateFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf(
+			"error %d: failed to read the resume state file at %s, more info => %v",
+			utils.OS_ERROR,
+			stateFilePath,
+			err,
+		)
+	}
+	if len(data) > 0 {
+		var prevState resumeState
+		if err := json.Unmarshal(data, &prevState); err != nil {
+			utils.LogError(
+				err,
+				fmt.Sprintf("the resume state file at %s is corrupted and will be reset", stateFilePath),
+				false,
+				utils.ERROR,
+			)
+		} else if prevState.RunKey == runKey && prevState.Completed != nil {
+			state.Completed = prevState.Completed
+		}
+	}
+
+	runState = state
+	return nil
+}
+
+// Returns the key of the download in the resume state which is its file path if the filename is known
+// or the directory with the URL without its query string, i.e. its signature, otherwise.
+func getResumeKey(urlInfo *ToDownload) string {
+	if filepath.Ext(urlInfo.FilePath) != "" {
+		return addFilenamePrefix(utils.NormaliseFileExt(urlInfo.FilePath), urlInfo.FilenamePrefix)
+	}
+
+	stableUrl := urlInfo.Url
+	if parsedUrl, err := url.Parse(urlInfo.Url); err == nil {
+		parsedUrl.RawQuery = ""
+		parsedUrl.Fragment = ""
+		stableUrl = parsedUrl.String()
+	}
+	return urlInfo.FilePath + "|" + urlInfo.FilenamePrefix + "|" + stableUrl
+}
+
+// Removes the downloads that were completed by the previous run if resuming is enabled
+// where the number of removed downloads can be retrieved with ResumedCount.
+func filterCompleted(urlInfoSlice []*ToDownload) []*ToDownload {
+	if runState == nil {
+		return urlInfoSlice
+	}
+
+	runState.mu.Lock()
+	defer runState.mu.Unlock()
+	remaining := make([]*ToDownload, 0, len(urlInfoSlice))
+	for _, urlInfo := range urlInfoSlice {
+		if runState.Completed[getResumeKey(urlInfo)] {
+			resumedCount.Add(1)
+			continue
+		}
+		remaining = append(remaining, urlInfo)
+	}
+	return remaining
+}
+
+// Records the download as completed if resuming is enabled.
+//
+// Since the completion is recorded per file, a post that was interrupted halfway will resume
+// from its first incomplete file without re-checking the files that were already downloaded.
+func markCompleted(urlInfo *ToDownload) {
+	if runState == nil {
+		return
+	}
+
+	key := getResumeKey(urlInfo)
+	runState.mu.Lock()
+	defer runState.mu.Unlock()
+	if runState.Completed[key] {
+		return
+	}
+
+	runState.Completed[key] = true
+	runState.changed = true
+	runState.unsaved++
+	if runState.unsaved < resumeCheckpointInterval {
+		return
+	}
+	if err := runState.save(); err != nil {
+		utils.LogError(err, "", false, utils.ERROR)
+	}
+}
+
+// ResumedCount returns the number of downloads skipped as they were completed by the previous run
+func ResumedCount() int64 {
+	return resumedCount.Load()
+}
+
+// SaveResumeState writes the resume state to the app's config directory if it has changed
+func SaveResumeState() error {
+	if runState == nil {
+		return nil
+	}
+
+	runState.mu.Lock()
+	defer runState.mu.Unlock()
+	return runState.save()
+}
+
+// Writes the resume state to the state file if it has changed.
+//
+// The state is written to a temporary file first so that the previous
+// checkpoint is kept intact if the program is killed while writing.
+//
+// Note: The caller must hold the lock.
+func (state *resumeState) save() error {
+	if !state.changed {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal the resume state, more info => %v",
+			utils.JSON_ERROR,
+			err,
+		)
+	}
+
+	os.MkdirAll(utils.APP_PATH, 0700)
+	stateFilePath := getResumeStateFilePath()
+	tmpFilePath := stateFilePath + ".tmp"
+	if err := os.WriteFile(tmpFilePath, data, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the resume state file to %s, more info => %v",
+			utils.OS_ERROR,
+			tmpFilePath,
+			err,
+		)
+	}
+	if err := os.Rename(tmpFilePath, stateFilePath); err != nil {
+		os.Remove(tmpFilePath)
+		return fmt.Errorf(
+			"error %d: failed to replace the resume state file at %s, more info => %v",
+			utils.OS_ERROR,
+			stateFilePath,
+			err,
+		)
+	}
+	state.changed = false
+	state.unsaved = 0
+	return nil
+}
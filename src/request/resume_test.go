@@ -0,0 +1,155 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Enables resuming with a state file in a temporary app directory for the test
+func useResumeState(t *testing.T, runKey string) {
+	t.Helper()
+	appPath := utils.APP_PATH
+	utils.APP_PATH = t.TempDir()
+	t.Cleanup(func() {
+		utils.APP_PATH = appPath
+		runState = nil
+		resumedCount.Store(0)
+	})
+	if err := EnableResume(runKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResumeSkipsCompletedDownloads(t *testing.T) {
+	useFakeClock(t)
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("file content"))
+	}))
+	defer srv.Close()
+
+	dlDir := t.TempDir()
+	getFiles := func(failLast bool) []*ToDownload {
+		var files []*ToDownload
+		for i := 0; i < 5; i++ {
+			fileUrl := fmt.Sprintf("%s/file%d.bin", srv.URL, i)
+			if failLast && i == 4 {
+				fileUrl += "?fail=1"
+			}
+			files = append(files, &ToDownload{
+				Url:      fileUrl,
+				FilePath: filepath.Join(dlDir, fmt.Sprintf("file%d.bin", i)),
+			})
+		}
+		return files
+	}
+	dlOptions := &DlOptions{MaxConcurrency: 2}
+
+	// the first run fails on the last file
+	useResumeState(t, "run")
+	DownloadUrlsWithHandler(getFiles(true), dlOptions, &configs.Config{}, CallRequest)
+	if err := SaveResumeState(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the resumed run only sends the requests of the failed file
+	if err := EnableResume("run"); err != nil {
+		t.Fatal(err)
+	}
+	firstRunRequests := requests.Load()
+	DownloadUrlsWithHandler(getFiles(false), dlOptions, &configs.Config{}, CallRequest)
+	if got := ResumedCount(); got != 4 {
+		t.Errorf("ResumedCount() = %d, want 4", got)
+	}
+	if got := requests.Load() - firstRunRequests; got != 2 {
+		t.Errorf("resumed run sent %d requests, want 2 (HEAD and GET of the failed file)", got)
+	}
+}
+
+func TestResumeIgnoresSignedUrls(t *testing.T) {
+	useFakeClock(t)
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(r.URL.Path)))
+		w.Write([]byte("file content"))
+	}))
+	defer srv.Close()
+
+	dlDir := t.TempDir()
+	getFiles := func(signature string) []*ToDownload {
+		return []*ToDownload{
+			{
+				// the filename is known beforehand
+				Url:      fmt.Sprintf("%s/image.jpg?Signature=%s", srv.URL, signature),
+				FilePath: filepath.Join(dlDir, "image.jpg"),
+			},
+			{
+				// the filename is resolved from the response
+				Url:      fmt.Sprintf("%s/attachment.zip?Signature=%s", srv.URL, signature),
+				FilePath: dlDir,
+			},
+		}
+	}
+	dlOptions := &DlOptions{MaxConcurrency: 2}
+
+	useResumeState(t, "run")
+	DownloadUrlsWithHandler(getFiles("first"), dlOptions, &configs.Config{}, CallRequest)
+	if err := SaveResumeState(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the URLs are signed again on the next run
+	if err := EnableResume("run"); err != nil {
+		t.Fatal(err)
+	}
+	firstRunRequests := requests.Load()
+	DownloadUrlsWithHandler(getFiles("second"), dlOptions, &configs.Config{}, CallRequest)
+	if got := ResumedCount(); got != 2 {
+		t.Errorf("ResumedCount() = %d, want 2", got)
+	}
+	if got := requests.Load() - firstRunRequests; got != 0 {
+		t.Errorf("resumed run sent %d requests, want 0", got)
+	}
+}
+
+// Measures the reconciliation of a large plan against the resume state which
+// replaces the HEAD request that every completed file would otherwise need.
+func BenchmarkFilterCompleted(b *testing.B) {
+	const planSize = 10000
+	plan := make([]*ToDownload, planSize)
+	completed := make(map[string]bool, planSize)
+	for i := range plan {
+		plan[i] = &ToDownload{Url: fmt.Sprintf("https://example.com/file%d.bin", i)}
+		if i%10 != 0 {
+			completed[getResumeKey(plan[i])] = true
+		}
+	}
+	runState = &resumeState{Completed: completed}
+	defer func() {
+		runState = nil
+		resumedCount.Store(0)
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if remaining := filterCompleted(plan); len(remaining) != planSize/10 {
+			b.Fatalf("filterCompleted() kept %d files, want %d", len(remaining), planSize/10)
+		}
+	}
+}
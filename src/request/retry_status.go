@@ -0,0 +1,50 @@
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// retryStatusCodes overrides the built-in status codes to retry if set
+var retryStatusCodes map[int]bool
+
+// SetRetryStatusCodes sets the comma-separated status codes, e.g. "429,500,502,503,520,522",
+// that should trigger a retry, overriding the built-in classification where every unsuccessful
+// status code is retried if the status code is checked. Set it to empty to use the built-in classification.
+//
+// The given status codes will be retried even if the status code of the request is not checked
+// while the other unsuccessful status codes will fail right away without being retried.
+func SetRetryStatusCodes(codesStr string) error {
+	codesStr = strings.TrimSpace(codesStr)
+	if codesStr == "" {
+		retryStatusCodes = nil
+		return nil
+	}
+
+	codes := make(map[int]bool)
+	for _, codeStr := range strings.Split(codesStr, ",") {
+		codeStr = strings.TrimSpace(codeStr)
+		code, err := strconv.Atoi(codeStr)
+		if err != nil || code < 400 || code > 599 {
+			return fmt.Errorf(
+				"error %d: invalid status code to retry on, %q, expected a status code from 400 to 599, e.g. \"429,500,502,503\"",
+				utils.INPUT_ERROR,
+				codeStr,
+			)
+		}
+		codes[code] = true
+	}
+	retryStatusCodes = codes
+	return nil
+}
+
+// Checks if the request should be retried based on the status code of its unsuccessful response
+func isRetryStatus(statusCode int, checkStatus bool) bool {
+	if retryStatusCodes != nil {
+		return retryStatusCodes[statusCode]
+	}
+	return checkStatus && !isSuccessStatus(statusCode)
+}
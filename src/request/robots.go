@@ -0,0 +1,167 @@
+package request
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Max size of the robots.txt file that will be parsed
+const maxRobotsTxtSize = 512 * 1024
+
+// robotsHost is the parsed crawl delay of a host's robots.txt
+// and the time the next request to the host can be sent at.
+type robotsHost struct {
+	once  sync.Once
+	delay time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+var (
+	// respectRobots is whether the crawl delay of the hosts' robots.txt should be respected
+	respectRobots bool
+
+	robotsHostsMu sync.Mutex
+	robotsHosts   = make(map[string]*robotsHost)
+)
+
+// SetRespectRobots sets whether the "Crawl-delay" directive of the hosts' robots.txt should be respected
+// where the specified delay will be inserted between the requests to the same host.
+//
+// The robots.txt of each host is only fetched once per run.
+func SetRespectRobots(respect bool) {
+	respectRobots = respect
+}
+
+// Returns the cached robots.txt info of the host, creating it if it does not exist
+func getRobotsHost(host string) *robotsHost {
+	robotsHostsMu.Lock()
+	defer robotsHostsMu.Unlock()
+	robots, ok := robotsHosts[host]
+	if !ok {
+		robots = &robotsHost{}
+		robotsHosts[host] = robots
+	}
+	return robots
+}
+
+// Parses the crawl delay from the robots.txt for the user agent where the group for the user agent
+// takes precedence over the group for all user agents, "*". Returns 0 if there is no crawl delay.
+func parseCrawlDelay(robotsTxt io.Reader, userAgent string) time.Duration {
+	userAgent = strings.ToLower(userAgent)
+	var agentDelay, wildcardDelay time.Duration
+	var groupAgents []string
+	inRules := false
+	scanner := bufio.NewScanner(robotsTxt)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				// a new group starts after the rules of the previous group
+				groupAgents = nil
+				inRules = false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+		case "crawl-delay":
+			inRules = true
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds <= 0 {
+				continue
+			}
+			delay := time.Duration(seconds * float64(time.Second))
+			for _, agent := range groupAgents {
+				if agent == "*" {
+					wildcardDelay = delay
+				} else if agent != "" && strings.Contains(userAgent, agent) {
+					agentDelay = delay
+				}
+			}
+		default:
+			inRules = true
+		}
+	}
+
+	if agentDelay > 0 {
+		return agentDelay
+	}
+	return wildcardDelay
+}
+
+// Fetches the robots.txt of the request's host and returns its crawl delay, 0 if it could not be fetched
+func fetchCrawlDelay(req *http.Request) time.Duration {
+	robotsUrl := req.URL.Scheme + "://" + req.URL.Host + "/robots.txt"
+	robotsReqArgs := &RequestArgs{
+		Url:     robotsUrl,
+		Timeout: 10,
+		Http2:   true,
+	}
+	client := GetHttpClient(robotsReqArgs)
+	client.Timeout = 10 * time.Second
+
+	robotsReq, err := http.NewRequestWithContext(req.Context(), "GET", robotsUrl, nil)
+	if err != nil {
+		return 0
+	}
+	userAgent := req.Header.Get("User-Agent")
+	robotsReq.Header.Set("User-Agent", userAgent)
+	res, err := client.Do(robotsReq)
+	if err != nil {
+		return 0
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return 0
+	}
+	return parseCrawlDelay(io.LimitReader(res.Body, maxRobotsTxtSize), userAgent)
+}
+
+// Waits for the crawl delay of the request's host since the previous request to it, if respecting robots.txt,
+// or until the request's context is cancelled.
+func waitForCrawlDelay(req *http.Request) error {
+	if !respectRobots {
+		return nil
+	}
+
+	robots := getRobotsHost(req.URL.Host)
+	robots.once.Do(func() {
+		robots.delay = fetchCrawlDelay(req)
+	})
+	if robots.delay == 0 {
+		return nil
+	}
+
+	// reserve the next slot of the host so that the concurrent requests are spaced out by the delay
+	robots.mu.Lock()
+	now := clock.Now()
+	sendAt := robots.next
+	if sendAt.Before(now) {
+		sendAt = now
+	}
+	robots.next = sendAt.Add(robots.delay)
+	robots.mu.Unlock()
+
+	wait := sendAt.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-clock.After(wait):
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
@@ -0,0 +1,530 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const (
+	// S3 requires each part of a multipart upload, except the last, to be at least 5MiB
+	s3PartSize       = 8 * 1024 * 1024
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3DefaultRegion  = "us-east-1"
+	s3SigningAlgo    = "AWS4-HMAC-SHA256"
+	s3AmzDateFormat  = "20060102T150405Z"
+	s3DateOnlyFormat = "20060102"
+)
+
+// s3Storage streams the downloaded files to an S3-compatible bucket using path-style requests.
+//
+// The credentials are read from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and the optional
+// AWS_SESSION_TOKEN environment variables while the region and the endpoint of S3-compatible services
+// can be configured with the AWS_REGION and AWS_ENDPOINT_URL environment variables respectively.
+type s3Storage struct {
+	bucket       string
+	prefix       string
+	endpoint     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+}
+
+func newS3Storage(bucket, prefix string) (*s3Storage, error) {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf(
+			"error %d: the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables are required for the S3 storage",
+			utils.INPUT_ERROR,
+		)
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_ENDPOINT_URL"), "/")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	return &s3Storage{
+		bucket:       bucket,
+		prefix:       prefix,
+		endpoint:     endpoint,
+		region:       region,
+		accessKey:    accessKey,
+		secretKey:    secretKey,
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client: &http.Client{
+			Transport: getHttp2Transport(&RequestArgs{Url: endpoint}),
+		},
+	}, nil
+}
+
+// URI-encodes the string according to the AWS Signature Version 4 rules
+func s3UriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || (b == '/' && !encodeSlash) {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// Returns the object key of the file path relative to the download directory
+func (s *s3Storage) getKey(filePath string) string {
+	key := filePath
+	if relPath, err := filepath.Rel(utils.DOWNLOAD_PATH, filePath); err == nil && !strings.HasPrefix(relPath, "..") {
+		key = relPath
+	}
+	key = strings.TrimLeft(filepath.ToSlash(key), "/")
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	return key
+}
+
+// Creates a new request to the object of the key signed with AWS Signature Version 4
+func (s *s3Storage) newRequest(method, key string, query map[string]string, body io.Reader) (*http.Request, error) {
+	reqUrl := s.endpoint + "/" + s3UriEncode(s.bucket, true) + "/" + s3UriEncode(key, false)
+	if len(query) > 0 {
+		queryValues := make(url.Values, len(query))
+		for queryKey, value := range query {
+			queryValues.Set(queryKey, value)
+		}
+		reqUrl += "?" + queryValues.Encode()
+	}
+	req, err := http.NewRequest(method, reqUrl, body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	req.Header.Set("x-amz-date", now.Format(s3AmzDateFormat))
+	req.Header.Set("x-amz-content-sha256", s3UnsignedBody)
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if s.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.sessionToken)
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+	}
+	signS3Request(req, signedHeaders, s3UnsignedBody, s.accessKey, s.secretKey, s.region, now)
+	return req, nil
+}
+
+// Signs the request with AWS Signature Version 4 by setting its "Authorization" header.
+//
+// The signed headers are the lowercase names of the request's headers to sign, including "host",
+// and the payload hash is the hex-encoded SHA-256 hash of the body or "UNSIGNED-PAYLOAD".
+func signS3Request(req *http.Request, signedHeaders []string, payloadHash, accessKey, secretKey, region string, now time.Time) {
+	canonicalUri := s3UriEncode(req.URL.Path, false)
+
+	query := req.URL.Query()
+	queryKeys := make([]string, 0, len(query))
+	for queryKey := range query {
+		queryKeys = append(queryKeys, queryKey)
+	}
+	sort.Strings(queryKeys)
+	queryParts := make([]string, 0, len(queryKeys))
+	for _, queryKey := range queryKeys {
+		queryParts = append(queryParts, s3UriEncode(queryKey, true)+"="+s3UriEncode(query.Get(queryKey), true))
+	}
+	canonicalQuery := strings.Join(queryParts, "&")
+
+	signedHeaders = append([]string(nil), signedHeaders...)
+	sort.Strings(signedHeaders)
+	var canonicalHeaders strings.Builder
+	for _, header := range signedHeaders {
+		value := req.Header.Get(header)
+		if header == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(header + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonicalReq := strings.Join([]string{
+		req.Method,
+		canonicalUri,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+	canonicalReqHash := sha256.Sum256([]byte(canonicalReq))
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format(s3DateOnlyFormat), region)
+	stringToSign := strings.Join([]string{
+		s3SigningAlgo,
+		now.Format(s3AmzDateFormat),
+		scope,
+		hex.EncodeToString(canonicalReqHash[:]),
+	}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+secretKey), now.Format(s3DateOnlyFormat))
+	signingKey = hmacSha256(signingKey, region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	req.Header.Set(
+		"Authorization",
+		fmt.Sprintf(
+			"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			s3SigningAlgo,
+			accessKey,
+			scope,
+			strings.Join(signedHeaders, ";"),
+			signature,
+		),
+	)
+}
+
+// Sends the request and returns an error if the response status code is not 2xx
+func (s *s3Storage) do(req *http.Request) (*http.Response, error) {
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, fmt.Errorf(
+			"s3 error %d: %s %s failed due to a %s response\nBody: %s",
+			utils.RESPONSE_ERROR,
+			req.Method,
+			req.URL.Path,
+			res.Status,
+			string(body),
+		)
+	}
+	return res, nil
+}
+
+// Uploads the body to the object of the key in a single request
+func (s *s3Storage) putObject(key string, body io.Reader, size int64) error {
+	req, err := s.newRequest("PUT", key, nil, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// Sends a HEAD request for the object of the file path and returns
+// the response or os.ErrNotExist if the object does not exist.
+func (s *s3Storage) head(filePath string) (*http.Response, error) {
+	req, err := s.newRequest("HEAD", s.getKey(filePath), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	// S3 responds with 403 instead of 404 for a missing object
+	// if the credentials lack the s3:ListBucket permission.
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusForbidden {
+		return nil, os.ErrNotExist
+	} else if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"s3 error %d: failed to get the metadata of %s due to a %s response",
+			utils.RESPONSE_ERROR,
+			req.URL.Path,
+			res.Status,
+		)
+	}
+	return res, nil
+}
+
+func (s *s3Storage) Size(filePath string) (int64, error) {
+	res, err := s.head(filePath)
+	if err != nil {
+		return -1, err
+	}
+	return res.ContentLength, nil
+}
+
+func (s *s3Storage) ModTime(filePath string) (time.Time, error) {
+	res, err := s.head(filePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return http.ParseTime(res.Header.Get("Last-Modified"))
+}
+
+// Open returns the body of a GET request for the object of the file path
+func (s *s3Storage) Open(filePath string) (io.ReadCloser, error) {
+	req, err := s.newRequest("GET", s.getKey(filePath), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (s *s3Storage) Exists(filePath string) bool {
+	_, err := s.Size(filePath)
+	return err == nil
+}
+
+// Rename copies the object to the dest key and deletes the src object as S3 has no rename operation
+func (s *s3Storage) Rename(src, dest string) error {
+	srcKey := s.getKey(src)
+	req, err := s.newRequest("PUT", s.getKey(dest), nil, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(
+		"x-amz-copy-source",
+		"/"+s3UriEncode(s.bucket, true)+"/"+s3UriEncode(srcKey, false),
+	)
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return s.Remove(src)
+}
+
+func (s *s3Storage) Remove(filePath string) error {
+	req, err := s.newRequest("DELETE", s.getKey(filePath), nil, nil)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// MkdirAll is a no-op as S3 has no directories
+func (s *s3Storage) MkdirAll(dirPath string) error {
+	return nil
+}
+
+// Returns a writer that streams the data to the object.
+//
+// If the size is known, the data is streamed in a single PUT request.
+// Otherwise, a multipart upload is used where each part is buffered in memory.
+func (s *s3Storage) Create(filePath string, size int64) (FileWriter, error) {
+	key := s.getKey(filePath)
+	if size < 0 {
+		return &s3MultipartWriter{
+			storage: s,
+			key:     key,
+		}, nil
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	writer := &s3StreamWriter{
+		pipeWriter: pipeWriter,
+		done:       make(chan error, 1),
+	}
+	go func() {
+		err := s.putObject(key, pipeReader, size)
+		pipeReader.CloseWithError(err)
+		writer.done <- err
+	}()
+	return writer, nil
+}
+
+// s3StreamWriter streams the data to a single PUT request via a pipe
+type s3StreamWriter struct {
+	pipeWriter *io.PipeWriter
+	done       chan error
+}
+
+func (w *s3StreamWriter) Write(p []byte) (int, error) {
+	return w.pipeWriter.Write(p)
+}
+
+func (w *s3StreamWriter) Commit() error {
+	w.pipeWriter.Close()
+	return <-w.done
+}
+
+func (w *s3StreamWriter) Abort() error {
+	w.pipeWriter.CloseWithError(errors.New("s3 upload aborted"))
+	<-w.done
+	return nil
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name           `xml:"CompleteMultipartUpload"`
+	Parts   []*s3CompletedPart `xml:"Part"`
+}
+
+// s3MultipartWriter uploads the data in parts when the size of the file is unknown
+type s3MultipartWriter struct {
+	storage  *s3Storage
+	key      string
+	uploadId string
+	buf      bytes.Buffer
+	parts    []*s3CompletedPart
+}
+
+func (w *s3MultipartWriter) startUpload() error {
+	req, err := w.storage.newRequest("POST", w.key, map[string]string{"uploads": ""}, nil)
+	if err != nil {
+		return err
+	}
+	res, err := w.storage.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var resXml struct {
+		UploadId string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(res.Body).Decode(&resXml); err != nil {
+		return fmt.Errorf(
+			"s3 error %d: failed to parse the multipart upload response for %s, more info => %v",
+			utils.RESPONSE_ERROR,
+			w.key,
+			err,
+		)
+	}
+	w.uploadId = resXml.UploadId
+	return nil
+}
+
+// Uploads the buffered data as the next part of the multipart upload
+func (w *s3MultipartWriter) uploadPart() error {
+	if w.uploadId == "" {
+		if err := w.startUpload(); err != nil {
+			return err
+		}
+	}
+
+	partNumber := len(w.parts) + 1
+	req, err := w.storage.newRequest(
+		"PUT",
+		w.key,
+		map[string]string{
+			"partNumber": fmt.Sprintf("%d", partNumber),
+			"uploadId":   w.uploadId,
+		},
+		bytes.NewReader(w.buf.Bytes()),
+	)
+	if err != nil {
+		return err
+	}
+	res, err := w.storage.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	w.parts = append(w.parts, &s3CompletedPart{
+		PartNumber: partNumber,
+		ETag:       res.Header.Get("ETag"),
+	})
+	w.buf.Reset()
+	return nil
+}
+
+func (w *s3MultipartWriter) Write(p []byte) (int, error) {
+	n, _ := w.buf.Write(p)
+	if w.buf.Len() >= s3PartSize {
+		if err := w.uploadPart(); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+func (w *s3MultipartWriter) Commit() error {
+	// small files that fit in a single part are uploaded in a single request
+	if w.uploadId == "" {
+		return w.storage.putObject(w.key, bytes.NewReader(w.buf.Bytes()), int64(w.buf.Len()))
+	}
+
+	if w.buf.Len() > 0 {
+		if err := w.uploadPart(); err != nil {
+			return err
+		}
+	}
+
+	completeXml, err := xml.Marshal(&s3CompleteMultipartUpload{Parts: w.parts})
+	if err != nil {
+		return err
+	}
+	req, err := w.storage.newRequest(
+		"POST",
+		w.key,
+		map[string]string{"uploadId": w.uploadId},
+		bytes.NewReader(completeXml),
+	)
+	if err != nil {
+		return err
+	}
+	res, err := w.storage.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (w *s3MultipartWriter) Abort() error {
+	w.buf.Reset()
+	if w.uploadId == "" {
+		return nil
+	}
+
+	req, err := w.storage.newRequest("DELETE", w.key, map[string]string{"uploadId": w.uploadId}, nil)
+	if err != nil {
+		return err
+	}
+	res, err := w.storage.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
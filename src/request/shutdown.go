@@ -0,0 +1,83 @@
+package request
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+var (
+	// shuttingDown is set once an interrupt signal has been received so that no new downloads will be started
+	shuttingDown     atomic.Bool
+	shutdownListener sync.Once
+
+	// inFlight tracks the downloads that have been started so that the shutdown can wait for them to stop.
+	// The mutex ensures that no download is added to the wait group once the shutdown has started to wait.
+	inFlightMu sync.Mutex
+	inFlight   sync.WaitGroup
+)
+
+// Tracks a download that is about to be started until the returned function is called.
+//
+// Returns false if the program is shutting down and the download should not be started.
+func trackInFlight() (func(), bool) {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	if IsShuttingDown() {
+		return nil, false
+	}
+	inFlight.Add(1)
+	return inFlight.Done, true
+}
+
+// Returns a channel that is closed once all the in-flight downloads have stopped
+// after no new downloads can be started.
+func waitForInFlight() <-chan struct{} {
+	inFlightMu.Lock()
+	shuttingDown.Store(true)
+	inFlightMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// HandleShutdownSignals listens for SIGINT/SIGTERM signals to gracefully shut down the program.
+//
+// On the first signal, no new downloads will be started and the in-flight downloads will be aborted.
+// The program will then wait for the in-flight downloads to stop, up to SHUTDOWN_GRACE_PERIOD seconds,
+// before flushing the state to disk via the shutdown hooks and exiting.
+// On the second signal, the program will exit immediately.
+func HandleShutdownSignals() {
+	shutdownListener.Do(func() {
+		sigs := make(chan os.Signal, 2)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigs
+			stopped := waitForInFlight()
+			color.Yellow("\nShutting down gracefully... (press Ctrl+C again to force quit)")
+
+			select {
+			case <-sigs:
+				os.Exit(2)
+			case <-stopped:
+			case <-time.After(utils.SHUTDOWN_GRACE_PERIOD * time.Second):
+			}
+			utils.ExitGracefully(2)
+		}()
+	})
+}
+
+// IsShuttingDown returns true if the program is shutting down due to an interrupt signal
+func IsShuttingDown() bool {
+	return shuttingDown.Load()
+}
@@ -0,0 +1,32 @@
+package request
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForInFlightDownloads(t *testing.T) {
+	t.Cleanup(func() { shuttingDown.Store(false) })
+
+	done, ok := trackInFlight()
+	if !ok {
+		t.Fatal("trackInFlight() refused a download before the shutdown")
+	}
+
+	stopped := waitForInFlight()
+	if _, ok := trackInFlight(); ok {
+		t.Error("trackInFlight() started a download after the shutdown")
+	}
+	select {
+	case <-stopped:
+		t.Fatal("the shutdown did not wait for the in-flight download")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the shutdown kept waiting after the in-flight download stopped")
+	}
+}
@@ -0,0 +1,64 @@
+package request
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+var (
+	// totalDownloadedBytes is the cumulative number of bytes downloaded in this run
+	totalDownloadedBytes int64
+
+	// maxTotalBytes is the max number of bytes to download in this run, 0 for no limit
+	maxTotalBytes   int64
+	maxTotalSizeStr string
+	sizeCapMsgOnce  sync.Once
+
+	// ErrMaxTotalSizeReached is returned when a download is skipped
+	// as the max total download size set by the user has been reached.
+	ErrMaxTotalSizeReached = errors.New("max total download size reached")
+)
+
+// SetMaxTotalSize sets the max total download size of the run, e.g. "50GB".
+//
+// Once the cap is hit, no new downloads will be started but in-flight downloads will be completed.
+func SetMaxTotalSize(sizeStr string) error {
+	if sizeStr == "" {
+		maxTotalBytes = 0
+		return nil
+	}
+
+	sizeInBytes, err := utils.ParseByteSize(sizeStr)
+	if err != nil {
+		return err
+	}
+	maxTotalBytes = sizeInBytes
+	maxTotalSizeStr = sizeStr
+	return nil
+}
+
+func addDownloadedBytes(n int64) {
+	atomic.AddInt64(&totalDownloadedBytes, n)
+}
+
+// GetTotalDownloadedBytes returns the cumulative number of bytes downloaded in this run
+func GetTotalDownloadedBytes() int64 {
+	return atomic.LoadInt64(&totalDownloadedBytes)
+}
+
+func maxTotalSizeReached() bool {
+	return maxTotalBytes > 0 && GetTotalDownloadedBytes() >= maxTotalBytes
+}
+
+func printSizeCapMsg() {
+	sizeCapMsgOnce.Do(func() {
+		color.Yellow(
+			"\nThe max total download size of %s has been reached, no new downloads will be started...",
+			maxTotalSizeStr,
+		)
+	})
+}
@@ -0,0 +1,113 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// ErrDownloadStalled is returned when no bytes were received for the stall timeout window
+var ErrDownloadStalled = errors.New("download stalled as no data was received within the stall timeout")
+
+// stallTimeout is the duration without any progress before a download is aborted, 0 to disable.
+var stallTimeout = time.Duration(utils.DEFAULT_STALL_TIMEOUT) * time.Second
+
+// SetStallTimeout sets the number of seconds without receiving any data
+// before a download is aborted and retried. Set to 0 to disable the stall detector.
+func SetStallTimeout(seconds int) error {
+	if seconds < 0 {
+		return fmt.Errorf(
+			"error %d: stall timeout cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			seconds,
+		)
+	}
+	stallTimeout = time.Duration(seconds) * time.Second
+	return nil
+}
+
+// stallReader records the last time data was received from the body for the stall watchdog
+type stallReader struct {
+	body     io.ReadCloser
+	cancel   context.CancelFunc // cancels the worker's request
+	lastRead int64              // unix nano
+	stalled  int32
+	done     chan struct{}
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		atomic.StoreInt64(&r.lastRead, time.Now().UnixNano())
+	}
+	if err != nil && atomic.LoadInt32(&r.stalled) == 1 {
+		return n, ErrDownloadStalled
+	}
+	return n, err
+}
+
+func (r *stallReader) Close() error {
+	select {
+	case <-r.done:
+	default:
+		close(r.done)
+	}
+	return r.body.Close()
+}
+
+// Cancels the worker's request and closes the body if no data was received for the
+// stall timeout window which will unblock the read and abort the download.
+//
+// The request is cancelled as well since closing the body alone
+// may not unblock a read that is stuck on the connection.
+func (r *stallReader) watch() {
+	checkInterval := stallTimeout / 4
+	if checkInterval < time.Second {
+		checkInterval = time.Second
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-ticker.C:
+			if dlPauser.isPaused() {
+				// paused downloads are not considered stalled
+				atomic.StoreInt64(&r.lastRead, now.UnixNano())
+				continue
+			}
+
+			lastRead := time.Unix(0, atomic.LoadInt64(&r.lastRead))
+			if now.Sub(lastRead) >= stallTimeout {
+				atomic.StoreInt32(&r.stalled, 1)
+				r.cancel()
+				r.body.Close()
+				return
+			}
+		}
+	}
+}
+
+// Wraps the body of the response with the stall detector if it is enabled
+// where cancel will be called to cancel the worker's request once it has stalled.
+func wrapStallBody(body io.ReadCloser, cancel context.CancelFunc) io.ReadCloser {
+	if stallTimeout <= 0 {
+		return body
+	}
+
+	reader := &stallReader{
+		body:     body,
+		cancel:   cancel,
+		lastRead: time.Now().UnixNano(),
+		done:     make(chan struct{}),
+	}
+	go reader.watch()
+	return reader
+}
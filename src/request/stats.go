@@ -0,0 +1,66 @@
+package request
+
+import (
+	"sync"
+)
+
+const (
+	dlDownloaded = iota
+	dlSkipped
+	dlFailed
+	dlDeleted
+)
+
+// PlatformStats contains the download counts of a platform in this run
+type PlatformStats struct {
+	Downloaded int `json:"downloaded"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+
+	// Files of the posts that were deleted after they were listed which are not counted as failed
+	Deleted int `json:"deleted"`
+}
+
+var (
+	dlStatsMu sync.Mutex
+	dlStats   = make(map[string]*PlatformStats)
+)
+
+// Records the outcome of a download based on the platform of the URL
+func recordDlStat(reqUrl string, outcome int) {
+	platform := getPlatformFromUrl(reqUrl)
+	if platform == "" {
+		platform = "other"
+	}
+
+	dlStatsMu.Lock()
+	defer dlStatsMu.Unlock()
+	stats, ok := dlStats[platform]
+	if !ok {
+		stats = &PlatformStats{}
+		dlStats[platform] = stats
+	}
+
+	switch outcome {
+	case dlDownloaded:
+		stats.Downloaded++
+	case dlSkipped:
+		stats.Skipped++
+	case dlFailed:
+		stats.Failed++
+	case dlDeleted:
+		stats.Deleted++
+	}
+}
+
+// GetDownloadStats returns a copy of the download counts of each platform in this run
+func GetDownloadStats() map[string]PlatformStats {
+	dlStatsMu.Lock()
+	defer dlStatsMu.Unlock()
+
+	statsCopy := make(map[string]PlatformStats, len(dlStats))
+	for platform, stats := range dlStats {
+		statsCopy[platform] = *stats
+	}
+	return statsCopy
+}
@@ -0,0 +1,380 @@
+package request
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// FileWriter is a file that is being written to a storage backend.
+//
+// The file will only be saved to its final path after Commit is called
+// while Abort discards any data that has been written so far.
+type FileWriter interface {
+	Write(p []byte) (int, error)
+	Commit() error
+	Abort() error
+}
+
+// Storage is the backend that the downloaded files are written to.
+//
+// The download logic only interacts with the files via this interface so
+// that alternative backends like S3 or an in-memory one can be plugged in.
+// The optional features are provided by the backends that also implement
+// PartialStorage, RandomAccessStorage, MtimeStorage, FreeSpaceStorage, or LinkStorage.
+type Storage interface {
+	// Exists checks if the file at the given path exists
+	Exists(filePath string) bool
+
+	// Size returns the size of the file at the given path
+	// or os.ErrNotExist if the file does not exist.
+	Size(filePath string) (int64, error)
+
+	// ModTime returns the last modified time of the file at the given path
+	// or os.ErrNotExist if the file does not exist.
+	ModTime(filePath string) (time.Time, error)
+
+	// Open returns a reader of the file at the given path
+	Open(filePath string) (io.ReadCloser, error)
+
+	// Create returns a writer for the file at the given path.
+	// The size is the expected size of the file or -1 if unknown.
+	Create(filePath string, size int64) (FileWriter, error)
+
+	// Rename moves the file from the src path to the dest path
+	Rename(src, dest string) error
+
+	// Remove deletes the file at the given path
+	Remove(filePath string) error
+
+	// MkdirAll creates the directory and any of its parents if the backend has directories
+	MkdirAll(dirPath string) error
+}
+
+// PartialStorage is implemented by the storage backends that keep the incomplete file,
+// i.e. the .part file, of a download so that it can be resumed with a Range request.
+//
+// The writers returned by Create and OpenAppend also implement io.Closer
+// to close the incomplete file without committing or removing it.
+type PartialStorage interface {
+	// PartSize returns the size of the incomplete file of the file path
+	PartSize(filePath string) (int64, error)
+
+	// OpenAppend returns a writer that appends to the incomplete file of the file path
+	OpenAppend(filePath string) (FileWriter, error)
+
+	// PartValidator returns the saved validator, i.e. the ETag or Last-Modified header,
+	// of the incomplete file of the file path or an empty string if there is none.
+	PartValidator(filePath string) string
+
+	// SetPartValidator saves the validator of the incomplete file of the file path
+	SetPartValidator(filePath, validator string) error
+
+	// RemovePart removes the incomplete file of the file path and its validator
+	RemovePart(filePath string) error
+}
+
+// RandomAccessWriter is a FileWriter that can also write at any offset of the file
+type RandomAccessWriter interface {
+	FileWriter
+	io.WriterAt
+}
+
+// RandomAccessStorage is implemented by the storage backends that can
+// write the chunks of a file at their offsets to download them in parallel.
+type RandomAccessStorage interface {
+	// CreateRandomAccess is the same as Create but the writer can also write at any offset
+	CreateRandomAccess(filePath string, size int64) (RandomAccessWriter, error)
+}
+
+// MtimeStorage is implemented by the storage backends that can set the modification time of a file
+type MtimeStorage interface {
+	// Chtimes sets the modification time of the file at the given path
+	Chtimes(filePath string, mtime time.Time) error
+}
+
+// FreeSpaceStorage is implemented by the storage backends with a limited amount of space
+type FreeSpaceStorage interface {
+	// FreeSpace returns the free space in bytes that is available for the file at the given path
+	FreeSpace(filePath string) (int64, error)
+}
+
+// LinkStorage is implemented by the storage backends that can link a file to another one
+type LinkStorage interface {
+	// Link replaces the file at the given path with a hard link to the target file
+	Link(target, filePath string) error
+
+	// Symlink replaces the file at the given path with a symbolic link to the target file
+	Symlink(target, filePath string) error
+}
+
+// dlStorage is the storage backend for the downloaded files, defaults to the local filesystem
+var dlStorage Storage = &localStorage{}
+
+// SetStorageBackend sets a custom storage backend for the downloaded files
+func SetStorageBackend(storage Storage) {
+	dlStorage = storage
+}
+
+// GetStorage returns the storage backend for the downloaded files
+func GetStorage() Storage {
+	return dlStorage
+}
+
+// IsRemoteStorage checks if the downloaded files are written somewhere other than the local filesystem
+func IsRemoteStorage() bool {
+	_, isLocal := dlStorage.(*localStorage)
+	return !isLocal
+}
+
+// SetStorage sets the storage backend for the downloaded files based on the URI.
+//
+// Supported URIs:
+//   - "" or "local" for the local filesystem (default)
+//   - "s3://bucket/prefix" for an S3-compatible bucket where the credentials are read from the environment
+func SetStorage(storageUri string) error {
+	if storageUri == "" || storageUri == "local" {
+		dlStorage = &localStorage{}
+		utils.SetSidecarStorage(nil)
+		return nil
+	}
+
+	parsedUri, err := url.Parse(storageUri)
+	if err != nil || parsedUri.Scheme != "s3" || parsedUri.Host == "" {
+		return fmt.Errorf(
+			"error %d: invalid storage %q, expected \"local\" or \"s3://bucket/prefix\"",
+			utils.INPUT_ERROR,
+			storageUri,
+		)
+	}
+
+	s3, err := newS3Storage(
+		parsedUri.Host,
+		strings.Trim(parsedUri.Path, "/"),
+	)
+	if err != nil {
+		return err
+	}
+	dlStorage = s3
+	utils.SetSidecarStorage(sidecarStorage{})
+	return nil
+}
+
+// WriteFile writes the data to the file at the given path of the storage backend
+func WriteFile(filePath string, data []byte) error {
+	if err := dlStorage.MkdirAll(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	file, err := dlStorage.Create(filePath, int64(len(data)))
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Abort()
+		return err
+	}
+	return file.Commit()
+}
+
+// sidecarStorage writes the sidecar files, e.g. the metadata JSON files,
+// to the storage backend so that they are kept next to the downloaded files.
+type sidecarStorage struct{}
+
+func (sidecarStorage) Exists(filePath string) bool {
+	return dlStorage.Exists(filePath)
+}
+
+func (sidecarStorage) WriteFile(filePath string, data []byte) error {
+	return WriteFile(filePath, data)
+}
+
+// localStorage writes the downloaded files to the local filesystem
+type localStorage struct{}
+
+type localFileWriter struct {
+	storage      *localStorage
+	file         *os.File
+	partFilePath string
+	filePath     string
+}
+
+func (l *localStorage) newFileWriter(filePath string, flag int) (*localFileWriter, error) {
+	partFilePath := GetPartFilePath(filePath)
+	file, err := os.OpenFile(partFilePath, flag, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &localFileWriter{
+		storage:      l,
+		file:         file,
+		partFilePath: partFilePath,
+		filePath:     filePath,
+	}, nil
+}
+
+func (l *localStorage) Create(filePath string, size int64) (FileWriter, error) {
+	return l.newFileWriter(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+}
+
+func (l *localStorage) CreateRandomAccess(filePath string, size int64) (RandomAccessWriter, error) {
+	return l.newFileWriter(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC)
+}
+
+func (l *localStorage) OpenAppend(filePath string) (FileWriter, error) {
+	return l.newFileWriter(filePath, os.O_WRONLY|os.O_APPEND)
+}
+
+func (l *localStorage) Exists(filePath string) bool {
+	return utils.PathExists(filePath)
+}
+
+func (l *localStorage) Size(filePath string) (int64, error) {
+	return getLocalFileSize(filePath)
+}
+
+// Returns the size of the file via os.Stat instead of utils.GetFileSize as the latter leaves the file open,
+// which would prevent the file from being renamed or removed afterwards on Windows.
+func getLocalFileSize(filePath string) (int64, error) {
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return -1, os.ErrNotExist
+		}
+		return -1, err
+	}
+	return fileInfo.Size(), nil
+}
+
+func (l *localStorage) ModTime(filePath string) (time.Time, error) {
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, os.ErrNotExist
+		}
+		return time.Time{}, err
+	}
+	return fileInfo.ModTime(), nil
+}
+
+func (l *localStorage) Open(filePath string) (io.ReadCloser, error) {
+	return os.Open(filePath)
+}
+
+func (l *localStorage) Rename(src, dest string) error {
+	return utils.MoveFile(src, dest)
+}
+
+func (l *localStorage) Remove(filePath string) error {
+	return os.Remove(filePath)
+}
+
+func (l *localStorage) MkdirAll(dirPath string) error {
+	return utils.MkdirAll(dirPath)
+}
+
+func (l *localStorage) Chtimes(filePath string, mtime time.Time) error {
+	return os.Chtimes(filePath, mtime, mtime)
+}
+
+func (l *localStorage) Link(target, filePath string) error {
+	return replaceWithLink(filePath, func(linkPath string) error {
+		return os.Link(target, linkPath)
+	})
+}
+
+func (l *localStorage) Symlink(target, filePath string) error {
+	// a relative link will still work if the download directory is moved
+	if relTarget, err := filepath.Rel(filepath.Dir(filePath), target); err == nil {
+		target = relTarget
+	}
+	return replaceWithLink(filePath, func(linkPath string) error {
+		return os.Symlink(target, linkPath)
+	})
+}
+
+// Creates the link next to the file before renaming it over the file
+// so that the file is kept as it is if the link could not be created.
+func replaceWithLink(filePath string, link func(linkPath string) error) error {
+	linkPath := filePath + ".link"
+	os.Remove(linkPath)
+	if err := link(linkPath); err != nil {
+		return err
+	}
+	if err := os.Rename(linkPath, filePath); err != nil {
+		os.Remove(linkPath)
+		return err
+	}
+	return nil
+}
+
+func (l *localStorage) FreeSpace(filePath string) (int64, error) {
+	return utils.GetFreeSpace(getExistingDir(filePath))
+}
+
+func (l *localStorage) PartSize(filePath string) (int64, error) {
+	return getLocalFileSize(GetPartFilePath(filePath))
+}
+
+func (l *localStorage) PartValidator(filePath string) string {
+	validator, err := os.ReadFile(getPartValidatorPath(GetPartFilePath(filePath)))
+	if err != nil {
+		return ""
+	}
+	return string(validator)
+}
+
+func (l *localStorage) SetPartValidator(filePath, validator string) error {
+	validatorPath := getPartValidatorPath(GetPartFilePath(filePath))
+	if validator == "" {
+		if err := os.Remove(validatorPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(validatorPath, []byte(validator), 0666)
+}
+
+func (l *localStorage) RemovePart(filePath string) error {
+	partFilePath := GetPartFilePath(filePath)
+	err := os.Remove(partFilePath)
+	if validatorErr := os.Remove(getPartValidatorPath(partFilePath)); validatorErr != nil && !os.IsNotExist(validatorErr) {
+		return validatorErr
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (w *localFileWriter) Write(p []byte) (int, error) {
+	return w.file.Write(p)
+}
+
+func (w *localFileWriter) WriteAt(p []byte, off int64) (int, error) {
+	return w.file.WriteAt(p, off)
+}
+
+// Commit moves the .part file to its final path
+func (w *localFileWriter) Commit() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	return w.storage.Rename(w.partFilePath, w.filePath)
+}
+
+// Abort removes the .part file
+func (w *localFileWriter) Abort() error {
+	w.file.Close()
+	return w.storage.Remove(w.partFilePath)
+}
+
+// Close closes the .part file without removing it so that its download can be resumed
+func (w *localFileWriter) Close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,142 @@
+package request
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Sets a new in-memory storage backend for the test and restores the local storage afterwards
+func useMemoryStorage(t *testing.T) *MemoryStorage {
+	t.Helper()
+	storage := NewMemoryStorage()
+	SetStorageBackend(storage)
+	t.Cleanup(func() { SetStorageBackend(&localStorage{}) })
+	return storage
+}
+
+func TestDownloadUrlToMemoryStorage(t *testing.T) {
+	useFakeClock(t)
+	tests := []struct {
+		name          string
+		interruptOnce bool
+		wantRanges    []string
+	}{
+		{
+			name:       "downloads the whole file",
+			wantRanges: []string{""},
+		},
+		{
+			name:          "resumes the interrupted attempt from the incomplete file",
+			interruptOnce: true,
+			wantRanges:    []string{"", "bytes=11-"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := useMemoryStorage(t)
+			var mu sync.Mutex
+			var ranges []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Content-Type", "application/octet-stream")
+				if r.Method == http.MethodGet {
+					mu.Lock()
+					ranges = append(ranges, r.Header.Get("Range"))
+					firstAttempt := len(ranges) == 1
+					mu.Unlock()
+					if test.interruptOnce && firstAttempt {
+						w.Header().Set("Content-Length", strconv.Itoa(len(partialResumeContent)))
+						w.WriteHeader(http.StatusOK)
+						w.Write([]byte(partialResumeContent[:11]))
+						w.(http.Flusher).Flush()
+						conn, _, _ := w.(http.Hijacker).Hijack()
+						conn.Close()
+						return
+					}
+				}
+				http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader([]byte(partialResumeContent)))
+			}))
+			defer srv.Close()
+
+			dirPath := t.TempDir()
+			filePath := filepath.Join(dirPath, "file.bin")
+			err := DownloadUrl(filePath, make(chan struct{}, 1), &RequestArgs{
+				Url:            srv.URL + "/file.bin",
+				Method:         "GET",
+				Timeout:        10,
+				Http2:          true,
+				RequestHandler: CallRequest,
+			}, false)
+			if err != nil {
+				t.Fatalf("DownloadUrl() error = %v", err)
+			}
+
+			data, err := storage.ReadFile(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != partialResumeContent {
+				t.Errorf("file content = %q, want %q", data, partialResumeContent)
+			}
+			if storage.HasPart(filePath) || storage.PartValidator(filePath) != "" {
+				t.Errorf("the incomplete file of %s was not removed after the download", filePath)
+			}
+			if entries, err := os.ReadDir(dirPath); err != nil || len(entries) != 0 {
+				t.Errorf("the download touched the local filesystem, got %v entries", entries)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(ranges) != len(test.wantRanges) {
+				t.Fatalf("GET Range headers = %q, want %q", ranges, test.wantRanges)
+			}
+			for i := range ranges {
+				if ranges[i] != test.wantRanges[i] {
+					t.Errorf("GET Range headers = %q, want %q", ranges, test.wantRanges)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadUrlSkipsExistingFileInStorage(t *testing.T) {
+	storage := useMemoryStorage(t)
+	// the served content has the same size as the existing file which is used to skip the download
+	servedContent := bytes.ToUpper([]byte(partialResumeContent))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(servedContent))
+	}))
+	defer srv.Close()
+
+	filePath := filepath.Join(t.TempDir(), "file.bin")
+	writer, err := storage.Create(filePath, int64(len(partialResumeContent)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write([]byte(partialResumeContent))
+	if err := writer.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = DownloadUrl(filePath, make(chan struct{}, 1), &RequestArgs{
+		Url:            srv.URL + "/file.bin",
+		Method:         "GET",
+		Timeout:        10,
+		Http2:          true,
+		RequestHandler: CallRequest,
+	}, false)
+	if err != nil {
+		t.Fatalf("DownloadUrl() error = %v", err)
+	}
+	if data, _ := storage.ReadFile(filePath); string(data) != partialResumeContent {
+		t.Errorf("file content = %q, want the existing content %q", data, partialResumeContent)
+	}
+}
@@ -0,0 +1,43 @@
+package request
+
+import (
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+)
+
+// DownloadUrlStreamWithHandler downloads the batches of files from the channel as they are discovered,
+// e.g. while the rest of the posts are still being listed, so that the downloads do not have to wait for the listing.
+// The total number of files on the progress spinner grows with each batch so that the percentage stays meaningful.
+//
+// The channel should be closed by the caller once all the files have been sent.
+// Unlike DownloadUrlsWithHandler, the files will not be downloaded in waves as the total number of files is not known up front.
+// If enumerating first, all the batches are added to the plan instead once the channel has been closed.
+func DownloadUrlStreamWithHandler(urlInfoChan <-chan []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler) {
+	if isPlanFirst() {
+		var urlInfoSlice []*ToDownload
+		for batch := range urlInfoChan {
+			urlInfoSlice = append(urlInfoSlice, batch...)
+		}
+		DownloadUrlsWithHandler(urlInfoSlice, dlOptions, config, reqHandler)
+		return
+	}
+
+	batches := make(chan []*ToDownload)
+	go func() {
+		defer close(batches)
+		for urlInfoSlice := range urlInfoChan {
+			urlInfoSlice = orderByPost(urlInfoSlice)
+			addToSyncPlan(urlInfoSlice)
+			if urlInfoSlice = filterCompleted(urlInfoSlice); len(urlInfoSlice) > 0 {
+				batches <- urlInfoSlice
+			}
+		}
+	}()
+
+	failed := downloadUrlBatches(batches, dlOptions, config, reqHandler, postRetries == 0)
+	retryFailedDownloads(failed, dlOptions, config, reqHandler)
+}
+
+// Same as DownloadUrlStreamWithHandler but uses the default request handler (CallRequest)
+func DownloadUrlStream(urlInfoChan <-chan []*ToDownload, dlOptions *DlOptions, config *configs.Config) {
+	DownloadUrlStreamWithHandler(urlInfoChan, dlOptions, config, CallRequest)
+}
@@ -0,0 +1,88 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+)
+
+func TestDownloadUrlStreamStartsBeforeListingEnds(t *testing.T) {
+	useFakeClock(t)
+	firstDownloaded := make(chan struct{})
+	var closeOnce atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("file content"))
+		if r.Method == http.MethodGet && r.URL.Path == "/file0.bin" && closeOnce.CompareAndSwap(false, true) {
+			close(firstDownloaded)
+		}
+	}))
+	defer srv.Close()
+
+	dlDir := t.TempDir()
+	getBatch := func(i int) []*ToDownload {
+		return []*ToDownload{{
+			Url:      fmt.Sprintf("%s/file%d.bin", srv.URL, i),
+			FilePath: filepath.Join(dlDir, fmt.Sprintf("file%d.bin", i)),
+		}}
+	}
+
+	// the second batch is only listed after the first batch has been downloaded
+	urlInfoChan := make(chan []*ToDownload)
+	go func() {
+		defer close(urlInfoChan)
+		urlInfoChan <- getBatch(0)
+		select {
+		case <-firstDownloaded:
+		case <-time.After(10 * time.Second):
+			t.Error("the first batch was not downloaded before the listing ended")
+		}
+		urlInfoChan <- getBatch(1)
+	}()
+	DownloadUrlStreamWithHandler(urlInfoChan, &DlOptions{MaxConcurrency: 2}, &configs.Config{}, CallRequest)
+
+	for i := 0; i < 2; i++ {
+		if _, err := os.Stat(filepath.Join(dlDir, fmt.Sprintf("file%d.bin", i))); err != nil {
+			t.Errorf("file%d.bin was not downloaded: %v", i, err)
+		}
+	}
+}
+
+func TestDownloadUrlStreamAddsToPlan(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	SetPlanFirst(true)
+	t.Cleanup(func() {
+		DiscardDownloadPlan()
+		SetPlanFirst(false)
+	})
+
+	dlDir := t.TempDir()
+	urlInfoChan := make(chan []*ToDownload, 2)
+	for i := 0; i < 2; i++ {
+		urlInfoChan <- []*ToDownload{{
+			Url:      fmt.Sprintf("%s/file%d.bin", srv.URL, i),
+			FilePath: filepath.Join(dlDir, fmt.Sprintf("file%d.bin", i)),
+		}}
+	}
+	close(urlInfoChan)
+	DownloadUrlStreamWithHandler(urlInfoChan, &DlOptions{MaxConcurrency: 2}, &configs.Config{}, CallRequest)
+
+	if !HasDownloadPlan() {
+		t.Error("the streamed files were not added to the download plan")
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("sent %d requests before the plan was confirmed, want 0", got)
+	}
+}
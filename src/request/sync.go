@@ -0,0 +1,325 @@
+package request
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// syncEntry is a downloaded file recorded in the sync manifest
+type syncEntry struct {
+	Url  string `json:"url"`
+	Size int64  `json:"size"`
+	Etag string `json:"etag,omitempty"`
+
+	// FilePath is only set by the older manifests that were keyed by the URL
+	FilePath string `json:"file_path,omitempty"`
+}
+
+// syncManifest maps the file paths to the files downloaded by the previous runs with the same run key
+// so that only the new or changed files will be downloaded and the removed ones can be deleted.
+//
+// The entries are keyed by the resolved file path instead of the URL as the download URLs
+// of some platforms like Fantia and Pixiv Fanbox are signed and change on every run.
+type syncManifest struct {
+	mu       sync.Mutex
+	RunKey   string                `json:"run_key"`
+	Files    map[string]*syncEntry `json:"files"`
+	planned  map[string]bool       // file paths that are part of the remote plan of this run
+	listed   map[string]bool       // URLs that are part of the remote plan of this run
+	resolved map[string]bool       // URLs whose file paths have been resolved
+	changed  bool
+}
+
+// manifest is nil if the sync mode is not enabled
+var manifest *syncManifest
+
+// Returns the path of the sync manifest of the run key in the app's config directory
+func getSyncManifestPath(runKey string) string {
+	runKeyHash := sha1.Sum([]byte(runKey))
+	return filepath.Join(
+		utils.APP_PATH,
+		"sync_manifests",
+		fmt.Sprintf("%x.json", runKeyHash[:8]),
+	)
+}
+
+// EnableSync loads the sync manifest of the run key from the app's config directory.
+//
+// Files that are in the manifest and have not changed based on their size and ETag
+// will be skipped while the changed files will be re-downloaded and overwritten.
+func EnableSync(runKey string) error {
+	state := &syncManifest{
+		RunKey:   runKey,
+		Files:    make(map[string]*syncEntry),
+		planned:  make(map[string]bool),
+		listed:   make(map[string]bool),
+		resolved: make(map[string]bool),
+	}
+
+	manifestPath := getSyncManifestPath(runKey)
+	data, err := os.ReadFile(manifestPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf(
+			"error %d: failed to read the sync manifest at %s, more info => %v",
+			utils.OS_ERROR,
+			manifestPath,
+			err,
+		)
+	}
+	if len(data) > 0 {
+		var prevState syncManifest
+		if err := json.Unmarshal(data, &prevState); err != nil {
+			utils.LogError(
+				err,
+				fmt.Sprintf("the sync manifest at %s is corrupted and will be reset", manifestPath),
+				false,
+				utils.ERROR,
+			)
+		} else if prevState.RunKey == runKey && prevState.Files != nil {
+			state.Files = migrateSyncEntries(prevState.Files)
+		}
+	}
+
+	manifest = state
+	return nil
+}
+
+// Re-keys the entries of the older manifests that were keyed by the URL by their file path
+func migrateSyncEntries(files map[string]*syncEntry) map[string]*syncEntry {
+	migrated := make(map[string]*syncEntry, len(files))
+	for key, entry := range files {
+		if entry.FilePath != "" {
+			entry.Url = key
+			key = entry.FilePath
+			entry.FilePath = ""
+		}
+		migrated[key] = entry
+	}
+	return migrated
+}
+
+// Adds the URLs to the remote plan of this run if the sync mode is enabled.
+//
+// The files with a known filename are added to the plan right away while the others
+// are added once their file path has been resolved from the response, see markSyncPlanned.
+func addToSyncPlan(urlInfoSlice []*ToDownload) {
+	if manifest == nil {
+		return
+	}
+
+	manifest.mu.Lock()
+	defer manifest.mu.Unlock()
+	for _, urlInfo := range urlInfoSlice {
+		manifest.listed[urlInfo.Url] = true
+		if filepath.Ext(urlInfo.FilePath) != "" {
+			filePath := addFilenamePrefix(utils.NormaliseFileExt(urlInfo.FilePath), urlInfo.FilenamePrefix)
+			manifest.planned[filePath] = true
+			manifest.resolved[urlInfo.Url] = true
+		}
+	}
+}
+
+// Adds the resolved file path of the URL to the remote plan of this run if the sync mode is enabled
+func markSyncPlanned(reqUrl, filePath string) {
+	if manifest == nil {
+		return
+	}
+
+	manifest.mu.Lock()
+	defer manifest.mu.Unlock()
+	manifest.planned[filePath] = true
+	manifest.resolved[reqUrl] = true
+}
+
+// IsSyncPlanComplete returns true if the file paths of all the files listed by this run have been resolved
+// so that the files missing from the remote plan are known to have been removed remotely.
+func IsSyncPlanComplete() bool {
+	if manifest == nil {
+		return false
+	}
+
+	manifest.mu.Lock()
+	defer manifest.mu.Unlock()
+	for reqUrl := range manifest.listed {
+		if !manifest.resolved[reqUrl] {
+			return false
+		}
+	}
+	return true
+}
+
+// Compares the HEAD response of the file with its manifest entry.
+//
+// Returns unchanged as true if the local file is intact and the remote file has the same size and ETag,
+// and changed as true if the local file is intact but the remote file differs and has to be overwritten.
+func checkSyncEntry(filePath string, headRes *http.Response) (unchanged, changed bool) {
+	if manifest == nil {
+		return false, false
+	}
+
+	manifest.mu.Lock()
+	entry, ok := manifest.Files[filePath]
+	manifest.mu.Unlock()
+	if !ok {
+		return false, false
+	}
+
+	localSize, err := dlStorage.Size(filePath)
+	if err != nil || localSize != entry.Size || isOlderThanMaxAge(filePath) {
+		return false, false
+	}
+
+	remoteEtag := headRes.Header.Get("ETag")
+	sameSize := headRes.ContentLength < 0 || headRes.ContentLength == entry.Size
+	sameEtag := remoteEtag == "" || entry.Etag == "" || remoteEtag == entry.Etag
+	if sameSize && sameEtag {
+		return true, false
+	}
+	return false, true
+}
+
+// Records the downloaded or already existing file of the URL in the sync manifest if the sync mode is enabled.
+//
+// The files removed as duplicates are only added to the remote plan as they are not kept locally.
+func recordSynced(reqUrl, filePath string, res *http.Response) {
+	if manifest == nil {
+		return
+	}
+	if isRemovedDuplicate(filePath) {
+		markSyncPlanned(reqUrl, filePath)
+		return
+	}
+
+	size := res.ContentLength
+	if fileSize, err := dlStorage.Size(filePath); err == nil {
+		size = fileSize
+	}
+
+	manifest.mu.Lock()
+	defer manifest.mu.Unlock()
+	manifest.Files[filePath] = &syncEntry{
+		Url:  reqUrl,
+		Size: size,
+		Etag: res.Header.Get("ETag"),
+	}
+	manifest.planned[filePath] = true
+	manifest.resolved[reqUrl] = true
+	manifest.changed = true
+}
+
+// GetRemovedSyncFiles returns the local file paths in the sync manifest that
+// were not part of the remote plan of this run, i.e. the files that were removed remotely.
+func GetRemovedSyncFiles() []string {
+	if manifest == nil {
+		return nil
+	}
+
+	manifest.mu.Lock()
+	defer manifest.mu.Unlock()
+	var removed []string
+	for filePath := range manifest.Files {
+		if !manifest.planned[filePath] && dlStorage.Exists(filePath) {
+			removed = append(removed, filePath)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// DeleteRemovedSyncFiles deletes the local files that were removed remotely
+// and drops them from the sync manifest, returning the number of deleted files.
+func DeleteRemovedSyncFiles(filePaths []string) int {
+	if manifest == nil {
+		return 0
+	}
+
+	toDelete := make(map[string]bool, len(filePaths))
+	for _, filePath := range filePaths {
+		toDelete[filePath] = true
+	}
+
+	manifest.mu.Lock()
+	defer manifest.mu.Unlock()
+	deleted := 0
+	for filePath := range manifest.Files {
+		// never delete a file that is part of this run's plan even if it was confirmed
+		if manifest.planned[filePath] || !toDelete[filePath] {
+			continue
+		}
+
+		if err := dlStorage.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			utils.LogError(
+				fmt.Errorf(
+					"error %d: failed to delete the removed file %s, more info => %v",
+					utils.OS_ERROR,
+					filePath,
+					err,
+				),
+				"",
+				false,
+				utils.ERROR,
+			)
+			continue
+		}
+		delete(manifest.Files, filePath)
+		manifest.changed = true
+		deleted++
+	}
+	return deleted
+}
+
+// SaveSyncManifest writes the sync manifest to the app's config directory if it has changed.
+//
+// The manifest is written to a temporary file first so that the previous
+// manifest is kept intact if the program is killed while writing.
+func SaveSyncManifest() error {
+	if manifest == nil {
+		return nil
+	}
+
+	manifest.mu.Lock()
+	defer manifest.mu.Unlock()
+	if !manifest.changed {
+		return nil
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to marshal the sync manifest, more info => %v",
+			utils.JSON_ERROR,
+			err,
+		)
+	}
+
+	manifestPath := getSyncManifestPath(manifest.RunKey)
+	os.MkdirAll(filepath.Dir(manifestPath), 0700)
+	tmpFilePath := manifestPath + ".tmp"
+	if err := os.WriteFile(tmpFilePath, data, 0666); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write the sync manifest to %s, more info => %v",
+			utils.OS_ERROR,
+			tmpFilePath,
+			err,
+		)
+	}
+	if err := os.Rename(tmpFilePath, manifestPath); err != nil {
+		os.Remove(tmpFilePath)
+		return fmt.Errorf(
+			"error %d: failed to replace the sync manifest at %s, more info => %v",
+			utils.OS_ERROR,
+			manifestPath,
+			err,
+		)
+	}
+	manifest.changed = false
+	return nil
+}
@@ -0,0 +1,195 @@
+package request
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// Minimum size of a file for it to be split into chunks at the end of a batch
+const tailSplitMinSize = 16 * 1024 * 1024
+
+// tailChunks is the max number of chunks to split each of the last large files of a batch into, 0 if disabled
+var tailChunks int
+
+// SetTailChunks sets the max number of parallel Range requests that each of the last large files of a batch
+// will be split into using the workers that have become idle at the end of the batch. Set it to 0 to disable it.
+func SetTailChunks(chunks int) error {
+	if chunks < 0 {
+		return fmt.Errorf(
+			"error %d: number of tail chunks cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			chunks,
+		)
+	}
+	tailChunks = chunks
+	return nil
+}
+
+// dlTail tracks whether a batch of downloads has reached its end where no files are waiting for a worker anymore
+type dlTail struct {
+	queue      chan struct{}
+	waiting    atomic.Int64
+	enumerated atomic.Bool
+}
+
+func newDlTail(queue chan struct{}) *dlTail {
+	return &dlTail{queue: queue}
+}
+
+// Takes up to the given number of the idle workers of the batch if all of its files have started downloading
+// and returns the number of workers taken and a function to release them.
+func (t *dlTail) takeIdleWorkers(max int) (int, func()) {
+	if t == nil || !t.enumerated.Load() || t.waiting.Load() > 0 {
+		return 0, func() {}
+	}
+
+	taken := 0
+takeWorkers:
+	for taken < max {
+		select {
+		case t.queue <- struct{}{}:
+			taken++
+		default:
+			break takeWorkers
+		}
+	}
+	return taken, func() {
+		for i := 0; i < taken; i++ {
+			<-t.queue
+		}
+	}
+}
+
+// Returns the number of extra chunks the response's file can be split into and a function to release their workers.
+//
+// The file will only be split if it is large enough, the server supports Range requests,
+// and the storage backend can write the chunks at their offsets.
+func getTailChunks(reqArgs *RequestArgs, res *http.Response) (int, func()) {
+	if tailChunks < 2 || res.ContentLength < tailSplitMinSize || res.StatusCode != http.StatusOK {
+		return 0, func() {}
+	}
+	if res.Header.Get("Accept-Ranges") != "bytes" {
+		return 0, func() {}
+	}
+	if _, ok := dlStorage.(RandomAccessStorage); !ok {
+		return 0, func() {}
+	}
+	return reqArgs.tail.takeIdleWorkers(tailChunks - 1)
+}
+
+// Downloads the file in parallel chunks where the first chunk is read from the response's body
+// and the rest are downloaded with Range requests, each written to the .part file at its offset.
+func dlToFileInChunks(res *http.Response, reqArgs *RequestArgs, filePath string, extraChunks int) error {
+	file, err := dlStorage.(RandomAccessStorage).CreateRandomAccess(filePath, res.ContentLength)
+	if err != nil {
+		if isDiskFullErr(err) {
+			return wrapDiskFullErr(err, filePath)
+		}
+		return fmt.Errorf(
+			"error %d: failed to create file, more info => %v\nfile path: %s",
+			utils.OS_ERROR,
+			err,
+			filePath,
+		)
+	}
+
+	chunkSize := res.ContentLength / int64(extraChunks+1)
+	validator := getIfRangeValidator(res)
+	var wg sync.WaitGroup
+	errs := make([]error, extraChunks+1)
+	for i := 1; i <= extraChunks; i++ {
+		start := int64(i) * chunkSize
+		end := start + chunkSize - 1
+		if i == extraChunks {
+			end = res.ContentLength - 1
+		}
+
+		wg.Add(1)
+		go func(i int, start, end int64) {
+			defer wg.Done()
+			errs[i] = dlChunk(reqArgs, file, validator, start, end)
+		}(i, start, end)
+	}
+
+	written, err := io.CopyN(file, res.Body, chunkSize)
+	addDownloadedBytes(written)
+	errs[0] = err
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if fileErr := file.Abort(); fileErr != nil {
+			utils.LogError(
+				fmt.Errorf(
+					"download error %d: failed to remove incomplete file of %s, more info => %v",
+					utils.OS_ERROR,
+					filePath,
+					fileErr,
+				),
+				"",
+				false,
+				utils.ERROR,
+			)
+		}
+		if isDiskFullErr(err) {
+			return wrapDiskFullErr(err, filePath)
+		}
+		return err
+	}
+	return wrapDiskFullErr(
+		file.Commit(),
+		filePath,
+	)
+}
+
+// Downloads the bytes from the start to the end offset of the file and writes them to the .part file at the start offset
+func dlChunk(reqArgs *RequestArgs, file io.WriterAt, validator string, start, end int64) error {
+	chunkReqArgs := *reqArgs
+	chunkReqArgs.Headers = make(map[string]string, len(reqArgs.Headers)+2)
+	for key, value := range reqArgs.Headers {
+		chunkReqArgs.Headers[key] = value
+	}
+	chunkReqArgs.Headers["Range"] = fmt.Sprintf("bytes=%d-%d", start, end)
+	if validator != "" {
+		chunkReqArgs.Headers["If-Range"] = validator
+	}
+
+	res, err := reqArgs.RequestHandler(&chunkReqArgs)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to download the chunk from byte %d to %d, more info => %v\nurl: %s",
+			utils.DOWNLOAD_ERROR,
+			start,
+			end,
+			err,
+			reqArgs.Url,
+		)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		// the server sent the whole file instead, e.g. as it has changed since the download started
+		return fmt.Errorf(
+			"error %d: expected a partial response for the chunk from byte %d to %d but got %q\nurl: %s",
+			utils.DOWNLOAD_ERROR,
+			start,
+			end,
+			res.Status,
+			reqArgs.Url,
+		)
+	}
+	if err := checkContentRange(res, start); err != nil {
+		return err
+	}
+
+	body := &pausableReader{ctx: reqArgs.Context, body: res.Body}
+	written, err := io.CopyN(io.NewOffsetWriter(file, start), body, end-start+1)
+	addDownloadedBytes(written)
+	return err
+}
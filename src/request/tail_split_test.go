@@ -0,0 +1,91 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDlToFileInChunks(t *testing.T) {
+	useFakeClock(t)
+	content := make([]byte, 64*1024)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+
+	tests := []struct {
+		name    string
+		storage func(t *testing.T) Storage
+	}{
+		{"local storage", func(t *testing.T) Storage { return &localStorage{} }},
+		{"memory storage", func(t *testing.T) Storage { return NewMemoryStorage() }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage := test.storage(t)
+			SetStorageBackend(storage)
+			t.Cleanup(func() { SetStorageBackend(&localStorage{}) })
+			filePath := filepath.Join(t.TempDir(), "file.bin")
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") == "" {
+					// hold back the first chunk until the other chunks have been written
+					// so that they have grown the .part file to its full size beforehand
+					w.Header().Set("Accept-Ranges", "bytes")
+					w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+					w.WriteHeader(http.StatusOK)
+					w.(http.Flusher).Flush()
+					deadline := time.Now().Add(5 * time.Second)
+					for time.Now().Before(deadline) {
+						if size, err := storage.(PartialStorage).PartSize(filePath); err == nil && size == int64(len(content)) {
+							break
+						}
+						time.Sleep(time.Millisecond)
+					}
+					w.Write(content)
+					return
+				}
+				http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+			}))
+			defer srv.Close()
+
+			reqArgs := &RequestArgs{
+				Url:            srv.URL + "/file.bin",
+				Method:         "GET",
+				Timeout:        10,
+				Http2:          true,
+				Context:        context.Background(),
+				RequestHandler: CallRequest,
+			}
+			res, err := CallRequest(reqArgs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if err := dlToFileInChunks(res, reqArgs, filePath, 3); err != nil {
+				t.Fatalf("dlToFileInChunks() error = %v", err)
+			}
+
+			file, err := storage.Open(filePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			got, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("the downloaded file of %d bytes does not match the %d bytes of the file", len(got), len(content))
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package request
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// base delay for a host after its first failure
+	HOST_THROTTLE_BASE_DELAY = 500 * time.Millisecond
+
+	// max delay before a request is sent to a failing host
+	HOST_THROTTLE_MAX_DELAY = 30 * time.Second
+)
+
+// hostThrottler keeps track of the consecutive failures per host
+// and delays the requests to the failing hosts exponentially
+// so that other hosts can still be downloaded from at full speed.
+type hostThrottler struct {
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+var dlThrottler = &hostThrottler{
+	failures: make(map[string]int),
+}
+
+func getHost(reqUrl string) string {
+	parsedUrl, err := url.Parse(reqUrl)
+	if err != nil {
+		return ""
+	}
+	return parsedUrl.Hostname()
+}
+
+// Returns the delay to wait before sending a request to the host
+func (t *hostThrottler) getDelay(host string) time.Duration {
+	t.mu.Lock()
+	failures := t.failures[host]
+	t.mu.Unlock()
+	if failures == 0 {
+		return 0
+	}
+
+	delay := HOST_THROTTLE_BASE_DELAY
+	for i := 1; i < failures && delay < HOST_THROTTLE_MAX_DELAY; i++ {
+		delay *= 2
+	}
+	if delay > HOST_THROTTLE_MAX_DELAY {
+		delay = HOST_THROTTLE_MAX_DELAY
+	}
+	return delay
+}
+
+// wait blocks for the throttled delay of the host or until the context is cancelled
+func (t *hostThrottler) wait(ctx context.Context, reqUrl string) error {
+	delay := t.getDelay(getHost(reqUrl))
+	if delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *hostThrottler) recordFailure(reqUrl string) {
+	host := getHost(reqUrl)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failures[host]++
+}
+
+// recordSuccess lowers the failure count of the host
+// so that it gradually recovers to its full speed.
+func (t *hostThrottler) recordSuccess(reqUrl string) {
+	host := getHost(reqUrl)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if failures, ok := t.failures[host]; ok {
+		if failures <= 1 {
+			delete(t.failures, host)
+		} else {
+			t.failures[host] = failures / 2
+		}
+	}
+}
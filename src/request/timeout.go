@@ -0,0 +1,44 @@
+package request
+
+import (
+	"fmt"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// minDlSpeed is the assumed minimum download speed in bytes per second
+// used to scale the download timeout with the file size, 0 to use the flat timeout.
+var minDlSpeed int64 = utils.DEFAULT_MIN_DL_SPEED * 1024
+
+// SetMinDownloadSpeed sets the assumed minimum download speed in KB/s used to
+// calculate the timeout of each download based on its size. Set it to 0 to use the flat timeout.
+func SetMinDownloadSpeed(kbps int) error {
+	if kbps < 0 {
+		return fmt.Errorf(
+			"error %d: min download speed cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			kbps,
+		)
+	}
+	minDlSpeed = int64(kbps) * 1024
+	return nil
+}
+
+// Returns the timeout in seconds for downloading a file of the given size.
+//
+// The timeout is the time needed to download the file at the min download speed plus a base,
+// clamped between a floor and a ceiling so that small files will time out quickly on stalls
+// while large files are given adequate time. If the size is unknown, the flat timeout is used.
+func getDownloadTimeout(contentLength int64) int {
+	if minDlSpeed <= 0 || contentLength <= 0 {
+		return utils.DOWNLOAD_TIMEOUT
+	}
+
+	timeout := utils.BASE_DOWNLOAD_TIMEOUT + contentLength/minDlSpeed
+	if timeout < utils.MIN_DOWNLOAD_TIMEOUT {
+		return utils.MIN_DOWNLOAD_TIMEOUT
+	} else if timeout > utils.MAX_DOWNLOAD_TIMEOUT {
+		return utils.MAX_DOWNLOAD_TIMEOUT
+	}
+	return int(timeout)
+}
@@ -0,0 +1,144 @@
+package request
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+const spkiPinPrefix = "sha256/"
+
+// tlsPins maps the host to the base64-encoded SHA-256 hashes of the
+// expected Subject Public Key Info (SPKI) of the certificates in its chain.
+//
+// A leading dot in the host, e.g. ".pximg.net", will also match any of its subdomains.
+var tlsPins map[string][]string
+
+// SetTlsPins loads the expected SPKI hashes per host from the given JSON file, e.g.
+//
+//	{
+//		"api.fanbox.cc": ["sha256/<base64 hash>", "<base64 hash of the backup key>"],
+//		".pximg.net": ["sha256/<base64 hash>"]
+//	}
+//
+// Connections to a pinned host will be aborted if none of the certificates in its verified chain matches the pins.
+// Hosts that are not in the file will not be pinned. Pass an empty string to disable the pinning.
+func SetTlsPins(pinFilePath string) error {
+	if pinFilePath == "" {
+		tlsPins = nil
+		return nil
+	}
+
+	data, err := os.ReadFile(pinFilePath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to read the TLS pin file %q, more info => %v",
+			utils.OS_ERROR,
+			pinFilePath,
+			err,
+		)
+	}
+
+	var pins map[string][]string
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to parse the TLS pin file %q, more info => %v",
+			utils.JSON_ERROR,
+			pinFilePath,
+			err,
+		)
+	}
+
+	tlsPins = make(map[string][]string, len(pins))
+	for host, hostPins := range pins {
+		if len(hostPins) == 0 {
+			continue
+		}
+
+		parsedPins := make([]string, 0, len(hostPins))
+		for _, pin := range hostPins {
+			pin = strings.TrimPrefix(strings.TrimSpace(pin), spkiPinPrefix)
+			hash, err := base64.StdEncoding.DecodeString(pin)
+			if err != nil || len(hash) != sha256.Size {
+				return fmt.Errorf(
+					"error %d: invalid TLS pin %q for %q, expected a base64-encoded SHA-256 hash of the SPKI",
+					utils.INPUT_ERROR,
+					pin,
+					host,
+				)
+			}
+			parsedPins = append(parsedPins, pin)
+		}
+		tlsPins[strings.ToLower(host)] = parsedPins
+	}
+	return nil
+}
+
+// Returns the pins of the host or nil if the host is not pinned
+func getHostPins(host string) []string {
+	var hostPins []string
+	for pinnedHost, pins := range tlsPins {
+		// a leading dot, e.g. ".pximg.net", also pins the subdomains of the host
+		if strings.EqualFold(host, pinnedHost) || (strings.HasPrefix(pinnedHost, ".") && strings.HasSuffix(strings.ToLower(host), pinnedHost)) {
+			hostPins = append(hostPins, pins...)
+		}
+	}
+	return hostPins
+}
+
+// Returns the base64-encoded SHA-256 hash of the certificate's SPKI
+func getSpkiHash(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// verifyTlsPins checks the verified certificate chains of the connection against the pins of the host.
+//
+// VerifyConnection is used instead of VerifyPeerCertificate as the same TLS config is used for
+// the redirected hosts, and only the former has access to the host that is being connected to.
+func verifyTlsPins(state tls.ConnectionState) error {
+	hostPins := getHostPins(state.ServerName)
+	if len(hostPins) == 0 {
+		return nil
+	}
+
+	for _, chain := range state.VerifiedChains {
+		for _, cert := range chain {
+			spkiHash := getSpkiHash(cert)
+			for _, pin := range hostPins {
+				if spkiHash == pin {
+					return nil
+				}
+			}
+		}
+	}
+
+	var receivedHash string
+	if len(state.PeerCertificates) > 0 {
+		receivedHash = getSpkiHash(state.PeerCertificates[0])
+	}
+	return fmt.Errorf(
+		"error %d: TLS certificate pin mismatch for %q, got %s%s which does not match the configured pins",
+		utils.CONNECTION_ERROR,
+		state.ServerName,
+		spkiPinPrefix,
+		receivedHash,
+	)
+}
+
+// Returns the TLS config for the http.Transport that enforces the pins or nil if the pinning is disabled
+func getTlsConfig() *tls.Config {
+	if len(tlsPins) == 0 {
+		return nil
+	}
+	return &tls.Config{
+		VerifyConnection: verifyTlsPins,
+	}
+}
@@ -0,0 +1,167 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// ipNetwork is the network to restrict the connections to.
+//
+// Can be "tcp4" or "tcp6", otherwise an empty string to allow both.
+var ipNetwork string
+
+// SetIpVersion restricts all connections to either IPv4 or IPv6.
+//
+// Note: Since HTTP/3 uses UDP, HTTP/2 will be used instead when an IP version is forced.
+func SetIpVersion(forceIpv4, forceIpv6 bool) error {
+	if forceIpv4 && forceIpv6 {
+		return fmt.Errorf(
+			"error %d: cannot use both the \"--force_ipv4\" and \"--force_ipv6\" flags",
+			utils.INPUT_ERROR,
+		)
+	}
+
+	if forceIpv4 {
+		ipNetwork = "tcp4"
+	} else if forceIpv6 {
+		ipNetwork = "tcp6"
+	} else {
+		ipNetwork = ""
+	}
+	return nil
+}
+
+var (
+	// dnsResolver is the custom resolver set by SetDnsServer, nil to use the system's resolver
+	dnsResolver *net.Resolver
+
+	// dnsServerAddr is the "host:port" address of the custom DNS server set by SetDnsServer
+	dnsServerAddr string
+)
+
+// SetDnsServer sets a custom DNS server, e.g. "1.1.1.1" or "[2606:4700:4700::1111]:53",
+// to resolve the hostnames with instead of the system's resolver.
+//
+// Note: Since the custom resolver is only used by the http.Transport, HTTP/2 will be used instead of HTTP/3.
+func SetDnsServer(dnsServer string) error {
+	if dnsServer == "" {
+		dnsResolver = nil
+		dnsServerAddr = ""
+		return nil
+	}
+
+	serverAddr := dnsServer
+	if net.ParseIP(serverAddr) != nil {
+		serverAddr = net.JoinHostPort(serverAddr, "53")
+	}
+	host, _, err := net.SplitHostPort(serverAddr)
+	if err != nil || net.ParseIP(host) == nil {
+		return fmt.Errorf(
+			"error %d: invalid DNS server %q, expected an IP address with an optional port, e.g. \"1.1.1.1\" or \"1.1.1.1:53\"",
+			utils.INPUT_ERROR,
+			dnsServer,
+		)
+	}
+
+	dnsDialer := &net.Dialer{
+		Timeout: utils.CONNECT_TIMEOUT * time.Second,
+	}
+	dnsServerAddr = serverAddr
+	dnsResolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dnsDialer.DialContext(ctx, network, serverAddr)
+		},
+	}
+	return nil
+}
+
+// getDialContext returns the DialContext function for the http.Transport
+// that restricts the network to the IP version set by SetIpVersion
+// and resolves the hostnames with the DNS server set by SetDnsServer.
+func getDialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   utils.CONNECT_TIMEOUT * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver:  dnsResolver,
+	}
+	if ipNetwork == "" {
+		return dialer.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, ipNetwork, addr)
+	}
+}
+
+// Returns true if the request has to be sent via the standard
+// http.Transport due to the user's network configurations.
+func requiresHttp2Transport(reqArgs *RequestArgs) bool {
+	return ipNetwork != "" || dnsResolver != nil || len(tlsPins) > 0 || getProxy(reqArgs.Url) != nil
+}
+
+// Returns a new http.Transport based on the user's network configurations
+func getHttp2Transport(reqArgs *RequestArgs) *http.Transport {
+	return &http.Transport{
+		Proxy:                 getProxyFunc(reqArgs.Url),
+		DialContext:           getDialContext(),
+		TLSClientConfig:       getTlsConfig(),
+		TLSHandshakeTimeout:   utils.TLS_HANDSHAKE_TIMEOUT * time.Second,
+		ResponseHeaderTimeout: utils.RESPONSE_HEADER_TIMEOUT * time.Second,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		MaxConnsPerHost:       maxConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		DisableCompression:    reqArgs.DisableCompression,
+	}
+}
+
+// errResponseHeaderTimeout is returned when the server did not send
+// the response headers in time which unlike the overall timeout can be retried.
+var errResponseHeaderTimeout = fmt.Errorf(
+	"timed out after %ds while waiting for the response headers",
+	utils.RESPONSE_HEADER_TIMEOUT,
+)
+
+// cancelOnCloseBody cancels the request's context once the response body has been closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Sends the request and cancels it if the response headers are not received within
+// the response header timeout, which applies to both HTTP/2 and HTTP/3 unlike the http.Transport setting.
+//
+// The reading of the response body will only be limited by the client's timeout
+// so that long downloads are not affected by a slow start of the connection.
+func doWithHeaderTimeout(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	headerTimer := time.AfterFunc(utils.RESPONSE_HEADER_TIMEOUT*time.Second, cancel)
+	res, err := client.Do(req.WithContext(ctx))
+	timedOut := !headerTimer.Stop()
+	if err != nil {
+		cancel()
+		if timedOut && req.Context().Err() == nil {
+			return nil, errResponseHeaderTimeout
+		}
+		return nil, err
+	}
+
+	res.Body = &cancelOnCloseBody{
+		ReadCloser: res.Body,
+		cancel:     cancel,
+	}
+	return res, nil
+}
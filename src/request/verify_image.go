@@ -0,0 +1,72 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"path/filepath"
+	"strings"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+)
+
+// errCorruptImage is returned when a downloaded image could not be decoded,
+// e.g. when the server responded with an error page with a 200 OK status code.
+var errCorruptImage = errors.New("downloaded image could not be decoded")
+
+var verifyImages bool
+
+// Image file extensions that can be decoded to be verified
+var verifiableImageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+}
+
+// SetVerifyImages sets whether the downloaded images should be decoded
+// to verify that they are not corrupted, which will re-download the ones that are.
+//
+// Note: Only applies to the JPEG, PNG, and GIF files.
+func SetVerifyImages(enabled bool) {
+	verifyImages = enabled
+}
+
+// Decodes the header of the downloaded image if enabled and removes the
+// file if it could not be decoded so that it can be downloaded again.
+func verifyImage(filePath string) error {
+	if !verifyImages {
+		return nil
+	}
+	if !verifiableImageExts[strings.ToLower(filepath.Ext(filePath))] {
+		return nil
+	}
+
+	file, err := dlStorage.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	_, _, decodeErr := image.DecodeConfig(file)
+	file.Close()
+	if decodeErr == nil {
+		return nil
+	}
+
+	if err := dlStorage.Remove(filePath); err != nil {
+		utils.LogError(
+			fmt.Errorf(
+				"error %d: failed to remove corrupted image %s, more info => %v",
+				utils.OS_ERROR,
+				filePath,
+				err,
+			),
+			"",
+			false,
+			utils.ERROR,
+		)
+	}
+	return fmt.Errorf("%w: %s, more info => %v", errCorruptImage, filePath, decodeErr)
+}
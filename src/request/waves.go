@@ -0,0 +1,77 @@
+package request
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KJHJason/Cultured-Downloader-CLI/configs"
+	"github.com/KJHJason/Cultured-Downloader-CLI/utils"
+	"github.com/fatih/color"
+)
+
+var (
+	// waveSize is the number of files to download per wave, 0 to download all files at once
+	waveSize  int
+	wavePause time.Duration
+)
+
+// SetDownloadWaves splits large batches of downloads into waves of the given number of files
+// with a pause in seconds between each wave so that the accounts are less likely to be flagged.
+//
+// Set the wave size to 0 to disable the waves.
+func SetDownloadWaves(size, pauseSeconds int) error {
+	if size < 0 {
+		return fmt.Errorf(
+			"error %d: wave size cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			size,
+		)
+	}
+	if pauseSeconds < 0 {
+		return fmt.Errorf(
+			"error %d: wave pause cannot be negative, got %d",
+			utils.INPUT_ERROR,
+			pauseSeconds,
+		)
+	}
+	waveSize = size
+	wavePause = time.Duration(pauseSeconds) * time.Second
+	return nil
+}
+
+// Downloads the files in waves of waveSize files with a pause between each wave
+// if the waves are enabled and returns the files that failed to download.
+func downloadInWaves(urlInfoSlice []*ToDownload, dlOptions *DlOptions, config *configs.Config, reqHandler RequestHandler, lastAttempt bool) []*ToDownload {
+	urlsLen := len(urlInfoSlice)
+	if waveSize <= 0 || urlsLen <= waveSize {
+		return downloadUrlsWithHandler(urlInfoSlice, dlOptions, config, reqHandler, lastAttempt)
+	}
+
+	var failed []*ToDownload
+	waveCount := (urlsLen + waveSize - 1) / waveSize
+	for wave := 0; wave < waveCount; wave++ {
+		if wave > 0 {
+			if diskFullErr.Load() != nil || maxTotalSizeReached() || IsShuttingDown() {
+				return failed
+			}
+			color.Yellow(
+				"\nWaiting %s before downloading the next wave of files [%d/%d]...",
+				wavePause,
+				wave+1,
+				waveCount,
+			)
+			clock.Sleep(wavePause)
+		}
+
+		end := (wave + 1) * waveSize
+		if end > urlsLen {
+			end = urlsLen
+		}
+		waveOptions := *dlOptions
+		failed = append(
+			failed,
+			downloadUrlsWithHandler(urlInfoSlice[wave*waveSize:end], &waveOptions, config, reqHandler, lastAttempt)...,
+		)
+	}
+	return failed
+}
@@ -1,7 +1,6 @@
 package spinner
 
 import (
-	"os"
 	"fmt"
 	"sync"
 	"time"
@@ -99,6 +98,7 @@ type Spinner struct {
 	count    int
 	maxCount int
 	active   bool
+	paused   bool
 	mu       *sync.RWMutex
 	stop     chan struct{}
 }
@@ -160,10 +160,14 @@ func (s *Spinner) Start() {
 						return
 					}
 
+					msg := s.Msg
+					if s.paused {
+						msg = "[Paused] " + msg
+					}
 					s.Colour.Printf(
 						"\r%s %s%s", 
 						frame, 
-						s.Msg, 
+						msg, 
 						CLEAR_LINE,
 					)
 					s.mu.Unlock()
@@ -189,6 +193,24 @@ func (s *Spinner) Add(i int) int {
 	return s.count
 }
 
+// SetMax changes the spinner's max count, e.g. when the total
+// number of items grows as more items are discovered.
+func (s *Spinner) SetMax(maxCount int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxCount = maxCount
+}
+
+// SetOutcomeMsgs changes the success and error messages printed when the spinner is stopped
+func (s *Spinner) SetOutcomeMsgs(successMsg, errMsg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.SuccessMsg = successMsg
+	s.ErrMsg = errMsg
+}
+
 // UpdateMsg changes the spinner message
 func (s *Spinner) UpdateMsg(msg string) {
 	s.mu.Lock()
@@ -197,6 +219,14 @@ func (s *Spinner) UpdateMsg(msg string) {
 	s.Msg = msg
 }
 
+// SetPaused sets whether the spinner should display a paused state
+func (s *Spinner) SetPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = paused
+}
+
 // MsgIncrement increments the spinner count and 
 // updates the message with the new count based onthe baseMsg.
 //
@@ -244,22 +274,21 @@ func (s *Spinner) Stop(hasErr bool) {
 	}
 }
 
-// KillProgram stops the spinner, 
-// prints the given message and exits the program with code 2.
+// KillProgram stops the spinner, prints the given message,
+// flushes the state via the shutdown hooks, and exits the program with code 2.
 //
 // Used for Ctrl + C interrupts.
 func (s *Spinner) KillProgram(msg string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if !s.active {
-		os.Exit(2)
+	if s.active {
+		s.stopSpinner()
+		color.Red(
+			"\r✗ %s%s\n",
+			msg,
+			CLEAR_LINE,
+		)
 	}
-
-	s.stopSpinner()
-	color.Red(
-		"\r✗ %s%s\n",
-		msg,
-		CLEAR_LINE,
-	)
-	os.Exit(2)
+	// the lock is released before exiting as the shutdown hooks may stop this spinner too
+	s.mu.Unlock()
+	utils.ExitGracefully(2)
 }
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IsPartFile returns true if the file is an incomplete download, i.e. a .part file or its resume validator
+func IsPartFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".part") || strings.HasSuffix(filePath, ".part.validator")
+}
+
+// Checks if the .part file or its resume validator belongs to an incomplete download
+// that has not been modified within the given duration.
+//
+// A recently modified .part file may still be resumed by another run of the program, e.g. a paused one.
+// A .part file without a validator, i.e. when the server sent no ETag or Last-Modified header,
+// and an orphaned validator without its .part file are only checked for their own age.
+func isStalePartFile(filePath string, olderThan time.Duration) bool {
+	partFilePath := strings.TrimSuffix(filePath, ".validator")
+	var lastModified time.Time
+	if validatorInfo, err := os.Stat(partFilePath + ".validator"); err == nil {
+		lastModified = validatorInfo.ModTime()
+	}
+	if partInfo, err := os.Stat(partFilePath); err == nil && partInfo.ModTime().After(lastModified) {
+		lastModified = partInfo.ModTime()
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	return time.Since(lastModified) >= olderThan
+}
+
+// Checks if the file is empty, e.g. left behind by a failed download,
+// and has not been modified within the given duration.
+//
+// Hidden files like ".nomedia" are kept as they are usually empty placeholder files on purpose.
+func isStaleEmptyFile(info os.FileInfo, olderThan time.Duration) bool {
+	if info.Size() != 0 || strings.HasPrefix(info.Name(), ".") {
+		return false
+	}
+	return time.Since(info.ModTime()) >= olderThan
+}
+
+// CleanDirectory walks the given directory and removes the empty files, the .part files, and their
+// resume validators left behind by incomplete downloads that have not been modified within the given duration.
+//
+// Hidden empty files like ".nomedia" are kept as they are usually placeholder files.
+// The subdirectories that cannot be read are skipped where all the errors
+// are returned after the rest of the directory has been cleaned.
+//
+// If dryRun is true, the files will only be reported and not removed.
+// Returns the paths of the files that were or would be removed.
+func CleanDirectory(dirPath string, olderThan time.Duration, dryRun bool) ([]string, error) {
+	var removedPaths []string
+	var errs []string
+	filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// skip the unreadable file or directory but continue with its siblings
+			errs = append(errs, err.Error())
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if IsPartFile(info.Name()) {
+			if !isStalePartFile(path, olderThan) {
+				return nil
+			}
+		} else if !isStaleEmptyFile(info, olderThan) {
+			return nil
+		}
+
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				errs = append(errs, err.Error())
+				return nil
+			}
+		}
+		removedPaths = append(removedPaths, path)
+		return nil
+	})
+	if len(errs) > 0 {
+		return removedPaths, fmt.Errorf(
+			"error %d: failed to clean up parts of %s, more info => %s",
+			OS_ERROR,
+			dirPath,
+			strings.Join(errs, "; "),
+		)
+	}
+	return removedPaths, nil
+}
@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCleanDirectory(t *testing.T) {
+	staleTime := time.Now().Add(-48 * time.Hour)
+	tests := []struct {
+		name        string
+		files       map[string]string // file name to content
+		staleFiles  []string          // files last modified before the threshold
+		dryRun      bool
+		wantRemoved []string
+	}{
+		{
+			name: "keeps hidden empty placeholder files",
+			files: map[string]string{
+				".nomedia": "",
+				".keep":    "",
+			},
+			staleFiles: []string{".nomedia", ".keep"},
+		},
+		{
+			name: "removes stale empty files",
+			files: map[string]string{
+				"empty.jpg": "",
+				"image.jpg": "image",
+			},
+			staleFiles:  []string{"empty.jpg", "image.jpg"},
+			wantRemoved: []string{"empty.jpg"},
+		},
+		{
+			name: "keeps recent empty files",
+			files: map[string]string{
+				"empty.jpg": "",
+			},
+		},
+		{
+			name: "removes a stale .part file and its validator",
+			files: map[string]string{
+				"image.jpg.part":           "partial",
+				"image.jpg.part.validator": `"etag"`,
+			},
+			staleFiles:  []string{"image.jpg.part", "image.jpg.part.validator"},
+			wantRemoved: []string{"image.jpg.part", "image.jpg.part.validator"},
+		},
+		{
+			name: "keeps a .part file that is still being resumed",
+			files: map[string]string{
+				"image.jpg.part":           "partial",
+				"image.jpg.part.validator": `"etag"`,
+			},
+			staleFiles: []string{"image.jpg.part.validator"},
+		},
+		{
+			name: "removes a stale .part file without a validator",
+			files: map[string]string{
+				"video.mp4.part": "partial",
+			},
+			staleFiles:  []string{"video.mp4.part"},
+			wantRemoved: []string{"video.mp4.part"},
+		},
+		{
+			name: "keeps a recent .part file without a validator",
+			files: map[string]string{
+				"video.mp4.part": "partial",
+			},
+		},
+		{
+			name: "removes a stale orphaned validator",
+			files: map[string]string{
+				"image.jpg.part.validator": `"etag"`,
+			},
+			staleFiles:  []string{"image.jpg.part.validator"},
+			wantRemoved: []string{"image.jpg.part.validator"},
+		},
+		{
+			name: "dry run keeps the files",
+			files: map[string]string{
+				"image.jpg.part":           "partial",
+				"image.jpg.part.validator": `"etag"`,
+			},
+			staleFiles:  []string{"image.jpg.part", "image.jpg.part.validator"},
+			dryRun:      true,
+			wantRemoved: []string{"image.jpg.part", "image.jpg.part.validator"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dirPath := t.TempDir()
+			for name, content := range test.files {
+				if err := os.WriteFile(filepath.Join(dirPath, name), []byte(content), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range test.staleFiles {
+				if err := os.Chtimes(filepath.Join(dirPath, name), staleTime, staleTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			removedPaths, err := CleanDirectory(dirPath, 24*time.Hour, test.dryRun)
+			if err != nil {
+				t.Fatalf("CleanDirectory() error = %v", err)
+			}
+
+			var removed []string
+			for _, removedPath := range removedPaths {
+				removed = append(removed, filepath.Base(removedPath))
+			}
+			sort.Strings(removed)
+			sort.Strings(test.wantRemoved)
+			if len(removed) != len(test.wantRemoved) {
+				t.Fatalf("removed = %q, want %q", removed, test.wantRemoved)
+			}
+			for i := range removed {
+				if removed[i] != test.wantRemoved[i] {
+					t.Fatalf("removed = %q, want %q", removed, test.wantRemoved)
+				}
+			}
+
+			for name := range test.files {
+				wantExists := test.dryRun || !SliceContains(test.wantRemoved, name)
+				if exists := PathExists(filepath.Join(dirPath, name)); exists != wantExists {
+					t.Errorf("%s exists = %v, want %v", name, exists, wantExists)
+				}
+			}
+		})
+	}
+}
@@ -40,14 +40,16 @@ func getAppPath() string {
 }
 
 const (
-	DEBUG_MODE                     = false // Will save a copy of all JSON response from the API
-	VERSION                        = "1.3.0"
-	MAX_RETRY_DELAY                = 3
-	MIN_RETRY_DELAY                = 1
-	RETRY_COUNTER                  = 4
-	MAX_CONCURRENT_DOWNLOADS       = 4
-	PIXIV_MAX_CONCURRENT_DOWNLOADS = 3
-	MAX_API_CALLS                  = 10
+	DEBUG_MODE                       = false // Will save a copy of all JSON response from the API
+	VERSION                          = "1.3.0"
+	MAX_RETRY_DELAY                  = 3
+	MIN_RETRY_DELAY                  = 1
+	RETRY_COUNTER                    = 4
+	MAX_CONCURRENT_DOWNLOADS         = 4
+	PIXIV_MAX_CONCURRENT_DOWNLOADS   = 3
+	MAX_API_CALLS                    = 10
+	DEFAULT_START_JITTER             = 300 // in milliseconds
+	DEFAULT_MAX_CONSECUTIVE_FAILURES = 50
 
 	PAGE_NUM_REGEX_STR = `[1-9]\d*(-[1-9]\d*)?`
 	DOWNLOAD_TIMEOUT   = 25 * 60 // 25 minutes in seconds as downloads
@@ -55,6 +57,37 @@ const (
 	// However, the average max file size on these platforms is around 300MB.
 	// Note: Fantia do have a max file size per post of 3GB if one paid extra for it.
 
+	// For the adaptive download timeout based on the file size (in seconds)
+	BASE_DOWNLOAD_TIMEOUT = 30
+	MIN_DOWNLOAD_TIMEOUT  = 60
+	MAX_DOWNLOAD_TIMEOUT  = 4 * 60 * 60
+	DEFAULT_MIN_DL_SPEED  = 256 // in KB/s
+	DEFAULT_STALL_TIMEOUT = 60  // in seconds
+
+	DEFAULT_FREE_SPACE_TIMEOUT = 30 * 60 // in seconds
+	DEFAULT_WAVE_PAUSE         = 60      // in seconds
+	POST_RETRY_DELAY           = 30      // in seconds, multiplied by the retry attempt
+
+	// Timeouts (in seconds) for establishing the connection and receiving the response headers
+	// which are kept short so that connection problems fail fast unlike the timeout for the whole request
+	CONNECT_TIMEOUT         = 15
+	TLS_HANDSHAKE_TIMEOUT   = 15
+	RESPONSE_HEADER_TIMEOUT = 60
+
+	// Defaults for the connection pool of the shared http.Transport
+	DEFAULT_MAX_IDLE_CONNS          = 100
+	DEFAULT_MAX_IDLE_CONNS_PER_HOST = 16
+	DEFAULT_IDLE_CONN_TIMEOUT       = 90 // in seconds
+
+	DEFAULT_MAX_RESPONSE_SIZE = 64           // in MB, for the API and metadata responses read into memory
+	DEFAULT_DATE_FORMAT       = "2006-01-02" // ISO 8601, for the dates in the output template
+	DEFAULT_MAX_REDIRECTS     = 10
+	DEFAULT_CLEAN_OLDER_THAN  = 24 // in hours, for the stale .part files removed by the clean command
+
+	// For the graceful shutdown on interrupts (in seconds)
+	SHUTDOWN_GRACE_PERIOD = 5
+	SHUTDOWN_TIMEOUT      = 10
+
 	FANTIA               = "fantia"
 	FANTIA_TITLE         = "Fantia"
 	FANTIA_URL           = "https://fantia.jp"
@@ -83,10 +116,10 @@ const (
 	ATTACHMENT_FOLDER = "attachments"
 	IMAGES_FOLDER     = "images"
 
-	KEMONO_EMBEDS_FOLDER   = "embeds"
-	KEMONO_CONTENT_FOLDER  = "post_content"
+	KEMONO_EMBEDS_FOLDER  = "embeds"
+	KEMONO_CONTENT_FOLDER = "post_content"
 
-	GDRIVE_URL 	         = "https://drive.google.com"
+	GDRIVE_URL           = "https://drive.google.com"
 	GDRIVE_FOLDER        = "gdrive"
 	GDRIVE_FILENAME      = "detected_gdrive_links.txt"
 	OTHER_LINKS_FILENAME = "detected_external_links.txt"
@@ -109,8 +142,8 @@ var (
 	PAGE_NUM_REGEX = regexp.MustCompile(
 		fmt.Sprintf(`^%s$`, PAGE_NUM_REGEX_STR),
 	)
-	NUMBER_REGEX             = regexp.MustCompile(`^\d+$`)
-	GDRIVE_URL_REGEX         = regexp.MustCompile(
+	NUMBER_REGEX     = regexp.MustCompile(`^\d+$`)
+	GDRIVE_URL_REGEX = regexp.MustCompile(
 		`https://drive\.google\.com/(?P<type>file/d|drive/(u/\d+/)?folders)/(?P<id>[\w-]+)`,
 	)
 	GDRIVE_REGEX_ID_INDEX   = GDRIVE_URL_REGEX.SubexpIndex("id")
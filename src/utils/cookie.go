@@ -56,7 +56,9 @@ func GetSessionCookieInfo(site string) *cookieInfo {
 }
 
 // For the exported cookies in JSON instead of Netscape format
-type ExportedCookies []struct {
+type ExportedCookies []ExportedCookie
+
+type ExportedCookie struct {
 	Domain   string  `json:"domain"`
 	Expire   float64 `json:"expirationDate"`
 	HttpOnly bool    `json:"httpOnly"`
@@ -68,10 +70,19 @@ type ExportedCookies []struct {
 }
 
 type cookieInfoArgs struct {
+	site     string
 	name     string
 	sameSite http.SameSite
 }
 
+// Returns true if the cookie should be parsed where all the cookies are parsed if no cookie name is given
+func (args *cookieInfoArgs) matches(name, domain string) bool {
+	if args.name == "" {
+		return true
+	}
+	return name == args.name && IsPlatformDomain(args.site, domain)
+}
+
 func parseTxtCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs) ([]*http.Cookie, error) {
 	var cookies []*http.Cookie
 	reader := bufio.NewReader(f)
@@ -101,7 +112,7 @@ func parseTxtCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs)
 		}
 
 		cookieName := cookieInfos[5]
-		if cookieName != cookieArgs.name {
+		if !cookieArgs.matches(cookieName, cookieInfos[0]) {
 			continue // not the session cookie
 		}
 
@@ -152,8 +163,8 @@ func parseJsonCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs
 	}
 
 	for _, cookie := range exportedCookies {
-		if cookie.Name != cookieArgs.name {
-			// not the session cookie
+		if !cookieArgs.matches(cookie.Name, cookie.Domain) {
+			// not the session cookie, e.g. a cookie of the same name from another website
 			continue
 		}
 
@@ -175,19 +186,8 @@ func parseJsonCookieFile(f *os.File, filePath string, cookieArgs *cookieInfoArgs
 	return cookies, nil
 }
 
-// parse the Netscape cookie file generated by extensions like Get cookies.txt LOCALLY
-func ParseNetscapeCookieFile(filePath, sessionId, website string) ([]*http.Cookie, error) {
-	if filePath != "" && sessionId != "" {
-		return nil, fmt.Errorf(
-			"error %d: cannot use both cookie file and session id flags",
-			INPUT_ERROR,
-		)
-	}
-
-	sessionCookieInfo := GetSessionCookieInfo(website)
-	sessionCookieName := sessionCookieInfo.Name
-	sessionCookieSameSite := sessionCookieInfo.SameSite
-
+// Parses the cookies matching the cookie args from the Netscape or JSON cookie file based on its file extension
+func parseCookieFile(filePath string, cookieArgs *cookieInfoArgs) ([]*http.Cookie, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf(
@@ -199,24 +199,47 @@ func ParseNetscapeCookieFile(filePath, sessionId, website string) ([]*http.Cooki
 	}
 	defer f.Close()
 
-	cookieArgs := &cookieInfoArgs{
-		name:     sessionCookieName,
-		sameSite: sessionCookieSameSite,
-	}
-	var cookies []*http.Cookie
-	if ext := filepath.Ext(filePath); ext == ".txt" {
-		cookies, err = parseTxtCookieFile(f, filePath, cookieArgs)
-	} else if ext == ".json" {
-		cookies, err = parseJsonCookieFile(f, filePath, cookieArgs)
-	} else {
-		err = fmt.Errorf(
+	switch ext := filepath.Ext(filePath); ext {
+	case ".txt":
+		return parseTxtCookieFile(f, filePath, cookieArgs)
+	case ".json":
+		return parseJsonCookieFile(f, filePath, cookieArgs)
+	default:
+		return nil, fmt.Errorf(
 			"error %d: invalid cookie file extension, %q, at %s...\nOnly .txt and .json files are supported",
 			INPUT_ERROR,
 			ext,
 			filePath,
 		)
 	}
+}
+
+// ParseCookieFile parses all the cookies in the Netscape or JSON cookie file regardless of their website
+func ParseCookieFile(filePath string) ([]*http.Cookie, error) {
+	return parseCookieFile(filePath, &cookieInfoArgs{})
+}
 
+// parse the Netscape cookie file generated by extensions like Get cookies.txt LOCALLY
+func ParseNetscapeCookieFile(filePath, sessionId, website string) ([]*http.Cookie, error) {
+	if filePath != "" && sessionId != "" {
+		return nil, fmt.Errorf(
+			"error %d: cannot use both cookie file and session id flags",
+			INPUT_ERROR,
+		)
+	}
+
+	sessionCookieInfo := GetSessionCookieInfo(website)
+	sessionCookieName := sessionCookieInfo.Name
+	sessionCookieSameSite := sessionCookieInfo.SameSite
+
+	cookies, err := parseCookieFile(
+		filePath,
+		&cookieInfoArgs{
+			site:     website,
+			name:     sessionCookieName,
+			sameSite: sessionCookieSameSite,
+		},
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -231,3 +254,29 @@ func ParseNetscapeCookieFile(filePath, sessionId, website string) ([]*http.Cooki
 	}
 	return cookies, nil
 }
+
+// Reads the session cookie value from the given file.
+//
+// The file should only contain the session value and any
+// leading or trailing whitespaces and newlines will be trimmed.
+func ReadSessionFile(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf(
+			"error %d: failed to read session file at %s, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+
+	session := strings.TrimSpace(string(data))
+	if session == "" {
+		return "", fmt.Errorf(
+			"error %d: session file at %s is empty",
+			INPUT_ERROR,
+			filePath,
+		)
+	}
+	return session, nil
+}
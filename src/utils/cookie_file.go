@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FormatNetscapeCookies formats the cookies in the Netscape cookie file format
+// where the cookies without a domain will be set to the default domain.
+func FormatNetscapeCookies(cookies []*http.Cookie, defaultDomain string) string {
+	var sb strings.Builder
+	sb.WriteString("# Netscape HTTP Cookie File\n")
+	for _, cookie := range cookies {
+		domain := cookie.Domain
+		if domain == "" {
+			domain = defaultDomain
+		}
+		includeSubdomains := "FALSE"
+		if strings.HasPrefix(domain, ".") {
+			includeSubdomains = "TRUE"
+		}
+		cookiePath := cookie.Path
+		if cookiePath == "" {
+			cookiePath = "/"
+		}
+		secure := "FALSE"
+		if cookie.Secure {
+			secure = "TRUE"
+		}
+		var expiry int64
+		if !cookie.Expires.IsZero() {
+			expiry = cookie.Expires.Unix()
+		}
+		fmt.Fprintf(
+			&sb,
+			"%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			includeSubdomains,
+			cookiePath,
+			secure,
+			expiry,
+			cookie.Name,
+			cookie.Value,
+		)
+	}
+	return sb.String()
+}
+
+// WriteCookieFile writes the cookies to the file in the Netscape or JSON cookie file format based on its file extension
+func WriteCookieFile(filePath string, cookies []*http.Cookie) error {
+	var data []byte
+	switch ext := filepath.Ext(filePath); ext {
+	case ".txt":
+		data = []byte(FormatNetscapeCookies(cookies, ""))
+	case ".json":
+		exportedCookies := make(ExportedCookies, 0, len(cookies))
+		for _, cookie := range cookies {
+			exportedCookie := ExportedCookie{
+				Domain:   cookie.Domain,
+				HttpOnly: cookie.HttpOnly,
+				Name:     cookie.Name,
+				Path:     cookie.Path,
+				Secure:   cookie.Secure,
+				Value:    cookie.Value,
+				Session:  cookie.Expires.IsZero(),
+			}
+			if !exportedCookie.Session {
+				exportedCookie.Expire = float64(cookie.Expires.Unix())
+			}
+			exportedCookies = append(exportedCookies, exportedCookie)
+		}
+
+		var err error
+		data, err = json.MarshalIndent(exportedCookies, "", "    ")
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: failed to encode the cookies to JSON, more info => %v",
+				JSON_ERROR,
+				err,
+			)
+		}
+	default:
+		return fmt.Errorf(
+			"error %d: invalid cookie file extension, %q, at %s...\nOnly .txt and .json files are supported",
+			INPUT_ERROR,
+			ext,
+			filePath,
+		)
+	}
+
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to write cookie file at %s, more info => %v",
+			OS_ERROR,
+			filePath,
+			err,
+		)
+	}
+	return nil
+}
+
+// MergeCookies merges the cookies, deduplicating them by their domain and name
+// where the cookie with the latest expiry is kept, or the later one if they expire at the same time.
+//
+// Session cookies without an expiry are treated as expiring before any other cookie.
+func MergeCookies(cookieLists ...[]*http.Cookie) []*http.Cookie {
+	var merged []*http.Cookie
+	indices := make(map[string]int)
+	for _, cookies := range cookieLists {
+		for _, cookie := range cookies {
+			key := normaliseDomain(cookie.Domain) + "\t" + cookie.Name
+			idx, ok := indices[key]
+			if !ok {
+				indices[key] = len(merged)
+				merged = append(merged, cookie)
+				continue
+			}
+			if !cookie.Expires.Before(merged[idx].Expires) {
+				merged[idx] = cookie
+			}
+		}
+	}
+	return merged
+}
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -70,6 +72,57 @@ func CleanPathName(pathName string) string {
 	return strings.Map(removeIllegalRuneInPath, pathName)
 }
 
+// Moves the file from src to dest.
+//
+// If renaming fails, e.g. when moving across devices (EXDEV),
+// the file will be copied to dest and then deleted from src.
+func MoveFile(src, dest string) error {
+	renameErr := os.Rename(src, dest)
+	if renameErr == nil {
+		return nil
+	}
+
+	if err := copyFile(src, dest); err != nil {
+		os.Remove(dest)
+		return fmt.Errorf(
+			"error %d: failed to move file from %s to %s, more info => %v, %v",
+			OS_ERROR,
+			src,
+			dest,
+			renameErr,
+			err,
+		)
+	}
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to remove file at %s after copying, more info => %v",
+			OS_ERROR,
+			src,
+			err,
+		)
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	destFile, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(destFile, srcFile); err != nil {
+		destFile.Close()
+		return err
+	}
+	return destFile.Close()
+}
+
 // Returns a directory path for a post, artwork, etc.
 // based on the user's saved download path and the provided arguments
 func GetPostFolder(downloadPath, creatorName, postId, postTitle string) string {
@@ -84,9 +137,120 @@ func GetPostFolder(downloadPath, creatorName, postId, postTitle string) string {
 	return postFolderPath
 }
 
+// Returns a file path that mirrors the host and path components of the given URL.
+//
+// E.g. "https://downloads.fanbox.cc/images/post/123/abc.jpg" -> "<downloadPath>/downloads.fanbox.cc/images/post/123/abc.jpg"
+// If the last path component has no file extension, only its parent directory path will be
+// returned so that the filename can be determined from the response instead.
+func GetMirrorFilePath(downloadPath, rawUrl string) (string, error) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", fmt.Errorf(
+			"error %d: failed to parse URL for mirroring, more info => %v\nurl: %s",
+			UNEXPECTED_ERROR,
+			err,
+			rawUrl,
+		)
+	}
+
+	pathComponents := []string{downloadPath, CleanPathName(parsedUrl.Hostname())}
+	for _, component := range strings.Split(parsedUrl.Path, "/") {
+		component = CleanPathName(component)
+		if component == "" || component == "." || component == ".." {
+			continue
+		}
+		pathComponents = append(pathComponents, component)
+	}
+
+	mirrorPath := filepath.Join(pathComponents...)
+	if len(pathComponents) > 2 && filepath.Ext(mirrorPath) == "" {
+		return filepath.Dir(mirrorPath), nil
+	}
+	return mirrorPath, nil
+}
+
 type ConfigFile struct {
 	DownloadDir string `json:"download_directory"`
 	Language    string `json:"language"`
+
+	// Proxies maps a platform, e.g. "fantia", to the proxy URL to use for it.
+	// It overrides the global proxy set via the --proxy flag.
+	Proxies map[string]string `json:"proxies,omitempty"`
+
+	// PlatformDomains maps a platform, e.g. "pixiv", to the extra domains associated with it, e.g. of a new CDN.
+	// They are added to the built-in domains of the platform.
+	PlatformDomains map[string][]string `json:"platform_domains,omitempty"`
+}
+
+// Sets the application's config directory where all the persistent
+// files like the config file, logs, and caches will be stored.
+//
+// The directory will be created if it does not exist and the
+// download path will be reloaded from the config file in the new directory.
+func SetAppPath(appPath string) error {
+	if appPath == "" {
+		return nil
+	}
+
+	appPath, err := filepath.Abs(appPath)
+	if err != nil {
+		return fmt.Errorf(
+			"error %d: failed to get the absolute path of the config directory %q, more info => %v",
+			INPUT_ERROR,
+			appPath,
+			err,
+		)
+	}
+	if err := os.MkdirAll(appPath, 0700); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to create the config directory %q, more info => %v",
+			OS_ERROR,
+			appPath,
+			err,
+		)
+	}
+
+	APP_PATH = appPath
+	DOWNLOAD_PATH = GetDefaultDownloadPath()
+	resetMainLogger()
+	return nil
+}
+
+// Returns the proxies configured per platform from the config file
+func GetPlatformProxies() map[string]string {
+	config := readConfigFile()
+	if config == nil {
+		return nil
+	}
+	return config.Proxies
+}
+
+// Returns the extra domains of the platforms from the config file, if any
+func GetPlatformDomainsConfig() map[string][]string {
+	config := readConfigFile()
+	if config == nil {
+		return nil
+	}
+	return config.PlatformDomains
+}
+
+// Returns the parsed config file or nil if it does not exist or could not be read
+func readConfigFile() *ConfigFile {
+	configFilePath := filepath.Join(APP_PATH, "config.json")
+	if !PathExists(configFilePath) {
+		return nil
+	}
+
+	configFile, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil
+	}
+
+	var config ConfigFile
+	if err := json.Unmarshal(configFile, &config); err != nil {
+		return nil
+	}
+	return &config
 }
 
 // Returns the download path from the config file
@@ -195,7 +359,7 @@ func SetDefaultDownloadPath(newDownloadPath string) error {
 		return fmt.Errorf("error %d: download path does not exist, please create the directory and try again", INPUT_ERROR)
 	}
 
-	os.MkdirAll(APP_PATH, 0755)
+	os.MkdirAll(APP_PATH, 0700)
 	configFilePath := filepath.Join(APP_PATH, "config.json")
 	if !PathExists(configFilePath) {
 		return saveConfig(newDownloadPath, configFilePath)
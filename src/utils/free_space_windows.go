@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// Returns the free space in bytes that is available to
+// the user on the volume of the given directory path
+func GetFreeSpace(dirPath string) (int64, error) {
+	dirPathPtr, err := syscall.UTF16PtrFromString(dirPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var freeBytesAvailable uint64
+	ret, _, err := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(dirPathPtr)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		0,
+		0,
+	)
+	if ret == 0 {
+		return 0, err
+	}
+	return int64(freeBytesAvailable), nil
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Write the Google Drive links as Internet Shortcut files which can be opened by double-clicking them
+	GDRIVE_SAVE_LINKS_URL = "url"
+
+	// Write the Google Drive links as plain text files
+	GDRIVE_SAVE_LINKS_TXT = "txt"
+)
+
+var ACCEPTED_GDRIVE_SAVE_LINKS = []string{
+	GDRIVE_SAVE_LINKS_URL,
+	GDRIVE_SAVE_LINKS_TXT,
+}
+
+// gdriveSaveLinks is the file format of the Google Drive link shortcuts, empty if disabled
+var gdriveSaveLinks string
+
+// SetGdriveSaveLinks sets the file format of the shortcuts to write for every detected Google Drive link
+// in the post's folder, regardless of whether the Google Drive files are downloaded. Set it to empty to disable it.
+func SetGdriveSaveLinks(format string) error {
+	format = strings.TrimPrefix(strings.ToLower(format), ".")
+	if format != "" && !SliceContains(ACCEPTED_GDRIVE_SAVE_LINKS, format) {
+		return fmt.Errorf(
+			"error %d: invalid Google Drive link shortcut format %q, expected one of %s",
+			INPUT_ERROR,
+			format,
+			strings.Join(ACCEPTED_GDRIVE_SAVE_LINKS, ", "),
+		)
+	}
+	gdriveSaveLinks = format
+	return nil
+}
+
+// Writes a shortcut file for every Google Drive link in the text to the post's folder
+// so that the links are kept even if the files could not be downloaded, e.g. without a Google Drive API key.
+//
+// Existing shortcuts will not be overwritten as they are named after the Google Drive file or folder ID.
+func saveGdriveShortcuts(text, postFolderPath string) {
+	if gdriveSaveLinks == "" {
+		return
+	}
+
+	for _, matched := range GDRIVE_URL_REGEX.FindAllStringSubmatch(text, -1) {
+		gdriveType := "file"
+		if strings.Contains(matched[GDRIVE_REGEX_TYPE_INDEX], "folders") {
+			gdriveType = "folder"
+		}
+
+		var content string
+		if gdriveSaveLinks == GDRIVE_SAVE_LINKS_URL {
+			content = fmt.Sprintf("[InternetShortcut]\r\nURL=%s\r\n", matched[0])
+		} else {
+			content = matched[0] + "\n"
+		}
+
+		shortcutPath := filepath.Join(
+			postFolderPath,
+			GDRIVE_FOLDER,
+			fmt.Sprintf("gdrive_%s_%s.%s", gdriveType, matched[GDRIVE_REGEX_ID_INDEX], gdriveSaveLinks),
+		)
+		if sidecarStorage.Exists(shortcutPath) {
+			continue
+		}
+
+		if err := sidecarStorage.WriteFile(shortcutPath, []byte(content)); err != nil {
+			err = fmt.Errorf(
+				"error %d: failed to write Google Drive link shortcut, more info => %v\nfile path: %s",
+				OS_ERROR,
+				err,
+				shortcutPath,
+			)
+			LogError(err, "", false, ERROR)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"html"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var htmlTitleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// IsHtmlPage checks if the response is an HTML page based on its Content-Type header
+// or by sniffing the first bytes of its body, e.g. a "post not found" or "login required" page
+// that was returned with a 200 OK status code instead of the expected JSON or file.
+func IsHtmlPage(contentType string, head []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+			return true
+		}
+	}
+	if len(head) == 0 {
+		return false
+	}
+	return strings.HasPrefix(http.DetectContentType(head), "text/html")
+}
+
+// GetHtmlPageTitle returns the title of the HTML page, if any, to describe the page in the error messages
+func GetHtmlPageTitle(body []byte) string {
+	matches := htmlTitleRegex.FindSubmatch(body)
+	if len(matches) < 2 {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(matches[1]))), " ")
+}
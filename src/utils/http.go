@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"path/filepath"
@@ -42,11 +45,82 @@ func GetLastPartOfUrl(url string) string {
 	return splittedUrl[len(splittedUrl)-1]
 }
 
+// Max number of times to decode a filename to handle
+// double-encoded filenames like "%2520" or "&amp;amp;"
+const maxFilenameDecodeRounds = 3
+
+// DecodeFilename decodes the percent-encoded sequences, e.g. "%20", and the HTML entities,
+// e.g. "&amp;", in the filename until there is nothing left to decode or the max rounds is reached.
+//
+// Illegal characters that were decoded, e.g. "/" from "%2F", will be replaced with "-".
+func DecodeFilename(filename string) string {
+	for i := 0; i < maxFilenameDecodeRounds; i++ {
+		decoded := html.UnescapeString(filename)
+		if unescaped, err := url.PathUnescape(decoded); err == nil {
+			// a filename with a literal "%" like "100%.jpg" is not URL-encoded
+			decoded = unescaped
+		}
+		if decoded == filename {
+			break
+		}
+		filename = decoded
+	}
+	return CleanPathName(filename)
+}
+
+// Returns the decoded filename from the response's Content-Disposition header
+// or an empty string if the header does not contain a filename.
+func GetContentDispositionFilename(res *http.Response) string {
+	contentDisposition := res.Header.Get("Content-Disposition")
+	if contentDisposition == "" {
+		return ""
+	}
+
+	// Note: mime.ParseMediaType also decodes the RFC 5987 "filename*" parameter
+	_, params, err := mime.ParseMediaType(contentDisposition)
+	if err != nil || params["filename"] == "" {
+		return ""
+	}
+	return DecodeFilename(filepath.Base(params["filename"]))
+}
+
 // Returns the path without the file extension
 func RemoveExtFromFilename(filename string) string {
 	return strings.TrimSuffix(filename, filepath.Ext(filename))
 }
 
+var extAliases = map[string]string{
+	".jpeg": ".jpg",
+	".jpe":  ".jpg",
+	".jfif": ".jpg",
+}
+
+// Returns the lowercased extension of the filename with any aliases resolved, e.g. ".JPEG" -> ".jpg"
+func normaliseExt(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if alias, ok := extAliases[ext]; ok {
+		return alias
+	}
+	return ext
+}
+
+// Returns the filename with a normalised file extension.
+//
+// E.g. "image.JPEG" -> "image.jpg" and "image.jpg.Jpeg" -> "image.jpg"
+func NormaliseFileExt(filename string) string {
+	ext := normaliseExt(filename)
+	if ext == "" {
+		return filename
+	}
+
+	filenameWithoutExt := RemoveExtFromFilename(filename)
+	for normaliseExt(filenameWithoutExt) == ext {
+		// remove redundant double extensions
+		filenameWithoutExt = RemoveExtFromFilename(filenameWithoutExt)
+	}
+	return filenameWithoutExt + ext
+}
+
 // Converts a map of string back to a string
 func ParamsToString(params map[string]string) string {
 	paramsStr := ""
@@ -56,10 +130,61 @@ func ParamsToString(params map[string]string) string {
 	return paramsStr[:len(paramsStr)-1] // remove the last &
 }
 
+// ErrResponseTooLarge is returned when the response body is larger than the max response size
+var ErrResponseTooLarge = errors.New("response body exceeds the max response size set by the \"--max_response_size\" flag")
+
+// maxResponseSize is the max size in bytes of the API and metadata responses to read into memory, 0 for no limit
+var maxResponseSize int64 = DEFAULT_MAX_RESPONSE_SIZE * 1024 * 1024
+
+// SetMaxResponseSize sets the max size in MB of the API and metadata responses
+// that will be read into memory. Set it to 0 to disable the limit.
+//
+// Note: The downloaded files are streamed to the disk and are not affected by this limit.
+func SetMaxResponseSize(sizeInMb int) error {
+	if sizeInMb < 0 {
+		return fmt.Errorf(
+			"error %d: max response size cannot be negative, got %d",
+			INPUT_ERROR,
+			sizeInMb,
+		)
+	}
+	maxResponseSize = int64(sizeInMb) * 1024 * 1024
+	return nil
+}
+
+type limitedResBody struct {
+	body      io.Reader
+	remaining int64
+}
+
+func (l *limitedResBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.body.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// LimitResBody returns a reader of the response body that returns ErrResponseTooLarge
+// once more than the max response size has been read instead of buffering unbounded data.
+func LimitResBody(res *http.Response) io.Reader {
+	if maxResponseSize == 0 {
+		return res.Body
+	}
+	return &limitedResBody{body: res.Body, remaining: maxResponseSize}
+}
+
 // Reads and returns the response body in bytes and closes it
 func ReadResBody(res *http.Response) ([]byte, error) {
 	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
+	body, err := io.ReadAll(LimitResBody(res))
 	if err != nil {
 		return nil, fmt.Errorf(
 			"error %d: failed to read response body from %s due to %v",
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormaliseFileExt(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"image.jpg", "image.jpg"},
+		{"image.JPG", "image.jpg"},
+		{"image.jpeg", "image.jpg"},
+		{"image.Jpeg", "image.jpg"},
+		{"image.JPEG", "image.jpg"},
+		{"image.jpe", "image.jpg"},
+		{"image.jfif", "image.jpg"},
+		{"image.PNG", "image.png"},
+		{"image.jpg.jpg", "image.jpg"},
+		{"image.jpg.Jpeg", "image.jpg"},
+		{"image.jpeg.jpg.JPG", "image.jpg"},
+		{"image.png.png", "image.png"},
+		{"image.jpg.png", "image.jpg.png"},
+		{"archive.tar.gz", "archive.tar.gz"},
+		{"README", "README"},
+		{filepath.Join("post", "image.JPEG"), filepath.Join("post", "image.jpg")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			if got := NormaliseFileExt(test.filename); got != test.want {
+				t.Errorf("NormaliseFileExt(%q) = %q, want %q", test.filename, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+	}{
+		{"percent-encoded space", "my%20file.jpg", "my file.jpg"},
+		{"double-encoded space is decoded once into a single space", "my%2520file.jpg", "my file.jpg"},
+		{"html entity", "Tom&amp;Jerry.png", "Tom&Jerry.png"},
+		{"double-escaped html entity", "Tom&amp;amp;Jerry.png", "Tom&Jerry.png"},
+		{"mixed percent-encoding and html entities", "Tom%20&amp;%2520Jerry.png", "Tom & Jerry.png"},
+		{"html-escaped percent-encoding", "50&#37;2520off.jpg", "50 off.jpg"},
+		{"percent-encoded html entity", "Tom%26amp%3BJerry.png", "Tom&Jerry.png"},
+		{"literal percent sign", "100%.jpg", "100%.jpg"},
+		{"decoded illegal characters are replaced", "a%2Fb%3F.jpg", "a-b-.jpg"},
+		{"non-ascii characters", "%E7%94%BB%E5%83%8F.png", "画像.png"},
+		{"nothing to decode", "image.jpg", "image.jpg"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DecodeFilename(test.filename); got != test.want {
+				t.Errorf("DecodeFilename(%q) = %q, want %q", test.filename, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetContentDispositionFilename(t *testing.T) {
+	tests := []struct {
+		name               string
+		contentDisposition string
+		want               string
+	}{
+		{"no header", "", ""},
+		{"no filename", "inline", ""},
+		{"plain filename", `attachment; filename="image.jpg"`, "image.jpg"},
+		{"html entity", `attachment; filename="Tom &amp; Jerry.jpg"`, "Tom & Jerry.jpg"},
+		{"double-encoded filename", `attachment; filename="my%2520file.jpg"`, "my file.jpg"},
+		{"rfc 5987 filename", `attachment; filename*=UTF-8''%E7%94%BB%E5%83%8F.png`, "画像.png"},
+		{"path in the filename", `attachment; filename="../../etc/passwd"`, "passwd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if test.contentDisposition != "" {
+				res.Header.Set("Content-Disposition", test.contentDisposition)
+			}
+			if got := GetContentDispositionFilename(res); got != test.want {
+				t.Errorf("GetContentDispositionFilename() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
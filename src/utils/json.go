@@ -54,6 +54,14 @@ func LoadJsonFromResponse(res *http.Response, format any) error {
 	}
 
 	if err = json.Unmarshal(body, &format); err != nil {
+		if IsHtmlPage(res.Header.Get("Content-Type"), body) {
+			return fmt.Errorf(
+				"error %d: received an HTML page titled %q instead of JSON from %s, the post may not exist or requires you to be logged in",
+				RESPONSE_ERROR,
+				GetHtmlPageTitle(body),
+				res.Request.URL.String(),
+			)
+		}
 		return fmt.Errorf(
 			"error %d: failed to unmarshal json response from %s due to %v\nBody: %s",
 			RESPONSE_ERROR,
@@ -76,3 +84,22 @@ func LoadJsonFromBytes(body []byte, format any) error {
 	}
 	return nil
 }
+
+// prettyJson is whether the JSON files saved by the program will be indented
+var prettyJson = true
+
+// SetPrettyJson sets whether the JSON files saved by the program will be indented or compact
+func SetPrettyJson(pretty bool) {
+	prettyJson = pretty
+}
+
+// Marshals the value into JSON which will be indented unless disabled by the user.
+//
+// Note: Map keys are sorted and struct fields follow their declaration
+// order so the output is stable for diffing across runs.
+func MarshalJson(v any) ([]byte, error) {
+	if prettyJson {
+		return json.MarshalIndent(v, "", "    ")
+	}
+	return json.Marshal(v)
+}
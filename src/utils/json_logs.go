@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// jsonLogEntry is a structured log entry written as a single line of JSON to stdout
+type jsonLogEntry struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Info    string `json:"info,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Url     string `json:"url,omitempty"`
+	PostId  string `json:"postId,omitempty"`
+	Status  int    `json:"status,omitempty"`
+}
+
+var (
+	jsonLogs   bool
+	jsonLogsMu sync.Mutex
+
+	// jsonLogsOutput is where the JSON log entries are written to
+	jsonLogsOutput io.Writer = os.Stdout
+)
+
+// LogFields are the contextual fields of a JSON log entry which
+// are given by the call sites instead of being parsed from the message.
+type LogFields struct {
+	Code   int
+	Url    string
+	PostId string
+	Status int
+}
+
+// Sets the fields that are not set yet to the ones of the other fields
+func (f *LogFields) merge(other LogFields) {
+	if f.Code == 0 {
+		f.Code = other.Code
+	}
+	if f.Url == "" {
+		f.Url = other.Url
+	}
+	if f.PostId == "" {
+		f.PostId = other.PostId
+	}
+	if f.Status == 0 {
+		f.Status = other.Status
+	}
+}
+
+// LogFielder is implemented by the errors that carry the contextual fields of their JSON log entry
+type LogFielder interface {
+	LogFields() LogFields
+}
+
+// FieldsError is an error with the contextual fields of its JSON log entry, see WithLogFields
+type FieldsError struct {
+	Err    error
+	Fields LogFields
+}
+
+func (e *FieldsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldsError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FieldsError) LogFields() LogFields {
+	return e.Fields
+}
+
+// WithLogFields wraps the error with the contextual fields of its JSON log entry
+// like the URL, post ID, and status code. Returns nil if the error is nil.
+func WithLogFields(err error, fields LogFields) error {
+	if err == nil {
+		return nil
+	}
+	return &FieldsError{Err: err, Fields: fields}
+}
+
+// Returns the contextual fields of the error's chain where the outer errors take precedence
+func getErrLogFields(err error) LogFields {
+	var fields LogFields
+	for ; err != nil; err = errors.Unwrap(err) {
+		if fielder, ok := err.(LogFielder); ok {
+			fields.merge(fielder.LogFields())
+		}
+	}
+	return fields
+}
+
+var logLevelNames = map[int]string{
+	INFO:  "info",
+	ERROR: "error",
+	DEBUG: "debug",
+}
+
+// SetJsonLogs sets whether the logs should be written to stdout as JSON objects, one per line,
+// instead of the human-readable log file for log collectors like the ones used with Docker or Kubernetes.
+//
+// The coloured console output, e.g. the progress spinners, will be written to stderr instead
+// so that stdout only contains the JSON log entries.
+func SetJsonLogs(enabled bool) {
+	jsonLogsMu.Lock()
+	defer jsonLogsMu.Unlock()
+
+	jsonLogs = enabled
+	if enabled {
+		color.Output = os.Stderr
+		color.NoColor = true
+	}
+}
+
+// JsonLogsEnabled returns true if the logs are written to stdout as JSON
+func JsonLogsEnabled() bool {
+	jsonLogsMu.Lock()
+	defer jsonLogsMu.Unlock()
+	return jsonLogs
+}
+
+// Writes the log entry to stdout as a single line of JSON with the given contextual fields
+func writeJsonLog(level int, msg, info string, fields LogFields) {
+	entry := jsonLogEntry{
+		Time:    time.Now().Format(time.RFC3339),
+		Level:   logLevelNames[level],
+		Message: strings.TrimSpace(msg),
+		Info:    strings.TrimSpace(info),
+		Code:    fields.Code,
+		Url:     fields.Url,
+		PostId:  fields.PostId,
+		Status:  fields.Status,
+	}
+
+	jsonLogsMu.Lock()
+	defer jsonLogsMu.Unlock()
+	encoder := json.NewEncoder(jsonLogsOutput)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(entry) // already appends a newline
+}
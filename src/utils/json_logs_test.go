@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestJsonLogFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		msg    string
+		fields LogFields
+		want   LogFields
+	}{
+		{
+			name: "fields of the error",
+			err:  WithLogFields(errors.New("failed to get the post"), LogFields{Code: CONNECTION_ERROR, Url: "https://example.com/api/post", PostId: "123", Status: 404}),
+			want: LogFields{Code: CONNECTION_ERROR, Url: "https://example.com/api/post", PostId: "123", Status: 404},
+		},
+		{
+			name: "outer fields take precedence over the wrapped ones",
+			err: WithLogFields(
+				fmt.Errorf("failed to download the file: %w", WithLogFields(errors.New("request failed"), LogFields{Url: "https://example.com/redirected", Status: 503})),
+				LogFields{Url: "https://example.com/file.jpg"},
+			),
+			want: LogFields{Url: "https://example.com/file.jpg", Status: 503},
+		},
+		{
+			name:   "fields of the call site",
+			msg:    "aborting the run",
+			fields: LogFields{Code: CONNECTION_ERROR, Url: "https://example.com"},
+			want:   LogFields{Code: CONNECTION_ERROR, Url: "https://example.com"},
+		},
+		{
+			name: "no fields are parsed from the message",
+			err:  errors.New("error 1000: failed to get post details for https://example.com/posts/123 due to a 404 response"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			jsonLogsMu.Lock()
+			jsonLogs = true
+			jsonLogsOutput = &out
+			jsonLogsMu.Unlock()
+			t.Cleanup(func() {
+				jsonLogsMu.Lock()
+				jsonLogs = false
+				jsonLogsOutput = os.Stdout
+				jsonLogsMu.Unlock()
+			})
+
+			LogErrorFields(test.err, test.msg, false, INFO, test.fields)
+
+			var entry jsonLogEntry
+			if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid JSON log entry %q, more info => %v", out.String(), err)
+			}
+			got := LogFields{Code: entry.Code, Url: entry.Url, PostId: entry.PostId, Status: entry.Status}
+			if got != test.want {
+				t.Errorf("log fields = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const KEYRING_SERVICE = "Cultured-Downloader-CLI"
+
+var (
+	// ErrKeyringUnavailable is returned when the OS keyring cannot be used, e.g. "secret-tool" is not installed on Linux
+	ErrKeyringUnavailable = errors.New("the system keyring is not available")
+
+	// ErrKeyringNotFound is returned when there is no session saved in the keyring for the platform
+	ErrKeyringNotFound = errors.New("no session found in the system keyring")
+
+	// The platforms whose session cookies can be saved in the keyring with their accepted names
+	keyringPlatforms = map[string]string{
+		FANTIA:         FANTIA,
+		PIXIV:          PIXIV,
+		PIXIV_FANBOX:   PIXIV_FANBOX,
+		"pixiv_fanbox": PIXIV_FANBOX,
+		KEMONO:         KEMONO,
+	}
+)
+
+// GetKeyringPlatform returns the platform of the given name, e.g. "fanbox" or "pixiv_fanbox",
+// for the keyring or an error if the platform is not supported.
+func GetKeyringPlatform(name string) (string, error) {
+	platform, ok := keyringPlatforms[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf(
+			"error %d: unsupported platform %q, expected one of fantia, pixiv, fanbox, or kemono",
+			INPUT_ERROR,
+			name,
+		)
+	}
+	return platform, nil
+}
+
+// SetKeyringSession saves the session cookie value of the platform in the OS keyring
+func SetKeyringSession(platform, session string) error {
+	if err := keyringSet(platform, session); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to save the %s session to the system keyring, more info => %w",
+			OS_ERROR,
+			platform,
+			err,
+		)
+	}
+	return nil
+}
+
+// GetKeyringSession returns the session cookie value of the platform saved in the OS keyring.
+//
+// ErrKeyringUnavailable or ErrKeyringNotFound will be returned (wrapped) if there is no session to use.
+func GetKeyringSession(platform string) (string, error) {
+	session, err := keyringGet(platform)
+	if err != nil {
+		return "", fmt.Errorf(
+			"error %d: failed to get the %s session from the system keyring, more info => %w",
+			OS_ERROR,
+			platform,
+			err,
+		)
+	}
+	return session, nil
+}
+
+// RemoveKeyringSession removes the session cookie value of the platform from the OS keyring
+func RemoveKeyringSession(platform string) error {
+	if err := keyringDelete(platform); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to remove the %s session from the system keyring, more info => %w",
+			OS_ERROR,
+			platform,
+			err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+//go:build !windows
+
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Runs the keyring command, e.g. "security" on macOS or "secret-tool" on Linux, and returns its output
+func runKeyringCmd(stdin string, name string, args ...string) (string, error) {
+	execPath, err := exec.LookPath(name)
+	if err != nil {
+		return "", ErrKeyringUnavailable
+	}
+
+	cmd := exec.Command(execPath, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() == 0 && stdout.Len() == 0 {
+			// both tools exit with an error without any output if the item does not exist
+			return "", ErrKeyringNotFound
+		}
+		if runtime.GOOS == "darwin" && errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrKeyringNotFound
+		}
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(args) > 0 && args[0] == "-i" && stderr.Len() > 0 {
+		// the interactive mode of "security" exits successfully even if the command has failed
+		return "", errors.New(strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func keyringSet(platform, secret string) error {
+	var err error
+	if runtime.GOOS == "darwin" {
+		// the command is read from the stdin in the interactive mode of the "security" tool
+		// with the secret in hexadecimal so that it is not exposed in the command line arguments
+		_, err = runKeyringCmd(
+			fmt.Sprintf(
+				"add-generic-password -U -s %s -a %s -X %s\n",
+				KEYRING_SERVICE,
+				platform,
+				hex.EncodeToString([]byte(secret)),
+			),
+			"security", "-i",
+		)
+	} else {
+		_, err = runKeyringCmd(
+			secret, "secret-tool", "store",
+			"--label="+KEYRING_SERVICE+" "+platform+" session",
+			"service", KEYRING_SERVICE, "account", platform,
+		)
+	}
+	return err
+}
+
+func keyringGet(platform string) (string, error) {
+	var secret string
+	var err error
+	if runtime.GOOS == "darwin" {
+		secret, err = runKeyringCmd(
+			"", "security", "find-generic-password",
+			"-s", KEYRING_SERVICE, "-a", platform, "-w",
+		)
+	} else {
+		secret, err = runKeyringCmd(
+			"", "secret-tool", "lookup",
+			"service", KEYRING_SERVICE, "account", platform,
+		)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secret = strings.TrimRight(secret, "\r\n")
+	if secret == "" {
+		return "", ErrKeyringNotFound
+	}
+	return secret, nil
+}
+
+func keyringDelete(platform string) error {
+	var err error
+	if runtime.GOOS == "darwin" {
+		_, err = runKeyringCmd(
+			"", "security", "delete-generic-password",
+			"-s", KEYRING_SERVICE, "-a", platform,
+		)
+	} else {
+		// secret-tool does not report if the item did not exist
+		_, err = runKeyringCmd(
+			"", "secret-tool", "clear",
+			"service", KEYRING_SERVICE, "account", platform,
+		)
+		if err == ErrKeyringNotFound {
+			err = nil
+		}
+	}
+	return err
+}
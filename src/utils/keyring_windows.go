@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32      = syscall.NewLazyDLL("advapi32.dll")
+	procCredWrite = advapi32.NewProc("CredWriteW")
+	procCredRead  = advapi32.NewProc("CredReadW")
+	procCredDel   = advapi32.NewProc("CredDeleteW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+// credential is the CREDENTIALW struct of the Windows Credential Manager
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// Returns the target name of the platform's credential in the Windows Credential Manager
+func getCredTarget(platform string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(KEYRING_SERVICE + ":" + platform)
+}
+
+func keyringSet(platform, secret string) error {
+	target, err := getCredTarget(platform)
+	if err != nil {
+		return err
+	}
+	userName, err := syscall.UTF16PtrFromString(platform)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           userName,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	ret, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+func keyringGet(platform string) (string, error) {
+	target, err := getCredTarget(platform)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	ret, _, err := procCredRead.Call(
+		uintptr(unsafe.Pointer(target)),
+		credTypeGeneric,
+		0,
+		uintptr(unsafe.Pointer(&cred)),
+	)
+	if ret == 0 {
+		if err == errorNotFound {
+			return "", ErrKeyringNotFound
+		}
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return "", ErrKeyringNotFound
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func keyringDelete(platform string) error {
+	target, err := getCredTarget(platform)
+	if err != nil {
+		return err
+	}
+
+	ret, _, err := procCredDel.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+	if ret == 0 && err != errorNotFound {
+		return err
+	}
+	return nil
+}
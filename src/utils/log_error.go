@@ -15,7 +15,23 @@ import (
 
 const LogSuffix = "\n\n"
 var (
-	mainLogger *logger
+	// loggedErrs holds the errors logged at the ERROR level in this run for the run summary
+	loggedErrs   []string
+	loggedErrsMu sync.Mutex
+
+	mainLogger   *logger
+	mainLoggerMu sync.Mutex
+	logFile      *os.File
+	logFolder    string
+	logFilePath  string
+)
+
+func init() {
+	setLogPaths()
+}
+
+// Sets the log folder and log file path based on the current APP_PATH
+func setLogPaths() {
 	logFolder = filepath.Join(APP_PATH, "logs")
 	logFilePath = filepath.Join(
 		logFolder,
@@ -25,11 +41,20 @@ var (
 			time.Now().Format("2006-01-02"),
 		),
 	)
-)
+}
 
-func init() {
-	// will be opened througout the program's runtime
-	// hence, there is no need to call f.Close() at the end of this function
+// Returns the main logger and opens the log file on the first call
+//
+// The log file will be opened througout the program's runtime
+// hence, there is no need to call f.Close() after logging.
+func getMainLogger() *logger {
+	mainLoggerMu.Lock()
+	defer mainLoggerMu.Unlock()
+	if mainLogger != nil {
+		return mainLogger
+	}
+
+	os.MkdirAll(logFolder, 0700)
 	f, fileErr := os.OpenFile(
 		logFilePath, 
 		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 
@@ -44,12 +69,31 @@ func init() {
 		log.Println(color.RedString(fileErr.Error()))
 		os.Exit(1)
 	}
+	logFile = f
 	mainLogger = NewLogger(f)
+	return mainLogger
+}
+
+// Closes the current log file so that the next log
+// will be written to the log folder of the new APP_PATH
+func resetMainLogger() {
+	mainLoggerMu.Lock()
+	defer mainLoggerMu.Unlock()
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+	mainLogger = nil
+	setLogPaths()
 }
 
 // Delete all empty log files and log files
 // older than 30 days except for the current day's log file.
 func DeleteEmptyAndOldLogs() error {
+	if !PathExists(logFolder) {
+		return nil
+	}
+
 	err := filepath.Walk(logFolder, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -74,23 +118,51 @@ func DeleteEmptyAndOldLogs() error {
 }
 
 // Thread-safe logging function that logs to "cultured_downloader.log" in the logs directory
+//
+// The contextual fields of the JSON logs are taken from the error if it carries them, see WithLogFields.
 func LogError(err error, errorMsg string, exit bool, level int) {
+	LogErrorFields(err, errorMsg, exit, level, LogFields{})
+}
+
+// Same as LogError but with the contextual fields of the JSON log entry which
+// take precedence over the ones carried by the error, e.g. for the logs without an error.
+func LogErrorFields(err error, errorMsg string, exit bool, level int, fields LogFields) {
 	if err == nil && errorMsg == "" {
 		return
 	}
 
-	if err != nil && errorMsg != "" {
-		mainLogger.LogBasedOnLvl(level, err.Error() + LogSuffix)
+	if JsonLogsEnabled() {
+		fields.merge(getErrLogFields(err))
+		if err != nil {
+			writeJsonLog(level, err.Error(), errorMsg, fields)
+		} else {
+			writeJsonLog(level, errorMsg, "", fields)
+		}
+	} else if err != nil && errorMsg != "" {
+		getMainLogger().LogBasedOnLvl(level, err.Error() + LogSuffix)
 		if errorMsg != "" {
-			mainLogger.LogBasedOnLvlf(level, "Additional info: %v%s", errorMsg, LogSuffix)
+			getMainLogger().LogBasedOnLvlf(level, "Additional info: %v%s", errorMsg, LogSuffix)
 		}
 	} else if err != nil {
-		mainLogger.LogBasedOnLvl(level, err.Error() + LogSuffix)
+		getMainLogger().LogBasedOnLvl(level, err.Error() + LogSuffix)
 	} else {
-		mainLogger.LogBasedOnLvlf(level, errorMsg + LogSuffix)
+		getMainLogger().LogBasedOnLvlf(level, errorMsg + LogSuffix)
+	}
+
+	if level == ERROR {
+		loggedErrsMu.Lock()
+		if err != nil {
+			loggedErrs = append(loggedErrs, err.Error())
+		} else {
+			loggedErrs = append(loggedErrs, errorMsg)
+		}
+		loggedErrsMu.Unlock()
 	}
 
 	if exit {
+		if JsonLogsEnabled() {
+			os.Exit(1)
+		}
 		if err != nil {
 			color.Red(err.Error())
 		} else {
@@ -100,6 +172,16 @@ func LogError(err error, errorMsg string, exit bool, level int) {
 	}
 }
 
+// Returns a copy of the errors logged at the ERROR level so far in this run
+func GetLoggedErrors() []string {
+	loggedErrsMu.Lock()
+	defer loggedErrsMu.Unlock()
+
+	errs := make([]string, len(loggedErrs))
+	copy(errs, loggedErrs)
+	return errs
+}
+
 // Uses the thread-safe LogError() function to log a slice of errors or a channel of errors
 //
 // Also returns if any errors were due to context.Canceled which is caused by Ctrl + C.
@@ -146,7 +228,16 @@ func LogMessageToPath(message, filePath string, level int) {
 	logToPathMux.Lock()
 	defer logToPathMux.Unlock()
 
-	os.MkdirAll(filepath.Dir(filePath), 0666)
+	if err := MkdirAll(filepath.Dir(filePath)); err != nil {
+		err = fmt.Errorf(
+			"%v\nfile path: %s\noriginal message: %s",
+			err,
+			filePath,
+			message,
+		)
+		LogError(err, "", false, ERROR)
+		return
+	}
 	if PathExists(filePath) {
 		logFileContents, err := os.ReadFile(filePath)
 		if err != nil {
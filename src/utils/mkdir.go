@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// noMkdir requires the directories of the downloads to already exist instead of creating them
+var noMkdir bool
+
+// SetNoMkdir sets whether the downloads should fail if their directories do not already exist
+// instead of creating them so that only the pre-provisioned directory structure is used.
+func SetNoMkdir(enabled bool) {
+	noMkdir = enabled
+}
+
+// MkdirAll creates the directory of the downloads and any of its parents, like os.MkdirAll,
+// unless the no mkdir mode is enabled where an error is returned if the directory does not already exist.
+func MkdirAll(dirPath string) error {
+	if !noMkdir {
+		return os.MkdirAll(dirPath, 0755)
+	}
+
+	info, err := os.Stat(dirPath)
+	if err == nil && info.IsDir() {
+		return nil
+	}
+	return fmt.Errorf(
+		"error %d: the directory %s does not exist and will not be created as the \"--no_mkdir\" flag is used, "+
+			"please create it beforehand",
+		OS_ERROR,
+		dirPath,
+	)
+}
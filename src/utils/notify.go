@@ -14,11 +14,15 @@ import (
 var (
 	//go:embed icon.png
 	iconImg []byte
-	iconPath = filepath.Join(APP_PATH, "icon.png")
 )
 
 const Title = "Cultured Downloader CLI"
 
+// Returns the path of the notification icon in the app's config directory
+func getIconPath() string {
+	return filepath.Join(APP_PATH, "icon.png")
+}
+
 func writeIcon() error {
 	defer func() {
 		if iconImg != nil {
@@ -26,6 +30,7 @@ func writeIcon() error {
 		}
 	}()
 
+	iconPath := getIconPath()
 	if PathExists(iconPath) {
 		return nil
 	}
@@ -51,7 +56,7 @@ func Alert(title, message string) error {
 		)
 	}
 
-	if err := beeep.Alert(title, message, iconPath); err != nil {
+	if err := beeep.Alert(title, message, getIconPath()); err != nil {
 		return fmt.Errorf(
 			"error %d: unable to show notification => %v", 
 			UNEXPECTED_ERROR,
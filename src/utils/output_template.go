@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The date formats used by the platforms for the posts' published date
+var postDateLayouts = []string{
+	time.RFC3339,          // Pixiv Fanbox and Pixiv
+	time.RFC1123Z,         // Fantia
+	time.RFC1123,          // Kemono Party's older API
+	"2006-01-02T15:04:05", // Kemono Party
+}
+
+// ParsePostDate parses the published date of a post in any of the platforms' date formats
+func ParsePostDate(dateStr string) (time.Time, bool) {
+	if dateStr == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range postDateLayouts {
+		if parsedTime, err := time.Parse(layout, dateStr); err == nil {
+			return parsedTime, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// PostFolderInfo is the metadata of a post that can be used in the output template
+type PostFolderInfo struct {
+	Platform    string
+	Service     string // only for Kemono Party
+	CreatorName string
+	PostId      string
+	Title       string
+	Date        string // the published date of the post in any of the platforms' date formats
+}
+
+// The variables available in the output template
+type outputTemplateVars struct {
+	Platform    string
+	Service     string
+	CreatorName string
+	PostId      string
+	Title       string
+	Year        string
+	Month       string
+	Day         string
+	Date        string
+}
+
+// outputTemplate has a template for each path component, nil to use the default folder structure
+var outputTemplate []*template.Template
+
+// dateFormat is the Go reference time layout of the Date variable in the output template
+var dateFormat = DEFAULT_DATE_FORMAT
+
+// SetDateFormat validates and sets the Go reference time layout, e.g. "2006-01-02" or "20060102",
+// used to render the published date of the posts into the paths via the Date variable of the output template.
+func SetDateFormat(layout string) error {
+	if layout == "" {
+		dateFormat = DEFAULT_DATE_FORMAT
+		return nil
+	}
+
+	// a layout without any of the reference time's elements would render the same text for every date
+	refTime := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+	formatted := refTime.Format(layout)
+	if formatted == layout || formatted == time.Date(2002, time.March, 4, 5, 6, 7, 0, time.UTC).Format(layout) {
+		return fmt.Errorf(
+			"error %d: invalid date format %q, must be a Go reference time layout like \"2006-01-02\" or \"20060102\"",
+			INPUT_ERROR,
+			layout,
+		)
+	}
+	if strings.ContainsAny(formatted, `/\`) {
+		return fmt.Errorf(
+			"error %d: invalid date format %q, must not contain path separators",
+			INPUT_ERROR,
+			layout,
+		)
+	}
+	dateFormat = layout
+	return nil
+}
+
+// SetOutputTemplate parses and validates the template for the directory path of each post
+// which is relative to the download directory, e.g. "{{.Platform}}/{{.CreatorName}}/{{.Year}}/{{.PostId}}_{{.Title}}".
+//
+// Each path component is rendered and sanitised separately so that a title with slashes cannot create extra directories.
+func SetOutputTemplate(templateStr string) error {
+	outputTemplate = nil
+	if templateStr == "" {
+		return nil
+	}
+
+	var parsedTemplate []*template.Template
+	for idx, component := range strings.Split(filepath.ToSlash(templateStr), "/") {
+		if component == "" {
+			continue
+		}
+
+		componentTemplate, err := template.New(fmt.Sprintf("component_%d", idx)).Parse(component)
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: invalid output template %q, more info => %v",
+				INPUT_ERROR,
+				templateStr,
+				err,
+			)
+		}
+		parsedTemplate = append(parsedTemplate, componentTemplate)
+	}
+	if len(parsedTemplate) == 0 {
+		return fmt.Errorf(
+			"error %d: output template %q has no path components",
+			INPUT_ERROR,
+			templateStr,
+		)
+	}
+
+	// render with sample data to catch unknown variables at startup
+	outputTemplate = parsedTemplate
+	if _, err := renderOutputTemplate(&PostFolderInfo{Platform: "Platform"}); err != nil {
+		outputTemplate = nil
+		return fmt.Errorf(
+			"error %d: invalid output template %q, more info => %v",
+			INPUT_ERROR,
+			templateStr,
+			err,
+		)
+	}
+	return nil
+}
+
+// Renders the output template with the post's metadata into a sanitised relative path
+func renderOutputTemplate(info *PostFolderInfo) (string, error) {
+	vars := &outputTemplateVars{
+		Platform:    info.Platform,
+		Service:     info.Service,
+		CreatorName: info.CreatorName,
+		PostId:      info.PostId,
+		Title:       info.Title,
+		Year:        "unknown",
+		Month:       "unknown",
+		Day:         "unknown",
+		Date:        "unknown",
+	}
+	if postDate, ok := ParsePostDate(info.Date); ok {
+		vars.Year = postDate.Format("2006")
+		vars.Month = postDate.Format("01")
+		vars.Day = postDate.Format("02")
+		vars.Date = postDate.Format(dateFormat)
+	}
+
+	var components []string
+	for _, componentTemplate := range outputTemplate {
+		var sb strings.Builder
+		if err := componentTemplate.Execute(&sb, vars); err != nil {
+			return "", err
+		}
+		component := CleanPathName(sb.String())
+		if component == "" || component == "." || component == ".." {
+			continue
+		}
+		components = append(components, component)
+	}
+	return filepath.Join(components...), nil
+}
+
+// GetTemplatedPostFolder returns the folder path of the post based on the output template set by SetOutputTemplate
+// relative to the download path, otherwise the default "<platformDir>/<creator>/[<postId>] <title>" structure is used.
+func GetTemplatedPostFolder(downloadPath, platformDir string, info *PostFolderInfo) string {
+	if outputTemplate != nil {
+		if relPath, err := renderOutputTemplate(info); err == nil && relPath != "" {
+			return filepath.Join(downloadPath, relPath)
+		}
+	}
+	return GetPostFolder(
+		filepath.Join(downloadPath, platformDir),
+		info.CreatorName,
+		info.PostId,
+		info.Title,
+	)
+}
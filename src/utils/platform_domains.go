@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// platformDomains maps a platform to all of its associated domains, e.g. the domains of its CDNs,
+// where the subdomains of a domain, e.g. "downloads.fanbox.cc" for "fanbox.cc", are also matched.
+var platformDomains = struct {
+	mu      sync.RWMutex
+	domains map[string][]string
+}{
+	domains: map[string][]string{
+		FANTIA:       {"fantia.jp"},
+		PIXIV:        {"pixiv.net", "pximg.net"},
+		PIXIV_FANBOX: {"fanbox.cc"},
+		KEMONO:       {"kemono.party"},
+	},
+}
+
+// Returns the domain in lowercase without the leading dot of a cookie domain
+func normaliseDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// AddPlatformDomains adds the domains, e.g. the domain of a new CDN, to the associated domains of the platform
+// which are used to route the platform's proxy and to send its cookies to the matching hosts.
+func AddPlatformDomains(site string, domains []string) error {
+	platformDomains.mu.Lock()
+	defer platformDomains.mu.Unlock()
+
+	siteDomains, ok := platformDomains.domains[site]
+	if !ok {
+		return fmt.Errorf(
+			"error %d: unknown platform, %q, for domain configuration",
+			INPUT_ERROR,
+			site,
+		)
+	}
+
+	for _, domain := range domains {
+		domain = normaliseDomain(domain)
+		if domain == "" || strings.ContainsAny(domain, "/: ") {
+			return fmt.Errorf(
+				"error %d: invalid domain, %q, for %s, expected a domain like \"example.com\"",
+				INPUT_ERROR,
+				domain,
+				GetReadableSiteStr(site),
+			)
+		}
+		covered := false
+		for _, siteDomain := range siteDomains {
+			if hostMatchesDomain(domain, siteDomain) {
+				covered = true // already matched as a subdomain
+				break
+			}
+		}
+		if !covered {
+			siteDomains = append(siteDomains, domain)
+		}
+	}
+	platformDomains.domains[site] = siteDomains
+	return nil
+}
+
+// GetPlatformDomains returns a copy of the associated domains of the platform
+func GetPlatformDomains(site string) []string {
+	platformDomains.mu.RLock()
+	defer platformDomains.mu.RUnlock()
+	return append([]string(nil), platformDomains.domains[site]...)
+}
+
+// IsKnownPlatform returns true if the platform has any associated domains
+func IsKnownPlatform(site string) bool {
+	platformDomains.mu.RLock()
+	defer platformDomains.mu.RUnlock()
+	_, ok := platformDomains.domains[site]
+	return ok
+}
+
+// Checks if the host is the domain or one of its subdomains
+func hostMatchesDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// IsPlatformDomain returns true if the host, or the domain of a cookie, belongs to one of the platform's domains
+func IsPlatformDomain(site, host string) bool {
+	host = normaliseDomain(host)
+	platformDomains.mu.RLock()
+	defer platformDomains.mu.RUnlock()
+	for _, domain := range platformDomains.domains[site] {
+		if hostMatchesDomain(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPlatformFromHost returns the platform whose domains the host, or the domain of a cookie,
+// belongs to or an empty string if unknown
+func GetPlatformFromHost(host string) string {
+	host = normaliseDomain(host)
+	platformDomains.mu.RLock()
+	defer platformDomains.mu.RUnlock()
+	for site, domains := range platformDomains.domains {
+		for _, domain := range domains {
+			if hostMatchesDomain(host, domain) {
+				return site
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// PostSummary is the summary of a post used by the list command
+type PostSummary struct {
+	Creator   string `json:"creator"`
+	Id        string `json:"id"`
+	Title     string `json:"title"`
+	Date      string `json:"date"`
+	FileCount int    `json:"file_count"`
+}
+
+var ACCEPTED_LIST_OUTPUT_FORMATS = []string{
+	"table",
+	"json",
+}
+
+// PrintPostSummaries prints the post summaries to stdout as a table or JSON
+func PrintPostSummaries(posts []*PostSummary, outputFormat string) error {
+	if outputFormat == "json" {
+		if posts == nil {
+			posts = []*PostSummary{}
+		}
+		postsJson, err := MarshalJson(posts)
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: failed to marshal post summaries, more info => %v",
+				JSON_ERROR,
+				err,
+			)
+		}
+		fmt.Println(string(postsJson))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CREATOR\tPOST ID\tDATE\tFILES\tTITLE")
+	for _, post := range posts {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%d\t%s\n",
+			post.Creator,
+			post.Id,
+			post.Date,
+			post.FileCount,
+			post.Title,
+		)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+const (
+	// One file path per line
+	PRINT_FILES_LINES = "lines"
+
+	// File paths separated by a NUL character for "xargs -0"
+	PRINT_FILES_NUL = "nul"
+
+	// A JSON array of the file paths
+	PRINT_FILES_JSON = "json"
+)
+
+var ACCEPTED_PRINT_FILES = []string{
+	PRINT_FILES_LINES,
+	PRINT_FILES_NUL,
+	PRINT_FILES_JSON,
+}
+
+var (
+	// printFiles is the format of the downloaded file list to print to stdout, empty if disabled
+	printFiles string
+
+	downloadedFilesMu sync.Mutex
+	downloadedFiles   []string
+)
+
+// SetPrintFiles sets the format of the list of the downloaded file paths to print to stdout after the run
+// for other tools to consume. Set it to empty to disable it.
+//
+// The coloured console output, e.g. the progress spinners, will be written to stderr instead
+// so that stdout only contains the downloaded file list.
+func SetPrintFiles(format string) error {
+	format = strings.ToLower(format)
+	if format != "" && !SliceContains(ACCEPTED_PRINT_FILES, format) {
+		return fmt.Errorf(
+			"error %d: invalid downloaded file list format %q, expected one of %s",
+			INPUT_ERROR,
+			format,
+			strings.Join(ACCEPTED_PRINT_FILES, ", "),
+		)
+	}
+
+	printFiles = format
+	if format != "" {
+		color.Output = os.Stderr
+	}
+	return nil
+}
+
+// RecordDownloadedFile adds the file path to the list of the downloaded files to print after the run
+func RecordDownloadedFile(filePath string) {
+	if printFiles == "" {
+		return
+	}
+
+	downloadedFilesMu.Lock()
+	defer downloadedFilesMu.Unlock()
+	downloadedFiles = append(downloadedFiles, filePath)
+}
+
+// PrintDownloadedFiles prints the list of the downloaded file paths to stdout in the configured format
+func PrintDownloadedFiles() error {
+	if printFiles == "" {
+		return nil
+	}
+
+	downloadedFilesMu.Lock()
+	defer downloadedFilesMu.Unlock()
+
+	var output strings.Builder
+	switch printFiles {
+	case PRINT_FILES_LINES, PRINT_FILES_NUL:
+		separator := "\n"
+		if printFiles == PRINT_FILES_NUL {
+			separator = "\x00"
+		}
+		for _, filePath := range downloadedFiles {
+			output.WriteString(filePath + separator)
+		}
+	case PRINT_FILES_JSON:
+		filePaths := downloadedFiles
+		if filePaths == nil {
+			filePaths = []string{}
+		}
+		data, err := json.Marshal(filePaths)
+		if err != nil {
+			return fmt.Errorf(
+				"error %d: failed to marshal the downloaded file list, more info => %v",
+				JSON_ERROR,
+				err,
+			)
+		}
+		output.Write(data)
+		output.WriteString("\n")
+	}
+
+	if _, err := os.Stdout.WriteString(output.String()); err != nil {
+		return fmt.Errorf(
+			"error %d: failed to print the downloaded file list, more info => %v",
+			OS_ERROR,
+			err,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func()
+	shutdownOnce  sync.Once
+)
+
+// OnShutdown registers a function to flush any state, like caches or the run summary,
+// to disk before the program exits due to an interrupt, e.g. Ctrl+C.
+func OnShutdown(hook func()) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
+// RunShutdownHooks runs the registered shutdown hooks once in the order they were registered.
+//
+// It will stop waiting for the hooks after SHUTDOWN_TIMEOUT seconds so that the program can't hang forever.
+func RunShutdownHooks() {
+	shutdownOnce.Do(func() {
+		shutdownMu.Lock()
+		hooks := make([]func(), len(shutdownHooks))
+		copy(hooks, shutdownHooks)
+		shutdownMu.Unlock()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for _, hook := range hooks {
+				hook()
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(SHUTDOWN_TIMEOUT * time.Second):
+		}
+	})
+}
+
+// ExitGracefully runs the shutdown hooks before exiting the program with the given code
+func ExitGracefully(code int) {
+	RunShutdownHooks()
+	os.Exit(code)
+}
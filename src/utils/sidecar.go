@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Always refresh the sidecar files, e.g. the metadata JSON files, as the posts may have been edited
+	SIDECAR_OVERWRITE_ALWAYS = "always"
+
+	// Never overwrite existing sidecar files
+	SIDECAR_OVERWRITE_NEVER = "never"
+
+	// Only overwrite existing sidecar files if the media files are overwritten via the "--overwrite" flag
+	SIDECAR_OVERWRITE_MEDIA = "media"
+)
+
+var ACCEPTED_SIDECAR_OVERWRITE = []string{
+	SIDECAR_OVERWRITE_ALWAYS,
+	SIDECAR_OVERWRITE_NEVER,
+	SIDECAR_OVERWRITE_MEDIA,
+}
+
+// SidecarStorage is where the sidecar files are written to, e.g. the storage backend of the downloaded files
+type SidecarStorage interface {
+	// Exists checks if the file at the given path exists
+	Exists(filePath string) bool
+
+	// WriteFile writes the data to the file at the given path, creating its directory if needed
+	WriteFile(filePath string, data []byte) error
+}
+
+// localSidecarStorage writes the sidecar files to the local filesystem
+type localSidecarStorage struct{}
+
+func (localSidecarStorage) Exists(filePath string) bool {
+	return PathExists(filePath)
+}
+
+func (localSidecarStorage) WriteFile(filePath string, data []byte) error {
+	if err := MkdirAll(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0666)
+}
+
+// sidecarStorage is where the sidecar files are written to, defaults to the local filesystem
+var sidecarStorage SidecarStorage = localSidecarStorage{}
+
+// SetSidecarStorage sets where the sidecar files are written to, nil for the local filesystem
+func SetSidecarStorage(storage SidecarStorage) {
+	if storage == nil {
+		storage = localSidecarStorage{}
+	}
+	sidecarStorage = storage
+}
+
+// sidecarOverwrite is the overwrite policy of the sidecar files which is separate from the media files
+var sidecarOverwrite = SIDECAR_OVERWRITE_ALWAYS
+
+// SetSidecarOverwrite sets the overwrite policy of the sidecar files like the metadata JSON files
+func SetSidecarOverwrite(policy string) error {
+	policy = strings.ToLower(policy)
+	if !SliceContains(ACCEPTED_SIDECAR_OVERWRITE, policy) {
+		return fmt.Errorf(
+			"error %d: invalid sidecar overwrite policy %q, expected one of %s",
+			INPUT_ERROR,
+			policy,
+			strings.Join(ACCEPTED_SIDECAR_OVERWRITE, ", "),
+		)
+	}
+	sidecarOverwrite = policy
+	return nil
+}
+
+// WriteSidecarFile writes the data to the sidecar file at the given path based on the overwrite policy
+// where overwriteMedia is whether the media files of the same download are being overwritten.
+//
+// Returns false if the file already exists and was not overwritten.
+func WriteSidecarFile(filePath string, data []byte, overwriteMedia bool) (bool, error) {
+	if sidecarStorage.Exists(filePath) {
+		switch sidecarOverwrite {
+		case SIDECAR_OVERWRITE_NEVER:
+			return false, nil
+		case SIDECAR_OVERWRITE_MEDIA:
+			if !overwriteMedia {
+				return false, nil
+			}
+		}
+	}
+
+	if err := sidecarStorage.WriteFile(filePath, data); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -18,7 +18,7 @@ func PrintWarningMsg() {
 	color.Yellow("CAUTION:")
 	color.Yellow("Please do NOT terminate the program while it is downloading unless you really have to!")
 	color.Yellow("Doing so MAY result in incomplete downloads and corrupted files.")
-	fmt.Println()
+	fmt.Fprintln(color.Output)
 }
 
 // Returns a readable format of the website name for the user
@@ -186,6 +186,20 @@ func RemoveSliceDuplicates[T SliceTypes](s []T) []T {
 	return result
 }
 
+// Removes the items whose key has already been seen, e.g. on a previous page of a paginated API,
+// and adds the keys of the remaining items to the seen set.
+func RemoveSeenItems[T any, K SliceTypes](items []T, seen map[K]struct{}, getKey func(T) K) []T {
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		key := getKey(item)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 // Used for removing duplicate IDs with its corresponding page number from the given slices.
 //
 // Returns the the new idSlice and pageSlice with the duplicates removed.
@@ -282,6 +296,7 @@ func DetectGDriveLinks(text, postFolderPath string, isUrl, logUrls bool) bool {
 		return false
 	}
 
+	saveGdriveShortcuts(text, postFolderPath)
 	if isUrl {
 		gdriveText := fmt.Sprintf(
 			"Google Drive link detected: %s\n\n",
@@ -307,3 +322,52 @@ func DetectOtherExtDLLink(text, postFolderPath string) bool {
 	}
 	return false
 }
+
+var byteSizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// Parses a human readable size string like "50GB" or "1.5 TB" into bytes.
+//
+// Note: the units are based on powers of 1024.
+func ParseByteSize(sizeStr string) (int64, error) {
+	sizeStr = strings.ToUpper(strings.TrimSpace(sizeStr))
+	numEndIdx := strings.IndexFunc(sizeStr, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if numEndIdx == -1 {
+		numEndIdx = len(sizeStr)
+	}
+
+	num, err := strconv.ParseFloat(sizeStr[:numEndIdx], 64)
+	unit, ok := byteSizeUnits[strings.TrimSpace(sizeStr[numEndIdx:])]
+	if err != nil || !ok || num <= 0 {
+		return 0, fmt.Errorf(
+			"error %d: invalid size %q, expected a positive number with an optional unit of B, KB, MB, GB, or TB, e.g. \"50GB\"",
+			INPUT_ERROR,
+			sizeStr,
+		)
+	}
+	return int64(num * float64(unit)), nil
+}
+
+// Formats the size in bytes into a human readable size string like "1.50 GB"
+// with the same units, based on powers of 1024, as ParseByteSize.
+func FormatByteSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	unitIdx := 0
+	value := float64(size)
+	for value >= 1024 && unitIdx < len(units)-1 {
+		value /= 1024
+		unitIdx++
+	}
+	if unitIdx == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.2f %s", value, units[unitIdx])
+}